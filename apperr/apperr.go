@@ -0,0 +1,156 @@
+package apperr
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Kind エラーの種類 (HTTPのステータスコードに対応する)
+type Kind string
+
+const (
+	KindValidation    Kind = "validation"    // 入力が不正 (400)
+	KindUnauthorized  Kind = "unauthorized"  // 認証されていない (401)
+	KindForbidden     Kind = "forbidden"     // 権限が無い (403)
+	KindNotFound      Kind = "not_found"     // 存在しない (404)
+	KindConflict      Kind = "conflict"      // 既存のデータと矛盾する (409)
+	KindGone          Kind = "gone"          // 削除済み (410)
+	KindUnprocessable Kind = "unprocessable" // 形式は正しいが処理できない (422)
+	KindRateLimited   Kind = "rate_limited"  // 上限を超えた (429)
+	KindInternal      Kind = "internal"      // サーバー内部の失敗 (500)
+	KindUpstream      Kind = "upstream"      // 外部サービスの失敗 (502)
+	KindUnavailable   Kind = "unavailable"   // 一時的に利用できない (503)
+	KindTimeout       Kind = "timeout"       // 処理・外部サービスのタイムアウト (504)
+)
+
+// kindStatus 種類ごとのHTTPのステータスコード
+var kindStatus = map[Kind]int{
+	KindValidation:    http.StatusBadRequest,
+	KindUnauthorized:  http.StatusUnauthorized,
+	KindForbidden:     http.StatusForbidden,
+	KindNotFound:      http.StatusNotFound,
+	KindConflict:      http.StatusConflict,
+	KindGone:          http.StatusGone,
+	KindUnprocessable: http.StatusUnprocessableEntity,
+	KindRateLimited:   http.StatusTooManyRequests,
+	KindInternal:      http.StatusInternalServerError,
+	KindUpstream:      http.StatusBadGateway,
+	KindUnavailable:   http.StatusServiceUnavailable,
+	KindTimeout:       http.StatusGatewayTimeout,
+}
+
+// Status 種類に対応するHTTPのステータスコード
+func (k Kind) Status() int {
+	if status, ok := kindStatus[k]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+// FieldError 入力の項目ごとの不正な理由
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Field 項目の不正な理由を作成
+func Field(field, message string) FieldError {
+	return FieldError{Field: field, Message: message}
+}
+
+// Error 種類と機械で判別できるコードを持つエラー
+// Message はクライアントにそのまま返すため、内部の詳細 (SQLのエラーなど) は原因 (Unwrap) に持たせる
+type Error struct {
+	Kind       Kind
+	Code       string // エラーの識別子 (例: "post_not_found")
+	Message    string
+	Fields     []FieldError
+	RetryAfter time.Duration // 再試行できるまでの時間 (KindRateLimited, KindUnavailable で使う)
+	cause      error
+}
+
+// New エラーを作成
+func New(kind Kind, code, message string) *Error {
+	return &Error{Kind: kind, Code: code, Message: message}
+}
+
+// Validation 入力が不正なエラーを作成 (fields に項目ごとの理由を付けられる)
+func Validation(code, message string, fields ...FieldError) *Error {
+	return &Error{Kind: KindValidation, Code: code, Message: message, Fields: fields}
+}
+
+// NotFound 存在しないエラーを作成
+func NotFound(code, message string) *Error {
+	return New(KindNotFound, code, message)
+}
+
+// Gone 削除済みのエラーを作成
+func Gone(code, message string) *Error {
+	return New(KindGone, code, message)
+}
+
+// Conflict 既存のデータと矛盾するエラーを作成
+func Conflict(code, message string) *Error {
+	return New(KindConflict, code, message)
+}
+
+// Forbidden 権限が無いエラーを作成
+func Forbidden(code, message string) *Error {
+	return New(KindForbidden, code, message)
+}
+
+// RateLimited 上限を超えたエラーを作成
+func RateLimited(code, message string, retryAfter time.Duration) *Error {
+	return &Error{Kind: KindRateLimited, Code: code, Message: message, RetryAfter: retryAfter}
+}
+
+// Internal サーバー内部の失敗を作成 (message はクライアントに返す一般的な説明)
+func Internal(message string) *Error {
+	return New(KindInternal, "internal", message)
+}
+
+func (e *Error) Error() string {
+	if e.cause != nil {
+		return fmt.Sprintf("%s: %v", e.Message, e.cause)
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.cause
+}
+
+// Is 種類とコードが同じなら同じエラーとみなす (詳細や原因を付けた複製も errors.Is で判別できる)
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Kind == e.Kind && t.Code == e.Code
+}
+
+// Wrap 原因を付けた複製を返す
+func (e *Error) Wrap(cause error) *Error {
+	copied := *e
+	copied.cause = cause
+	return &copied
+}
+
+// Detailf メッセージに詳細を付け足した複製を返す
+func (e *Error) Detailf(format string, args ...interface{}) *Error {
+	copied := *e
+	copied.Message = e.Message + ": " + fmt.Sprintf(format, args...)
+	return &copied
+}
+
+// WithFields 項目ごとの理由を付けた複製を返す
+func (e *Error) WithFields(fields ...FieldError) *Error {
+	copied := *e
+	copied.Fields = append(append([]FieldError(nil), e.Fields...), fields...)
+	return &copied
+}
+
+// WithRetryAfter 再試行できるまでの時間を付けた複製を返す
+func (e *Error) WithRetryAfter(retryAfter time.Duration) *Error {
+	copied := *e
+	copied.RetryAfter = retryAfter
+	return &copied
+}
@@ -0,0 +1,127 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// Stats キャッシュのヒット・ミスなどの累計
+type Stats struct {
+	Hits          int64 `json:"hits"`
+	Misses        int64 `json:"misses"`
+	Evictions     int64 `json:"evictions"`
+	Invalidations int64 `json:"invalidations"`
+	Size          int   `json:"size"`
+	Capacity      int   `json:"capacity"`
+}
+
+// entry キャッシュの1件
+type entry[V any] struct {
+	key       string
+	tag       string // まとめて無効化するための目印 (ユーザーIDや投稿IDなど)
+	value     V
+	expiresAt time.Time
+}
+
+// LRU 容量を超えると最も長く使われていないものから捨てる、有効期限付きのキャッシュ
+// 複数の goroutine から同時に使える
+type LRU[V any] struct {
+	mu       sync.Mutex
+	capacity int
+	items    map[string]*list.Element
+	order    *list.List // 先頭が最近使われたもの
+	stats    Stats
+}
+
+// NewLRU 容量 capacity のキャッシュを作成
+func NewLRU[V any](capacity int) *LRU[V] {
+	return &LRU[V]{
+		capacity: capacity,
+		items:    make(map[string]*list.Element),
+		order:    list.New(),
+		stats:    Stats{Capacity: capacity},
+	}
+}
+
+// Get キーに対応する値を返す (無い・期限切れなら ok は false)
+func (c *LRU[V]) Get(key string) (value V, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, found := c.items[key]
+	if !found {
+		c.stats.Misses++
+		return value, false
+	}
+	e := element.Value.(*entry[V])
+	if time.Now().After(e.expiresAt) {
+		c.remove(element)
+		c.stats.Misses++
+		return value, false
+	}
+	c.order.MoveToFront(element)
+	c.stats.Hits++
+	return e.value, true
+}
+
+// Set 値を ttl の間保存する
+func (c *LRU[V]) Set(key, tag string, value V, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, found := c.items[key]; found {
+		e := element.Value.(*entry[V])
+		e.tag, e.value, e.expiresAt = tag, value, time.Now().Add(ttl)
+		c.order.MoveToFront(element)
+		return
+	}
+
+	c.items[key] = c.order.PushFront(&entry[V]{key: key, tag: tag, value: value, expiresAt: time.Now().Add(ttl)})
+	for c.order.Len() > c.capacity {
+		c.remove(c.order.Back())
+		c.stats.Evictions++
+	}
+}
+
+// Delete キーの値を削除
+func (c *LRU[V]) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, found := c.items[key]; found {
+		c.remove(element)
+		c.stats.Invalidations++
+	}
+}
+
+// DeleteTag 目印 tag が付いた値を全て削除
+func (c *LRU[V]) DeleteTag(tag string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for element := c.order.Front(); element != nil; {
+		next := element.Next()
+		if element.Value.(*entry[V]).tag == tag {
+			c.remove(element)
+			c.stats.Invalidations++
+		}
+		element = next
+	}
+}
+
+// Stats ヒット・ミスなどの累計を返す
+func (c *LRU[V]) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := c.stats
+	stats.Size = c.order.Len()
+	return stats
+}
+
+// remove ヘルパー関数: 要素を削除 (ロックを取った状態で呼ぶ)
+func (c *LRU[V]) remove(element *list.Element) {
+	c.order.Remove(element)
+	delete(c.items, element.Value.(*entry[V]).key)
+}
@@ -0,0 +1,343 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// fileEnvKey 設定ファイルのパスを指定する環境変数
+const fileEnvKey = "CONFIG_FILE"
+
+// Scope 設定を使うコマンド (コマンドで使わない必須項目は検証しない)
+type Scope int
+
+const (
+	ForServer  Scope = iota // サーバーの起動と設定の表示
+	ForMigrate              // マイグレーション (DB の設定のみ必須)
+)
+
+// Config 起動時に読み込む設定
+type Config struct {
+	Server     ServerConfig
+	DB         DBConfig
+	AI         AIConfig
+	Quota      QuotaConfig
+	Cache      CacheConfig
+	Prompt     PromptConfig
+	Image      ImageConfig
+	AdminToken string // 空なら管理者用エンドポイントは全て 403
+	File       string // 読み込んだ設定ファイル (無ければ空)
+}
+
+// ServerConfig HTTPサーバーの設定
+type ServerConfig struct {
+	Port              string
+	AllowedOrigins    []string // CORS で許可するオリジン
+	ReadHeaderTimeout time.Duration
+	ReadTimeout       time.Duration
+	WriteTimeout      time.Duration // AIの生成結果を逐次返すエンドポイントの締め切りより長くする
+	IdleTimeout       time.Duration
+	ShutdownTimeout   time.Duration // 終了のシグナルを受けてから処理中のリクエストを待つ最大時間
+}
+
+// DBConfig MySQL の接続と接続プールの設定
+type DBConfig struct {
+	User            string
+	Password        string
+	Host            string // 例: tcp(localhost:3306), unix(/cloudsql/<接続名>)
+	Database        string
+	MaxOpenConns    int // 0 なら無制限
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration // 0 なら無制限
+	ConnMaxIdleTime time.Duration // 0 なら無制限
+}
+
+// DSN MySQL ドライバに渡す接続文字列
+func (c DBConfig) DSN() string {
+	return fmt.Sprintf("%s:%s@%s/%s?parseTime=true", c.User, c.Password, c.Host, c.Database)
+}
+
+// AIConfig Vertex AI (Gemini・埋め込み) の設定
+type AIConfig struct {
+	ProjectID       string
+	Location        string
+	GenerationModel string
+	EmbeddingModel  string
+}
+
+// QuotaConfig AI使用量の上限 (0 は無制限)
+type QuotaConfig struct {
+	UserRequestsPerMinute int
+	UserRequestsPerDay    int
+	UserTokensPerDay      int
+	GlobalRequestsPerDay  int
+	GlobalTokensPerDay    int
+}
+
+// CacheConfig AI生成結果のキャッシュの設定
+type CacheConfig struct {
+	Size    int
+	Persist bool // true なら ai_cache テーブルにも保存する
+}
+
+// PromptConfig プロンプトテンプレートの設定
+type PromptConfig struct {
+	Versions           string // 例: "generate_bio=2,summarize_thread=1"
+	ContextTokenBudget int
+	MaxInstructionLen  int
+	MaxTempTextLen     int
+}
+
+// ImageConfig 画像の取得の設定
+type ImageConfig struct {
+	AllowedHosts []string // 空なら https の公開アドレスならどのホストでもよい
+}
+
+// setting 1つの設定項目 (環境変数名・デフォルト値・読み込み先)
+type setting struct {
+	key          string
+	defaultValue string
+	required     bool
+	serverOnly   bool // 必須なのはサーバーを起動するときのみ
+	secret       bool // 表示するときに値を伏せる
+	parse        func(raw string) error
+	format       func() string
+}
+
+// settings ヘルパー関数: 全ての設定項目を表示する順に返す
+func (c *Config) settings() []setting {
+	return []setting{
+		stringSetting("PORT", "8080", false, false, &c.Server.Port),
+		listSetting("CORS_ALLOWED_ORIGINS", "*", &c.Server.AllowedOrigins),
+		durationSetting("SERVER_READ_HEADER_TIMEOUT", "10s", &c.Server.ReadHeaderTimeout),
+		durationSetting("SERVER_READ_TIMEOUT", "30s", &c.Server.ReadTimeout),
+		durationSetting("SERVER_WRITE_TIMEOUT", "150s", &c.Server.WriteTimeout),
+		durationSetting("SERVER_IDLE_TIMEOUT", "120s", &c.Server.IdleTimeout),
+		durationSetting("SERVER_SHUTDOWN_TIMEOUT", "15s", &c.Server.ShutdownTimeout),
+
+		stringSetting("MYSQL_USER", "", true, false, &c.DB.User),
+		stringSetting("MYSQL_PWD", "", false, true, &c.DB.Password),
+		stringSetting("MYSQL_HOST", "", true, false, &c.DB.Host),
+		stringSetting("MYSQL_DATABASE", "", true, false, &c.DB.Database),
+		intSetting("DB_MAX_OPEN_CONNS", "25", 0, &c.DB.MaxOpenConns),
+		intSetting("DB_MAX_IDLE_CONNS", "10", 0, &c.DB.MaxIdleConns),
+		durationSetting("DB_CONN_MAX_LIFETIME", "5m", &c.DB.ConnMaxLifetime),
+		durationSetting("DB_CONN_MAX_IDLE_TIME", "1m", &c.DB.ConnMaxIdleTime),
+
+		serverOnly(stringSetting("GCP_PROJECT_ID", "", true, false, &c.AI.ProjectID)),
+		stringSetting("GCP_LOCATION", "asia-northeast1", false, false, &c.AI.Location),
+		stringSetting("GEMINI_MODEL", "gemini-1.5-flash-002", false, false, &c.AI.GenerationModel),
+		stringSetting("EMBEDDING_MODEL", "text-multilingual-embedding-002", false, false, &c.AI.EmbeddingModel),
+
+		intSetting("AI_USER_REQUESTS_PER_MINUTE", "10", 0, &c.Quota.UserRequestsPerMinute),
+		intSetting("AI_USER_REQUESTS_PER_DAY", "100", 0, &c.Quota.UserRequestsPerDay),
+		intSetting("AI_USER_TOKENS_PER_DAY", "200000", 0, &c.Quota.UserTokensPerDay),
+		intSetting("AI_GLOBAL_REQUESTS_PER_DAY", "10000", 0, &c.Quota.GlobalRequestsPerDay),
+		intSetting("AI_GLOBAL_TOKENS_PER_DAY", "20000000", 0, &c.Quota.GlobalTokensPerDay),
+
+		intSetting("AI_CACHE_SIZE", "1000", 1, &c.Cache.Size),
+		boolSetting("AI_CACHE_PERSIST", "false", &c.Cache.Persist),
+
+		stringSetting("PROMPT_VERSIONS", "", false, false, &c.Prompt.Versions),
+		intSetting("PROMPT_CONTEXT_TOKEN_BUDGET", "4000", 1, &c.Prompt.ContextTokenBudget),
+		intSetting("PROMPT_MAX_INSTRUCTION_LEN", "200", 1, &c.Prompt.MaxInstructionLen),
+		intSetting("PROMPT_MAX_TEMP_TEXT_LEN", "280", 1, &c.Prompt.MaxTempTextLen),
+
+		listSetting("IMAGE_ALLOWED_HOSTS", "", &c.Image.AllowedHosts),
+
+		stringSetting("ADMIN_TOKEN", "", false, true, &c.AdminToken),
+	}
+}
+
+// Load 設定を読み込んで検証する
+// 値は環境変数、設定ファイル (環境変数 CONFIG_FILE で指定した JSON)、デフォルト値の順に優先する
+// 設定ファイルは環境変数と同じ名前をキーにしたオブジェクト (例: {"MYSQL_HOST": "tcp(localhost:3306)"})
+// 不正な項目があれば全ての理由をまとめて返す (scope のコマンドで使わない必須項目は未設定でもよい)
+func Load(scope Scope) (*Config, error) {
+	c := &Config{File: os.Getenv(fileEnvKey)}
+	fileValues, err := readFile(c.File)
+	if err != nil {
+		return nil, err
+	}
+
+	var problems []string
+	for _, s := range c.settings() {
+		raw, ok := os.LookupEnv(s.key)
+		if !ok {
+			raw, ok = fileValues[s.key]
+		}
+		if !ok {
+			raw = s.defaultValue
+		}
+		raw = strings.TrimSpace(raw)
+		if raw == "" && s.required {
+			if s.serverOnly && scope != ForServer {
+				continue
+			}
+			problems = append(problems, fmt.Sprintf("%s は必須項目です", s.key))
+			continue
+		}
+		if err := s.parse(raw); err != nil {
+			problems = append(problems, fmt.Sprintf("%s %v: %q", s.key, err, raw))
+		}
+	}
+	var unknown []string
+	for key := range fileValues {
+		if !c.known(key) {
+			unknown = append(unknown, key)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		problems = append(problems, fmt.Sprintf("設定ファイルに不明な項目があります: %s", strings.Join(unknown, ", ")))
+	}
+	if c.DB.MaxOpenConns > 0 && c.DB.MaxIdleConns > c.DB.MaxOpenConns {
+		problems = append(problems, "DB_MAX_IDLE_CONNS は DB_MAX_OPEN_CONNS 以下で指定してください")
+	}
+
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("設定が不正です:\n  - %s", strings.Join(problems, "\n  - "))
+	}
+	return c, nil
+}
+
+// Print 実際に使う設定を1行1項目で出力する (パスワードやトークンは伏せる)
+func (c *Config) Print(w io.Writer) {
+	if c.File != "" {
+		fmt.Fprintf(w, "%s=%s\n", fileEnvKey, c.File)
+	}
+	for _, s := range c.settings() {
+		value := s.format()
+		if s.secret && value != "" {
+			value = "********"
+		}
+		fmt.Fprintf(w, "%s=%s\n", s.key, value)
+	}
+}
+
+// known ヘルパー関数: 設定項目の名前か
+func (c *Config) known(key string) bool {
+	for _, s := range c.settings() {
+		if s.key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// readFile ヘルパー関数: 設定ファイルを読み込む (path が空なら何もしない)
+// 値は文字列・数値・真偽値のいずれかで書ける
+func readFile(path string) (map[string]string, error) {
+	if path == "" {
+		return nil, nil
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("設定ファイルの読み込み失敗: %w", err)
+	}
+	var raw map[string]interface{}
+	if err := json.Unmarshal(content, &raw); err != nil {
+		return nil, fmt.Errorf("設定ファイル %s の JSON が不正です: %w", path, err)
+	}
+
+	values := make(map[string]string, len(raw))
+	for key, value := range raw {
+		switch v := value.(type) {
+		case string:
+			values[key] = v
+		case float64, bool:
+			values[key] = fmt.Sprint(v)
+		default:
+			return nil, fmt.Errorf("設定ファイル %s の %s は文字列・数値・真偽値で指定してください", path, key)
+		}
+	}
+	return values, nil
+}
+
+// serverOnly ヘルパー関数: サーバーを起動するときのみ必須の設定項目にする
+func serverOnly(s setting) setting {
+	s.serverOnly = true
+	return s
+}
+
+// stringSetting ヘルパー関数: 文字列の設定項目
+func stringSetting(key, defaultValue string, required, secret bool, target *string) setting {
+	return setting{
+		key: key, defaultValue: defaultValue, required: required, secret: secret,
+		parse:  func(raw string) error { *target = raw; return nil },
+		format: func() string { return *target },
+	}
+}
+
+// intSetting ヘルパー関数: min 以上の整数の設定項目
+func intSetting(key, defaultValue string, min int, target *int) setting {
+	return setting{
+		key: key, defaultValue: defaultValue,
+		parse: func(raw string) error {
+			value, err := strconv.Atoi(raw)
+			if err != nil || value < min {
+				return fmt.Errorf("は%d以上の整数で指定してください", min)
+			}
+			*target = value
+			return nil
+		},
+		format: func() string { return strconv.Itoa(*target) },
+	}
+}
+
+// durationSetting ヘルパー関数: 0以上の時間の設定項目 (例: 30s, 5m)
+func durationSetting(key, defaultValue string, target *time.Duration) setting {
+	return setting{
+		key: key, defaultValue: defaultValue,
+		parse: func(raw string) error {
+			value, err := time.ParseDuration(raw)
+			if err != nil || value < 0 {
+				return errors.New("は 30s や 5m の形式の0以上の時間で指定してください")
+			}
+			*target = value
+			return nil
+		},
+		format: func() string { return target.String() },
+	}
+}
+
+// boolSetting ヘルパー関数: true または false の設定項目
+func boolSetting(key, defaultValue string, target *bool) setting {
+	return setting{
+		key: key, defaultValue: defaultValue,
+		parse: func(raw string) error {
+			value, err := strconv.ParseBool(raw)
+			if err != nil {
+				return errors.New("は true または false で指定してください")
+			}
+			*target = value
+			return nil
+		},
+		format: func() string { return strconv.FormatBool(*target) },
+	}
+}
+
+// listSetting ヘルパー関数: カンマ区切りの設定項目 (空の要素は除く)
+func listSetting(key, defaultValue string, target *[]string) setting {
+	return setting{
+		key: key, defaultValue: defaultValue,
+		parse: func(raw string) error {
+			var values []string
+			for _, value := range strings.Split(raw, ",") {
+				if value = strings.TrimSpace(value); value != "" {
+					values = append(values, value)
+				}
+			}
+			*target = values
+			return nil
+		},
+		format: func() string { return strings.Join(*target, ",") },
+	}
+}
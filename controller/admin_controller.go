@@ -0,0 +1,195 @@
+package controller
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"twitter/apperr"
+	"twitter/usecase"
+
+	"github.com/gorilla/mux"
+)
+
+// RestrictionRequest 制限追加リクエストボディの構造体
+type RestrictionRequest struct {
+	Reason        string `json:"reason"`
+	DurationHours int    `json:"duration_hours"` // 0 なら無期限
+}
+
+// QuotaOverrideRequest AI使用量の上限の上書きリクエストボディの構造体
+type QuotaOverrideRequest struct {
+	RequestsPerDay int    `json:"requests_per_day"` // 0 なら無制限
+	TokensPerDay   int    `json:"tokens_per_day"`   // 0 なら無制限
+	Reason         string `json:"reason"`
+}
+
+// errAdminForbidden 管理者トークンが無い、または一致しない
+var errAdminForbidden = apperr.Forbidden("admin_forbidden", "管理者権限がありません")
+
+// AdminController 管理者用エンドポイントのコントローラ
+type AdminController struct {
+	adminUseCase *usecase.AdminUseCase
+	adminToken   string
+}
+
+// NewAdminController コントローラの初期化 (adminToken が空なら全てのリクエストを拒否する)
+func NewAdminController(useCase *usecase.AdminUseCase, adminToken string) *AdminController {
+	return &AdminController{adminUseCase: useCase, adminToken: adminToken}
+}
+
+// authorize ヘルパー関数: Authorization ヘッダの管理者トークンを検証
+func (c *AdminController) authorize(w http.ResponseWriter, r *http.Request) bool {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if c.adminToken == "" || subtle.ConstantTimeCompare([]byte(token), []byte(c.adminToken)) != 1 {
+		log.Printf("[admin_controller.go] 管理者認証失敗 (パス: %s)", r.URL.Path)
+		writeError(w, errAdminForbidden, "")
+		return false
+	}
+	return true
+}
+
+// HandleRestrictUser ユーザーを凍結またはシャドウバンする
+func (c *AdminController) HandleRestrictUser(w http.ResponseWriter, r *http.Request) {
+	if !c.authorize(w, r) {
+		return
+	}
+	vars := mux.Vars(r)
+	kind := vars["kind"]
+	userID := vars["user_id"]
+
+	var req RestrictionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("[admin_controller.go] JSONデコード失敗: %v", err)
+		writeError(w, errInvalidJSON.Wrap(err), "")
+		return
+	}
+
+	restriction, err := c.adminUseCase.RestrictUser(r.Context(), userID, kind, req.Reason, req.DurationHours)
+	if err != nil {
+		log.Printf("[admin_controller.go] 制限追加失敗 (user_id: %s, kind: %s): %v", userID, kind, err)
+		writeError(w, err, "制限の追加に失敗しました")
+		return
+	}
+
+	resp, err := json.Marshal(restriction)
+	if err != nil {
+		log.Printf("[admin_controller.go] JSONエンコード失敗: %v", err)
+		writeError(w, err, "レスポンス生成に失敗しました")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	w.Write(resp)
+}
+
+// HandleLiftRestriction ユーザーの凍結またはシャドウバンを解除する
+func (c *AdminController) HandleLiftRestriction(w http.ResponseWriter, r *http.Request) {
+	if !c.authorize(w, r) {
+		return
+	}
+	vars := mux.Vars(r)
+	kind := vars["kind"]
+	userID := vars["user_id"]
+
+	if err := c.adminUseCase.LiftRestriction(r.Context(), userID, kind); err != nil {
+		log.Printf("[admin_controller.go] 制限解除失敗 (user_id: %s, kind: %s): %v", userID, kind, err)
+		writeError(w, err, "制限の解除に失敗しました")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandleGetRestrictions ユーザーの制限履歴を取得する
+func (c *AdminController) HandleGetRestrictions(w http.ResponseWriter, r *http.Request) {
+	if !c.authorize(w, r) {
+		return
+	}
+	vars := mux.Vars(r)
+	userID := vars["user_id"]
+
+	restrictions, err := c.adminUseCase.GetRestrictions(r.Context(), userID)
+	if err != nil {
+		log.Printf("[admin_controller.go] 制限履歴取得失敗 (user_id: %s): %v", userID, err)
+		writeError(w, err, "制限履歴の取得に失敗しました")
+		return
+	}
+
+	resp, err := json.Marshal(restrictions)
+	if err != nil {
+		log.Printf("[admin_controller.go] JSONエンコード失敗: %v", err)
+		writeError(w, err, "レスポンス生成に失敗しました")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(resp)
+}
+
+// HandleSetQuotaOverride ユーザーのAI使用量の1日の上限を上書きする
+func (c *AdminController) HandleSetQuotaOverride(w http.ResponseWriter, r *http.Request) {
+	if !c.authorize(w, r) {
+		return
+	}
+	userID := mux.Vars(r)["user_id"]
+
+	var req QuotaOverrideRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("[admin_controller.go] JSONデコード失敗: %v", err)
+		writeError(w, errInvalidJSON.Wrap(err), "")
+		return
+	}
+
+	override, err := c.adminUseCase.SetQuotaOverride(r.Context(), userID, req.RequestsPerDay, req.TokensPerDay, req.Reason)
+	if err != nil {
+		log.Printf("[admin_controller.go] 上限設定失敗 (user_id: %s): %v", userID, err)
+		writeError(w, err, "上限の設定に失敗しました")
+		return
+	}
+
+	resp, err := json.Marshal(override)
+	if err != nil {
+		log.Printf("[admin_controller.go] JSONエンコード失敗: %v", err)
+		writeError(w, err, "レスポンス生成に失敗しました")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(resp)
+}
+
+// HandleDeleteQuotaOverride ユーザーのAI使用量の上限の上書きを削除する
+func (c *AdminController) HandleDeleteQuotaOverride(w http.ResponseWriter, r *http.Request) {
+	if !c.authorize(w, r) {
+		return
+	}
+	userID := mux.Vars(r)["user_id"]
+
+	if err := c.adminUseCase.DeleteQuotaOverride(r.Context(), userID); err != nil {
+		log.Printf("[admin_controller.go] 上限削除失敗 (user_id: %s): %v", userID, err)
+		writeError(w, err, "上限の削除に失敗しました")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandleGetCacheStats AI生成結果のキャッシュの統計を取得する
+func (c *AdminController) HandleGetCacheStats(w http.ResponseWriter, r *http.Request) {
+	if !c.authorize(w, r) {
+		return
+	}
+
+	resp, err := json.Marshal(c.adminUseCase.GetCacheStats())
+	if err != nil {
+		log.Printf("[admin_controller.go] JSONエンコード失敗: %v", err)
+		writeError(w, err, "レスポンス生成に失敗しました")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(resp)
+}
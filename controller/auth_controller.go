@@ -6,6 +6,8 @@ import (
 	"net/http"
 	"twitter/model"
 	"twitter/usecase"
+
+	"github.com/gorilla/mux"
 )
 
 type AuthController struct {
@@ -21,7 +23,7 @@ func (c *AuthController) Handle(w http.ResponseWriter, r *http.Request) {
 	var user model.User
 	if err := json.NewDecoder(r.Body).Decode(&user); err != nil {
 		log.Printf("[auth_controller.go] JSONデコード失敗: %v", err)
-		http.Error(w, "リクエストの形式が不正です", http.StatusBadRequest)
+		writeError(w, errInvalidJSON.Wrap(err), "")
 		return
 	}
 
@@ -37,12 +39,40 @@ func (c *AuthController) Handle(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// ユーザー登録
-	if _, err := c.AuthUseCase.RegisterUser(user.UserID, user.Name, bio, profileImgURL); err != nil {
+	if _, err := c.AuthUseCase.RegisterUser(r.Context(), user.UserID, user.Name, bio, profileImgURL); err != nil {
 		log.Printf("[auth_controller.go] ユーザー登録失敗: %v", err)
-		http.Error(w, "ユーザー登録に失敗しました", http.StatusInternalServerError)
+		writeError(w, err, "ユーザー登録に失敗しました")
 		return
 	}
 
 	// 成功時はステータスコードのみを返す
 	w.WriteHeader(http.StatusCreated)
 }
+
+// HandleCheckLogin ログイン可否確認ハンドラー (凍結中なら 403 と凍結情報を返す)
+func (c *AuthController) HandleCheckLogin(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	userID := vars["user_id"]
+
+	restriction, err := c.AuthUseCase.CheckLogin(r.Context(), userID)
+	if err != nil {
+		log.Printf("[auth_controller.go] ログイン可否確認失敗 (user_id: %s): %v", userID, err)
+		writeError(w, err, "ログイン可否の確認に失敗しました")
+		return
+	}
+	if restriction == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	resp, err := json.Marshal(restriction)
+	if err != nil {
+		log.Printf("[auth_controller.go] JSONエンコード失敗: %v", err)
+		writeError(w, err, "レスポンス生成に失敗しました")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusForbidden)
+	w.Write(resp)
+}
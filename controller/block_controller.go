@@ -0,0 +1,87 @@
+package controller
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"twitter/model"
+	"twitter/usecase"
+
+	"github.com/gorilla/mux"
+)
+
+type BlockController struct {
+	blockUseCase *usecase.BlockUseCase
+}
+
+func NewBlockController(blockUseCase *usecase.BlockUseCase) *BlockController {
+	return &BlockController{blockUseCase: blockUseCase}
+}
+
+// HandleAddBlock 指定ユーザーをブロック
+func (c *BlockController) HandleAddBlock(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	blockedUserID := vars["user_id"]
+
+	var block model.Block
+	if err := json.NewDecoder(r.Body).Decode(&block); err != nil {
+		log.Printf("[block_controller.go] JSONデコード失敗: %v", err)
+		writeError(w, errInvalidJSON.Wrap(err), "")
+		return
+	}
+
+	if err := c.blockUseCase.AddBlock(r.Context(), block.UserID, blockedUserID); err != nil {
+		log.Printf("[block_controller.go] ブロック追加失敗: %v", err)
+		writeError(w, err, "ブロックに失敗しました")
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+}
+
+// HandleRemoveBlock 指定ユーザーのブロックを解除
+func (c *BlockController) HandleRemoveBlock(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	blockedUserID := vars["user_id"]
+
+	var block model.Block
+	if err := json.NewDecoder(r.Body).Decode(&block); err != nil {
+		log.Printf("[block_controller.go] JSONデコード失敗: %v", err)
+		writeError(w, errInvalidJSON.Wrap(err), "")
+		return
+	}
+
+	if err := c.blockUseCase.RemoveBlock(r.Context(), block.UserID, blockedUserID); err != nil {
+		log.Printf("[block_controller.go] ブロック解除失敗: %v", err)
+		writeError(w, err, "ブロック解除に失敗しました")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandleGetBlockedUsers 指定ユーザーがブロックしているユーザー一覧を取得
+func (c *BlockController) HandleGetBlockedUsers(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	userID := vars["user_id"]
+
+	users, err := c.blockUseCase.GetBlockedUsers(r.Context(), userID)
+	if err != nil {
+		log.Printf("[block_controller.go] ブロック中一覧取得失敗: %v", err)
+		writeError(w, err, "ブロック中一覧の取得に失敗しました")
+		return
+	}
+	if users == nil {
+		users = []model.User{}
+	}
+
+	resp, err := json.Marshal(users)
+	if err != nil {
+		log.Printf("[block_controller.go] JSONエンコード失敗: %v", err)
+		writeError(w, err, "レスポンス生成に失敗しました")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(resp)
+}
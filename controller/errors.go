@@ -0,0 +1,115 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+	"twitter/apperr"
+	"twitter/usecase"
+)
+
+// errInvalidJSON リクエストボディを JSON として読めない
+var errInvalidJSON = apperr.Validation("invalid_json", "リクエストの形式が不正です")
+
+// errRequestTimeout 処理がエンドポイントの締め切り (WithTimeout) までに終わらなかった
+var errRequestTimeout = apperr.New(apperr.KindTimeout, "request_timeout", "処理がタイムアウトしました。しばらくしてからお試しください")
+
+// ErrorResponse 全てのエンドポイントで共通のエラーレスポンス
+type ErrorResponse struct {
+	Error ErrorBody `json:"error"`
+}
+
+// ErrorBody エラーの内容 (Code は機械で判別できる識別子、RetryAfter は再試行できるまでの秒数)
+type ErrorBody struct {
+	Code       string              `json:"code"`
+	Message    string              `json:"message"`
+	Fields     []apperr.FieldError `json:"fields,omitempty"`
+	RetryAfter int                 `json:"retry_after,omitempty"`
+}
+
+// writeError ヘルパー関数: エラーを種類に対応するステータスコードと共通の形式の JSON で返す
+// 種類の無いエラーは内部の失敗として、詳細を隠して fallbackMessage を返す
+// 使用量の上限超過 (429) と障害中 (503) は Retry-After ヘッダも付ける
+func writeError(w http.ResponseWriter, err error, fallbackMessage string) {
+	appErr := classifyError(err, fallbackMessage)
+	if appErr.RetryAfter > 0 {
+		setRetryAfter(w, appErr.RetryAfter)
+	}
+
+	body := errorBody(appErr)
+	resp, marshalErr := json.Marshal(ErrorResponse{Error: body})
+	if marshalErr != nil {
+		log.Printf("[errors.go] エラーレスポンスのJSONエンコード失敗: %v", marshalErr)
+		http.Error(w, body.Message, appErr.Kind.Status())
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(appErr.Kind.Status())
+	w.Write(resp)
+}
+
+// writeJSON ヘルパー関数: 値を JSON で返す
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	resp, err := json.Marshal(v)
+	if err != nil {
+		log.Printf("[errors.go] JSONエンコード失敗: %v", err)
+		writeError(w, err, "レスポンス生成に失敗しました")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(resp)
+}
+
+// invalidParameter ヘルパー関数: クエリやパスのパラメータが不正なエラー
+func invalidParameter(field, message string) error {
+	return apperr.Validation("invalid_parameter", message, apperr.Field(field, message))
+}
+
+// classifyError ヘルパー関数: エラーを種類とコードを持つエラーに変換する
+// 使用量の上限超過と障害中は再試行できるまでの時間を付け、締め切りを過ぎた処理はタイムアウトにする
+func classifyError(err error, fallbackMessage string) *apperr.Error {
+	var quotaErr *usecase.QuotaExceededError
+	var circuitErr *usecase.CircuitOpenError
+	var appErr *apperr.Error
+	switch {
+	case err == nil:
+		return apperr.Internal(fallbackMessage)
+	case errors.As(err, &quotaErr):
+		return usecase.ErrQuotaExceeded.Detailf("%s: %s", quotaErr.Scope, quotaErr.Limit).WithRetryAfter(quotaErr.RetryAfter)
+	case errors.As(err, &circuitErr):
+		return usecase.ErrAIUnavailable.WithRetryAfter(circuitErr.RetryAfter)
+	case errors.As(err, &appErr):
+		return appErr
+	case errors.Is(err, context.DeadlineExceeded):
+		return errRequestTimeout.Wrap(err)
+	default:
+		return apperr.Internal(fallbackMessage).Wrap(err)
+	}
+}
+
+// errorBody ヘルパー関数: レスポンスに含めるエラーの内容
+func errorBody(appErr *apperr.Error) ErrorBody {
+	body := ErrorBody{Code: appErr.Code, Message: appErr.Message, Fields: appErr.Fields}
+	if appErr.RetryAfter > 0 {
+		body.RetryAfter = retryAfterSeconds(appErr.RetryAfter)
+	}
+	return body
+}
+
+// setRetryAfter ヘルパー関数: 再試行できるまでの秒数 (1秒以上に切り上げ) を Retry-After ヘッダに設定
+func setRetryAfter(w http.ResponseWriter, retryAfter time.Duration) {
+	w.Header().Set("Retry-After", strconv.Itoa(retryAfterSeconds(retryAfter)))
+}
+
+// retryAfterSeconds ヘルパー関数: 再試行できるまでの時間を1秒以上に切り上げた秒数
+func retryAfterSeconds(retryAfter time.Duration) int {
+	return max(int(math.Ceil(retryAfter.Seconds())), 1)
+}
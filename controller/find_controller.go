@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"log"
 	"net/http"
+	"strconv"
 	"twitter/usecase"
 
 	"github.com/gorilla/mux"
@@ -11,11 +12,13 @@ import (
 
 // FindController 検索用のコントローラー
 type FindController struct {
-	findUseCase *usecase.FindUseCase
+	findUseCase           *usecase.FindUseCase
+	semanticSearchUseCase *usecase.SemanticSearchUseCase
+	suggestUseCase        *usecase.SuggestUseCase
 }
 
-func NewFindController(findUseCase *usecase.FindUseCase) *FindController {
-	return &FindController{findUseCase: findUseCase}
+func NewFindController(findUseCase *usecase.FindUseCase, semanticSearchUseCase *usecase.SemanticSearchUseCase, suggestUseCase *usecase.SuggestUseCase) *FindController {
+	return &FindController{findUseCase: findUseCase, semanticSearchUseCase: semanticSearchUseCase, suggestUseCase: suggestUseCase}
 }
 
 // HandleFindUsers 指定したキーワードを含むユーザーを検索
@@ -23,17 +26,17 @@ func (c *FindController) HandleFindUsers(w http.ResponseWriter, r *http.Request)
 	vars := mux.Vars(r)
 	key := vars["key"]
 
-	users, err := c.findUseCase.FindUsers(key)
+	users, err := c.findUseCase.FindUsers(r.Context(), key)
 	if err != nil {
 		log.Printf("[find_controller.go] ユーザー検索失敗 (key: %s): %v", key, err)
-		http.Error(w, "ユーザー検索に失敗しました", http.StatusInternalServerError)
+		writeError(w, err, "ユーザー検索に失敗しました")
 		return
 	}
 
 	resp, err := json.Marshal(users)
 	if err != nil {
 		log.Printf("[find_controller.go] JSONエンコード失敗: %v", err)
-		http.Error(w, "レスポンス生成に失敗しました", http.StatusInternalServerError)
+		writeError(w, err, "レスポンス生成に失敗しました")
 		return
 	}
 
@@ -42,22 +45,22 @@ func (c *FindController) HandleFindUsers(w http.ResponseWriter, r *http.Request)
 	w.Write(resp)
 }
 
-// HandleFindPosts 指定したキーワードを含む投稿を検索
+// HandleFindPosts 検索クエリに一致する投稿を検索 (クエリの形式が不正なら 400)
 func (c *FindController) HandleFindPosts(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	key := vars["key"]
 
-	posts, err := c.findUseCase.FindPosts(key)
+	posts, err := c.findUseCase.FindPosts(r.Context(), key, r.URL.Query().Get("auth_id"))
 	if err != nil {
 		log.Printf("[find_controller.go] 投稿検索失敗 (key: %s): %v", key, err)
-		http.Error(w, "投稿検索に失敗しました", http.StatusInternalServerError)
+		writeError(w, err, "投稿検索に失敗しました")
 		return
 	}
 
 	resp, err := json.Marshal(posts)
 	if err != nil {
 		log.Printf("[find_controller.go] JSONエンコード失敗: %v", err)
-		http.Error(w, "レスポンス生成に失敗しました", http.StatusInternalServerError)
+		writeError(w, err, "レスポンス生成に失敗しました")
 		return
 	}
 
@@ -65,3 +68,189 @@ func (c *FindController) HandleFindPosts(w http.ResponseWriter, r *http.Request)
 	w.WriteHeader(http.StatusOK)
 	w.Write(resp)
 }
+
+// HandleSemanticSearchPosts クエリ q と意味の近い投稿を検索
+// オプション: 閲覧者 auth_id、件数 limit、キーワード検索との組み合わせ hybrid=true とその重み keyword_weight (0〜1)
+func (c *FindController) HandleSemanticSearchPosts(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	q := query.Get("q")
+
+	limit := 0
+	if value := query.Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			writeError(w, invalidParameter("limit", "limit は正の整数で指定してください"), "")
+			return
+		}
+		limit = parsed
+	}
+	keywordWeight := 0.0
+	if value := query.Get("keyword_weight"); value != "" {
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil || parsed <= 0 || parsed >= 1 {
+			writeError(w, invalidParameter("keyword_weight", "keyword_weight は0より大きく1より小さい数で指定してください"), "")
+			return
+		}
+		keywordWeight = parsed
+	}
+
+	results, err := c.semanticSearchUseCase.SearchPosts(r.Context(), q, query.Get("auth_id"), limit, query.Get("hybrid") == "true", keywordWeight)
+	if err != nil {
+		log.Printf("[find_controller.go] 投稿の意味検索失敗 (q: %s): %v", q, err)
+		writeError(w, err, "投稿検索に失敗しました")
+		return
+	}
+
+	resp, err := json.Marshal(results)
+	if err != nil {
+		log.Printf("[find_controller.go] JSONエンコード失敗: %v", err)
+		writeError(w, err, "レスポンス生成に失敗しました")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(resp)
+}
+
+// HandleSuggest 入力 q に前方一致するユーザーとハッシュタグを入力補完の候補として返す
+// オプション: 閲覧者 auth_id、それぞれの件数 limit
+func (c *FindController) HandleSuggest(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	q := query.Get("q")
+
+	limit := 0
+	if value := query.Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			writeError(w, invalidParameter("limit", "limit は正の整数で指定してください"), "")
+			return
+		}
+		limit = parsed
+	}
+
+	suggestions, err := c.suggestUseCase.Suggest(r.Context(), q, query.Get("auth_id"), limit)
+	if err != nil {
+		log.Printf("[find_controller.go] 入力補完失敗 (q: %s): %v", q, err)
+		writeError(w, err, "入力補完に失敗しました")
+		return
+	}
+
+	resp, err := json.Marshal(suggestions)
+	if err != nil {
+		log.Printf("[find_controller.go] JSONエンコード失敗: %v", err)
+		writeError(w, err, "レスポンス生成に失敗しました")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(resp)
+}
+
+// SaveSearchRequest 検索を保存するリクエスト
+type SaveSearchRequest struct {
+	Query string `json:"query"`
+}
+
+// HandleSaveSearch 投稿検索のクエリを保存する
+func (c *FindController) HandleSaveSearch(w http.ResponseWriter, r *http.Request) {
+	authID := mux.Vars(r)["auth_id"]
+
+	var req SaveSearchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("[find_controller.go] JSONデコード失敗: %v", err)
+		writeError(w, errInvalidJSON.Wrap(err), "")
+		return
+	}
+
+	saved, err := c.findUseCase.SaveSearch(r.Context(), authID, req.Query)
+	if err != nil {
+		log.Printf("[find_controller.go] 検索の保存失敗 (auth_id: %s): %v", authID, err)
+		writeError(w, err, "検索の保存に失敗しました")
+		return
+	}
+	writeJSON(w, http.StatusCreated, saved)
+}
+
+// HandleListSavedSearches 保存した検索の一覧を取得
+func (c *FindController) HandleListSavedSearches(w http.ResponseWriter, r *http.Request) {
+	authID := mux.Vars(r)["auth_id"]
+
+	searches, err := c.findUseCase.ListSavedSearches(r.Context(), authID)
+	if err != nil {
+		log.Printf("[find_controller.go] 保存した検索の一覧取得失敗 (auth_id: %s): %v", authID, err)
+		writeError(w, err, "保存した検索の取得に失敗しました")
+		return
+	}
+	writeJSON(w, http.StatusOK, searches)
+}
+
+// HandleGetSavedSearchTimeline 保存した検索に前回見たとき以降に一致した投稿を取得し、見た日時を更新する
+func (c *FindController) HandleGetSavedSearchTimeline(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	authID, searchID := vars["auth_id"], vars["search_id"]
+
+	timeline, err := c.findUseCase.GetSavedSearchTimeline(r.Context(), authID, searchID)
+	if err != nil {
+		log.Printf("[find_controller.go] 保存した検索の新しい投稿の取得失敗 (auth_id: %s, search_id: %s): %v", authID, searchID, err)
+		writeError(w, err, "保存した検索の新しい投稿の取得に失敗しました")
+		return
+	}
+	writeJSON(w, http.StatusOK, timeline)
+}
+
+// HandleDeleteSavedSearch 保存した検索を削除
+func (c *FindController) HandleDeleteSavedSearch(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	authID, searchID := vars["auth_id"], vars["search_id"]
+
+	if err := c.findUseCase.DeleteSavedSearch(r.Context(), authID, searchID); err != nil {
+		log.Printf("[find_controller.go] 保存した検索の削除失敗 (auth_id: %s, search_id: %s): %v", authID, searchID, err)
+		writeError(w, err, "保存した検索の削除に失敗しました")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandleListSearchHistory 最近の検索履歴を新しい順に取得 (オプション: 件数 limit)
+func (c *FindController) HandleListSearchHistory(w http.ResponseWriter, r *http.Request) {
+	authID := mux.Vars(r)["auth_id"]
+
+	history, err := c.findUseCase.ListSearchHistory(r.Context(), authID, parseLimitWithDefault(r.URL.Query().Get("limit"), 0))
+	if err != nil {
+		log.Printf("[find_controller.go] 検索履歴の取得失敗 (auth_id: %s): %v", authID, err)
+		writeError(w, err, "検索履歴の取得に失敗しました")
+		return
+	}
+	writeJSON(w, http.StatusOK, history)
+}
+
+// HandleDeleteSearchHistory 検索履歴からクエリ q を削除
+func (c *FindController) HandleDeleteSearchHistory(w http.ResponseWriter, r *http.Request) {
+	authID := mux.Vars(r)["auth_id"]
+	q := r.URL.Query().Get("q")
+	if q == "" {
+		writeError(w, invalidParameter("q", "q は必須項目です"), "")
+		return
+	}
+
+	if err := c.findUseCase.DeleteSearchHistory(r.Context(), authID, q); err != nil {
+		log.Printf("[find_controller.go] 検索履歴の削除失敗 (auth_id: %s): %v", authID, err)
+		writeError(w, err, "検索履歴の削除に失敗しました")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandleClearSearchHistory 検索履歴を全て削除
+func (c *FindController) HandleClearSearchHistory(w http.ResponseWriter, r *http.Request) {
+	authID := mux.Vars(r)["auth_id"]
+
+	if err := c.findUseCase.ClearSearchHistory(r.Context(), authID); err != nil {
+		log.Printf("[find_controller.go] 検索履歴の全削除失敗 (auth_id: %s): %v", authID, err)
+		writeError(w, err, "検索履歴の削除に失敗しました")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
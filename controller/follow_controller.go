@@ -26,13 +26,13 @@ func (c *FollowController) HandleAddFollow(w http.ResponseWriter, r *http.Reques
 	var follow model.Follow
 	if err := json.NewDecoder(r.Body).Decode(&follow); err != nil {
 		log.Printf("[follow_controller.go] JSONデコード失敗: %v", err)
-		http.Error(w, "リクエストの形式が不正です", http.StatusBadRequest)
+		writeError(w, errInvalidJSON.Wrap(err), "")
 		return
 	}
 
-	if err := c.followUseCase.AddFollow(follow.UserID, followingUserID); err != nil {
+	if err := c.followUseCase.AddFollow(r.Context(), follow.UserID, followingUserID); err != nil {
 		log.Printf("[follow_controller.go] フォロー追加失敗: %v", err)
-		http.Error(w, "フォロー追加に失敗しました", http.StatusInternalServerError)
+		writeError(w, err, "フォロー追加に失敗しました")
 		return
 	}
 
@@ -47,13 +47,13 @@ func (c *FollowController) HandleRemoveFollow(w http.ResponseWriter, r *http.Req
 	var follow model.Follow
 	if err := json.NewDecoder(r.Body).Decode(&follow); err != nil {
 		log.Printf("[follow_controller.go] JSONデコード失敗: %v", err)
-		http.Error(w, "リクエストの形式が不正です", http.StatusBadRequest)
+		writeError(w, errInvalidJSON.Wrap(err), "")
 		return
 	}
 
-	if err := c.followUseCase.RemoveFollow(follow.UserID, followingUserID); err != nil {
+	if err := c.followUseCase.RemoveFollow(r.Context(), follow.UserID, followingUserID); err != nil {
 		log.Printf("[follow_controller.go] フォロー解除失敗: %v", err)
-		http.Error(w, "フォロー解除に失敗しました", http.StatusInternalServerError)
+		writeError(w, err, "フォロー解除に失敗しました")
 		return
 	}
 
@@ -65,17 +65,17 @@ func (c *FollowController) HandleGetFollowers(w http.ResponseWriter, r *http.Req
 	vars := mux.Vars(r)
 	userID := vars["user_id"]
 
-	users, err := c.followUseCase.GetFollowers(userID)
+	users, err := c.followUseCase.GetFollowers(r.Context(), userID)
 	if err != nil {
 		log.Printf("[follow_controller.go] フォロワー一覧取得失敗: %v", err)
-		http.Error(w, "フォロワー一覧の取得に失敗しました", http.StatusInternalServerError)
+		writeError(w, err, "フォロワー一覧の取得に失敗しました")
 		return
 	}
 
 	resp, err := json.Marshal(users)
 	if err != nil {
 		log.Printf("[follow_controller.go] JSONエンコード失敗: %v", err)
-		http.Error(w, "レスポンス生成に失敗しました", http.StatusInternalServerError)
+		writeError(w, err, "レスポンス生成に失敗しました")
 		return
 	}
 
@@ -88,17 +88,17 @@ func (c *FollowController) HandleGetFollowing(w http.ResponseWriter, r *http.Req
 	vars := mux.Vars(r)
 	userID := vars["user_id"]
 
-	users, err := c.followUseCase.GetFollowing(userID)
+	users, err := c.followUseCase.GetFollowing(r.Context(), userID)
 	if err != nil {
 		log.Printf("[follow_controller.go] フォロー中一覧取得失敗: %v", err)
-		http.Error(w, "フォロー中一覧の取得に失敗しました", http.StatusInternalServerError)
+		writeError(w, err, "フォロー中一覧の取得に失敗しました")
 		return
 	}
 
 	resp, err := json.Marshal(users)
 	if err != nil {
 		log.Printf("[follow_controller.go] JSONエンコード失敗: %v", err)
-		http.Error(w, "レスポンス生成に失敗しました", http.StatusInternalServerError)
+		writeError(w, err, "レスポンス生成に失敗しました")
 		return
 	}
 
@@ -108,17 +108,17 @@ func (c *FollowController) HandleGetFollowing(w http.ResponseWriter, r *http.Req
 
 // HandleGetFollowGraph フォローグラフを取得
 func (c *FollowController) HandleGetFollowGraph(w http.ResponseWriter, r *http.Request) {
-	follows, err := c.followUseCase.GetFollowGraph()
+	follows, err := c.followUseCase.GetFollowGraph(r.Context())
 	if err != nil {
 		log.Printf("[follow_controller.go] フォローグラフの取得失敗: %v", err)
-		http.Error(w, "フォローグラフの取得に失敗しました", http.StatusInternalServerError)
+		writeError(w, err, "フォローグラフの取得に失敗しました")
 		return
 	}
 
 	resp, err := json.Marshal(follows)
 	if err != nil {
 		log.Printf("[follow_controller.go] JSONエンコード失敗: %v", err)
-		http.Error(w, "レスポンス生成に失敗しました", http.StatusInternalServerError)
+		writeError(w, err, "レスポンス生成に失敗しました")
 		return
 	}
 
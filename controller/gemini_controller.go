@@ -2,8 +2,12 @@ package controller
 
 import (
 	"encoding/json"
+	"errors"
+	"io"
 	"log"
 	"net/http"
+	"time"
+	"twitter/prompt"
 	"twitter/usecase"
 
 	"github.com/gorilla/mux"
@@ -15,14 +19,35 @@ type InstructionRequest struct {
 	TempText    *string `json:"temp_text"`
 }
 
+// PromptTemplateHeader 生成に使ったプロンプトテンプレートを返すレスポンスヘッダ
+const PromptTemplateHeader = "X-Prompt-Template"
+
+// CacheStatusHeader キャッシュした生成結果を返したか (HIT / MISS) を返すレスポンスヘッダ
+const CacheStatusHeader = "X-Cache"
+
+// CandidateRequest 複数候補生成・候補修正リクエストボディの構造体
+type CandidateRequest struct {
+	Instruction *string `json:"instruction"`
+	Count       int     `json:"count"`    // 0 ならデフォルトの3件
+	Previous    string  `json:"previous"` // 修正する以前の候補 (修正時のみ)
+	Feedback    string  `json:"feedback"` // 修正の要望 (修正時のみ)
+}
+
+// ReplySuggestionRequest 返信の提案リクエストボディの構造体 (ボディは省略できる)
+type ReplySuggestionRequest struct {
+	Instruction *string  `json:"instruction"`
+	Tones       []string `json:"tones"` // 空ならデフォルトの口調 (friendly, polite, humorous, empathetic)
+}
+
 // GeminiController Gemini関連エンドポイントのコントローラ
 type GeminiController struct {
 	geminiUseCase *usecase.GeminiUseCase
+	quotaUseCase  *usecase.QuotaUseCase
 }
 
 // NewGeminiController コントローラの初期化
-func NewGeminiController(useCase *usecase.GeminiUseCase) *GeminiController {
-	return &GeminiController{geminiUseCase: useCase}
+func NewGeminiController(useCase *usecase.GeminiUseCase, quotaUseCase *usecase.QuotaUseCase) *GeminiController {
+	return &GeminiController{geminiUseCase: useCase, quotaUseCase: quotaUseCase}
 }
 
 // HandleGenerateBio 自己紹介生成
@@ -34,7 +59,7 @@ func (c *GeminiController) HandleGenerateBio(w http.ResponseWriter, r *http.Requ
 	var req InstructionRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		log.Printf("[gemini_controller.go] リクエストデコード失敗 (auth_id: %s): %v", authID, err)
-		http.Error(w, "リクエスト形式が不正です", http.StatusBadRequest)
+		writeError(w, errInvalidJSON.Wrap(err), "")
 		return
 	}
 
@@ -44,17 +69,19 @@ func (c *GeminiController) HandleGenerateBio(w http.ResponseWriter, r *http.Requ
 		instruction = *req.Instruction
 	}
 
-	part, err := c.geminiUseCase.GenerateBio(authID, instruction)
+	generation, err := c.geminiUseCase.GenerateBio(r.Context(), authID, instruction, requestLocale(r))
 	if err != nil {
 		log.Printf("[gemini_controller.go] 自己紹介生成失敗 (auth_id: %s): %v", authID, err)
-		http.Error(w, "自己紹介の生成に失敗しました", http.StatusInternalServerError)
+		writeError(w, err, "自己紹介の生成に失敗しました")
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(part); err != nil {
+	w.Header().Set(PromptTemplateHeader, generation.Prompt.String())
+	w.Header().Set(CacheStatusHeader, cacheStatus(generation.Cached))
+	if err := json.NewEncoder(w).Encode(generation.Part); err != nil {
 		log.Printf("[gemini_controller.go] jsonエンコード失敗 (auth_id: %s): %v", authID, err)
-		http.Error(w, "レスポンスの生成に失敗しました", http.StatusInternalServerError)
+		writeError(w, err, "レスポンスの生成に失敗しました")
 	}
 }
 
@@ -67,7 +94,7 @@ func (c *GeminiController) HandleGenerateName(w http.ResponseWriter, r *http.Req
 	var req InstructionRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		log.Printf("[gemini_controller.go] リクエストデコード失敗 (auth_id: %s): %v", authID, err)
-		http.Error(w, "リクエスト形式が不正です", http.StatusBadRequest)
+		writeError(w, errInvalidJSON.Wrap(err), "")
 		return
 	}
 
@@ -77,17 +104,19 @@ func (c *GeminiController) HandleGenerateName(w http.ResponseWriter, r *http.Req
 		instruction = *req.Instruction
 	}
 
-	part, err := c.geminiUseCase.GenerateName(authID, instruction)
+	generation, err := c.geminiUseCase.GenerateName(r.Context(), authID, instruction, requestLocale(r))
 	if err != nil {
 		log.Printf("[gemini_controller.go] 名前生成失敗 (auth_id: %s): %v", authID, err)
-		http.Error(w, "名前の生成に失敗しました", http.StatusInternalServerError)
+		writeError(w, err, "名前の生成に失敗しました")
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(part); err != nil {
+	w.Header().Set(PromptTemplateHeader, generation.Prompt.String())
+	w.Header().Set(CacheStatusHeader, cacheStatus(generation.Cached))
+	if err := json.NewEncoder(w).Encode(generation.Part); err != nil {
 		log.Printf("[gemini_controller.go] jsonエンコード失敗 (auth_id: %s): %v", authID, err)
-		http.Error(w, "レスポンスの生成に失敗しました", http.StatusInternalServerError)
+		writeError(w, err, "レスポンスの生成に失敗しました")
 	}
 }
 
@@ -100,7 +129,7 @@ func (c *GeminiController) HandleGenerateTweetContinuation(w http.ResponseWriter
 	var req InstructionRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		log.Printf("[gemini_controller.go] リクエストデコード失敗 (auth_id: %s): %v", authID, err)
-		http.Error(w, "リクエスト形式が不正です", http.StatusBadRequest)
+		writeError(w, errInvalidJSON.Wrap(err), "")
 		return
 	}
 
@@ -116,18 +145,154 @@ func (c *GeminiController) HandleGenerateTweetContinuation(w http.ResponseWriter
 	}
 
 	// ユースケースを呼び出し
-	part, err := c.geminiUseCase.GenerateTweetContinuation(authID, instruction, tempText)
+	generation, err := c.geminiUseCase.GenerateTweetContinuation(r.Context(), authID, instruction, tempText, requestLocale(r))
 	if err != nil {
 		log.Printf("[gemini_controller.go] ツイートの生成失敗 (auth_id: %s): %v", authID, err)
-		http.Error(w, "ツイートの生成に失敗しました", http.StatusInternalServerError)
+		writeError(w, err, "ツイートの生成に失敗しました")
 		return
 	}
 
 	// レスポンスを返却
 	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(part); err != nil {
+	w.Header().Set(PromptTemplateHeader, generation.Prompt.String())
+	w.Header().Set(CacheStatusHeader, cacheStatus(generation.Cached))
+	if err := json.NewEncoder(w).Encode(generation.Part); err != nil {
+		log.Printf("[gemini_controller.go] jsonエンコード失敗 (auth_id: %s): %v", authID, err)
+		writeError(w, err, "レスポンスの生成に失敗しました")
+	}
+}
+
+// HandleStreamBio 自己紹介をストリーミング生成
+func (c *GeminiController) HandleStreamBio(w http.ResponseWriter, r *http.Request) {
+	authID := mux.Vars(r)["auth_id"]
+
+	var req InstructionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("[gemini_controller.go] リクエストデコード失敗 (auth_id: %s): %v", authID, err)
+		writeError(w, errInvalidJSON.Wrap(err), "")
+		return
+	}
+
+	c.serveStream(w, r, authID, "自己紹介の生成に失敗しました", func(onText func(string) error) (*usecase.StreamResult, error) {
+		return c.geminiUseCase.StreamBio(r.Context(), authID, stringOrEmpty(req.Instruction), requestLocale(r), onText)
+	})
+}
+
+// HandleStreamName 名前をストリーミング生成
+func (c *GeminiController) HandleStreamName(w http.ResponseWriter, r *http.Request) {
+	authID := mux.Vars(r)["auth_id"]
+
+	var req InstructionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("[gemini_controller.go] リクエストデコード失敗 (auth_id: %s): %v", authID, err)
+		writeError(w, errInvalidJSON.Wrap(err), "")
+		return
+	}
+
+	c.serveStream(w, r, authID, "名前の生成に失敗しました", func(onText func(string) error) (*usecase.StreamResult, error) {
+		return c.geminiUseCase.StreamName(r.Context(), authID, stringOrEmpty(req.Instruction), requestLocale(r), onText)
+	})
+}
+
+// HandleStreamTweetContinuation ツイートの続きをストリーミング生成
+func (c *GeminiController) HandleStreamTweetContinuation(w http.ResponseWriter, r *http.Request) {
+	authID := mux.Vars(r)["auth_id"]
+
+	var req InstructionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("[gemini_controller.go] リクエストデコード失敗 (auth_id: %s): %v", authID, err)
+		writeError(w, errInvalidJSON.Wrap(err), "")
+		return
+	}
+
+	c.serveStream(w, r, authID, "ツイートの生成に失敗しました", func(onText func(string) error) (*usecase.StreamResult, error) {
+		return c.geminiUseCase.StreamTweetContinuation(r.Context(), authID, stringOrEmpty(req.Instruction), stringOrEmpty(req.TempText), requestLocale(r), onText)
+	})
+}
+
+// serveStream ストリーミング生成の共通処理
+// 生成されたテキストを token イベント、完了時にテンプレートと使用量を done イベント、途中の失敗を error イベントで送る
+func (c *GeminiController) serveStream(w http.ResponseWriter, r *http.Request, authID, failMessage string, run func(onText func(string) error) (*usecase.StreamResult, error)) {
+	stream, err := newStreamWriter(w, r)
+	if err != nil {
+		log.Printf("[gemini_controller.go] ストリーミング開始失敗 (auth_id: %s): %v", authID, err)
+		writeError(w, err, failMessage)
+		return
+	}
+
+	result, err := run(func(text string) error {
+		return stream.Send("token", map[string]string{"text": text})
+	})
+	if err != nil {
+		if r.Context().Err() != nil {
+			log.Printf("[gemini_controller.go] クライアント切断によりストリーミング生成を中断 (auth_id: %s)", authID)
+			return
+		}
+		log.Printf("[gemini_controller.go] ストリーミング生成失敗 (auth_id: %s): %v", authID, err)
+		if stream.Started() {
+			stream.Send("error", ErrorResponse{Error: errorBody(classifyError(err, failMessage))})
+			return
+		}
+		writeError(w, err, failMessage)
+		return
+	}
+
+	if err := stream.Send("done", result); err != nil {
+		log.Printf("[gemini_controller.go] 完了イベント送信失敗 (auth_id: %s): %v", authID, err)
+	}
+}
+
+// HandleGenerateNameCandidates 名前の候補を複数生成
+func (c *GeminiController) HandleGenerateNameCandidates(w http.ResponseWriter, r *http.Request) {
+	c.serveCandidates(w, r, "名前の候補生成", func(authID string, req CandidateRequest) (*usecase.CandidateGeneration, error) {
+		return c.geminiUseCase.GenerateNameCandidates(r.Context(), authID, stringOrEmpty(req.Instruction), requestLocale(r), req.Count)
+	})
+}
+
+// HandleGenerateBioCandidates 自己紹介の候補を複数生成
+func (c *GeminiController) HandleGenerateBioCandidates(w http.ResponseWriter, r *http.Request) {
+	c.serveCandidates(w, r, "自己紹介の候補生成", func(authID string, req CandidateRequest) (*usecase.CandidateGeneration, error) {
+		return c.geminiUseCase.GenerateBioCandidates(r.Context(), authID, stringOrEmpty(req.Instruction), requestLocale(r), req.Count)
+	})
+}
+
+// HandleRefineName 以前の名前の候補と要望から名前を考え直す
+func (c *GeminiController) HandleRefineName(w http.ResponseWriter, r *http.Request) {
+	c.serveCandidates(w, r, "名前の修正", func(authID string, req CandidateRequest) (*usecase.CandidateGeneration, error) {
+		return c.geminiUseCase.RefineName(r.Context(), authID, req.Previous, req.Feedback, requestLocale(r), req.Count)
+	})
+}
+
+// HandleRefineBio 以前の自己紹介の候補と要望から自己紹介を書き直す
+func (c *GeminiController) HandleRefineBio(w http.ResponseWriter, r *http.Request) {
+	c.serveCandidates(w, r, "自己紹介の修正", func(authID string, req CandidateRequest) (*usecase.CandidateGeneration, error) {
+		return c.geminiUseCase.RefineBio(r.Context(), authID, req.Previous, req.Feedback, requestLocale(r), req.Count)
+	})
+}
+
+// serveCandidates 候補生成・候補修正の共通処理
+func (c *GeminiController) serveCandidates(w http.ResponseWriter, r *http.Request, label string, run func(authID string, req CandidateRequest) (*usecase.CandidateGeneration, error)) {
+	authID := mux.Vars(r)["auth_id"]
+
+	var req CandidateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("[gemini_controller.go] リクエストデコード失敗 (auth_id: %s): %v", authID, err)
+		writeError(w, errInvalidJSON.Wrap(err), "")
+		return
+	}
+
+	generation, err := run(authID, req)
+	if err != nil {
+		log.Printf("[gemini_controller.go] %s失敗 (auth_id: %s): %v", label, authID, err)
+		writeError(w, err, label+"に失敗しました")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set(PromptTemplateHeader, generation.Prompt.String())
+	if err := json.NewEncoder(w).Encode(generation); err != nil {
 		log.Printf("[gemini_controller.go] jsonエンコード失敗 (auth_id: %s): %v", authID, err)
-		http.Error(w, "レスポンスの生成に失敗しました", http.StatusInternalServerError)
+		writeError(w, err, "レスポンスの生成に失敗しました")
 	}
 }
 
@@ -136,18 +301,129 @@ func (c *GeminiController) HandleCheckIsBad(w http.ResponseWriter, r *http.Reque
 	vars := mux.Vars(r)
 	postID := vars["post_id"]
 
-	part, err := c.geminiUseCase.CheckIfPostIsBad(postID)
+	generation, err := c.geminiUseCase.CheckIfPostIsBad(r.Context(), postID, requestLocale(r))
 	if err != nil {
 		log.Printf("[gemini_controller.go] 投稿検査失敗 (post_id: %s): %v", postID, err)
-		http.Error(w, "投稿検査に失敗しました", http.StatusInternalServerError)
+		writeError(w, err, "投稿検査に失敗しました")
 		return
 	}
 
 	// 結果を返却
 	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(part); err != nil {
+	w.Header().Set(PromptTemplateHeader, generation.Prompt.String())
+	w.Header().Set(CacheStatusHeader, cacheStatus(generation.Cached))
+	if err := json.NewEncoder(w).Encode(generation.Part); err != nil {
+		log.Printf("[gemini_controller.go] JSONエンコード失敗 (post_id: %s): %v", postID, err)
+		writeError(w, err, "レスポンスの生成に失敗しました")
+	}
+}
+
+// HandleTranslatePost 投稿をクエリ to で指定した言語 (ja, en, ko, zh) に翻訳する
+// クエリ auth_id があれば閲覧者の使用量として数える
+func (c *GeminiController) HandleTranslatePost(w http.ResponseWriter, r *http.Request) {
+	postID := mux.Vars(r)["post_id"]
+	query := r.URL.Query()
+
+	translation, generation, err := c.geminiUseCase.TranslatePost(r.Context(), postID, query.Get("to"), query.Get("auth_id"), requestLocale(r))
+	if err != nil {
+		log.Printf("[gemini_controller.go] 投稿翻訳失敗 (post_id: %s, to: %s): %v", postID, query.Get("to"), err)
+		writeError(w, err, "投稿の翻訳に失敗しました")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if generation != nil {
+		w.Header().Set(PromptTemplateHeader, generation.Prompt.String())
+		w.Header().Set(CacheStatusHeader, cacheStatus(generation.Cached))
+	}
+	if err := json.NewEncoder(w).Encode(translation); err != nil {
 		log.Printf("[gemini_controller.go] JSONエンコード失敗 (post_id: %s): %v", postID, err)
-		http.Error(w, "レスポンスの生成に失敗しました", http.StatusInternalServerError)
+		writeError(w, err, "レスポンスの生成に失敗しました")
+	}
+}
+
+// HandleSuggestReplies 投稿への口調の異なる返信の候補を生成する
+func (c *GeminiController) HandleSuggestReplies(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	postID := vars["post_id"]
+	authID := vars["auth_id"]
+
+	var req ReplySuggestionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		log.Printf("[gemini_controller.go] リクエストデコード失敗 (auth_id: %s): %v", authID, err)
+		writeError(w, errInvalidJSON.Wrap(err), "")
+		return
+	}
+	instruction := ""
+	if req.Instruction != nil {
+		instruction = *req.Instruction
+	}
+
+	suggestions, err := c.geminiUseCase.SuggestReplies(r.Context(), postID, authID, instruction, req.Tones, requestLocale(r))
+	if err != nil {
+		log.Printf("[gemini_controller.go] 返信の提案失敗 (post_id: %s, auth_id: %s): %v", postID, authID, err)
+		writeError(w, err, "返信の提案に失敗しました")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set(PromptTemplateHeader, suggestions.Prompt.String())
+	w.Header().Set(CacheStatusHeader, cacheStatus(suggestions.Cached))
+	if err := json.NewEncoder(w).Encode(suggestions); err != nil {
+		log.Printf("[gemini_controller.go] JSONエンコード失敗 (post_id: %s): %v", postID, err)
+		writeError(w, err, "レスポンスの生成に失敗しました")
+	}
+}
+
+// HandleSummarizeThread 投稿が属する会話を要約する (クエリ auth_id は閲覧者のID)
+func (c *GeminiController) HandleSummarizeThread(w http.ResponseWriter, r *http.Request) {
+	postID := mux.Vars(r)["post_id"]
+
+	summary, generation, err := c.geminiUseCase.SummarizeThread(r.Context(), postID, r.URL.Query().Get("auth_id"), requestLocale(r))
+	if err != nil {
+		log.Printf("[gemini_controller.go] 会話の要約失敗 (post_id: %s): %v", postID, err)
+		writeError(w, err, "会話の要約に失敗しました")
+		return
+	}
+
+	writeSummary(w, summary, generation)
+}
+
+// HandleDigestTimeline タイムラインで見逃した投稿を要約する (クエリ since は RFC3339 形式、省略時は24時間前)
+func (c *GeminiController) HandleDigestTimeline(w http.ResponseWriter, r *http.Request) {
+	authID := mux.Vars(r)["auth_id"]
+
+	var since time.Time
+	if value := r.URL.Query().Get("since"); value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			log.Printf("[gemini_controller.go] 不正な since (auth_id: %s, since: %s): %v", authID, value, err)
+			writeError(w, invalidParameter("since", "since は RFC3339 形式で指定してください"), "")
+			return
+		}
+		since = parsed
+	}
+
+	digest, generation, err := c.geminiUseCase.DigestTimeline(r.Context(), authID, since, requestLocale(r))
+	if err != nil {
+		log.Printf("[gemini_controller.go] タイムラインの要約失敗 (auth_id: %s): %v", authID, err)
+		writeError(w, err, "タイムラインの要約に失敗しました")
+		return
+	}
+
+	writeSummary(w, digest, generation)
+}
+
+// writeSummary ヘルパー関数: 要約を JSON で返す (生成した場合はテンプレートとキャッシュの状態をヘッダに付ける)
+func writeSummary(w http.ResponseWriter, summary interface{}, generation *usecase.Generation) {
+	w.Header().Set("Content-Type", "application/json")
+	if generation != nil {
+		w.Header().Set(PromptTemplateHeader, generation.Prompt.String())
+		w.Header().Set(CacheStatusHeader, cacheStatus(generation.Cached))
+	}
+	if err := json.NewEncoder(w).Encode(summary); err != nil {
+		log.Printf("[gemini_controller.go] JSONエンコード失敗: %v", err)
+		writeError(w, err, "レスポンスの生成に失敗しました")
 	}
 }
 
@@ -165,14 +441,14 @@ func (c *GeminiController) HandleUpdateIsBad(w http.ResponseWriter, r *http.Requ
 		isBad = false
 	} else {
 		log.Printf("[gemini_controller.go] 不正な bool 値 (post_id: %s, bool: %s)", postID, boolValue)
-		http.Error(w, "bool 値が不正です。0 または 1 を指定してください", http.StatusBadRequest)
+		writeError(w, invalidParameter("bool", "bool 値が不正です。0 または 1 を指定してください"), "")
 		return
 	}
 
 	// UseCase を呼び出し
-	if err := c.geminiUseCase.UpdateIsBad(postID, isBad); err != nil {
+	if err := c.geminiUseCase.UpdateIsBad(r.Context(), postID, isBad); err != nil {
 		log.Printf("[gemini_controller.go] is_bad 更新失敗 (post_id: %s, is_bad: %v): %v", postID, isBad, err)
-		http.Error(w, "is_bad の更新に失敗しました", http.StatusInternalServerError)
+		writeError(w, err, "is_bad の更新に失敗しました")
 		return
 	}
 
@@ -180,7 +456,10 @@ func (c *GeminiController) HandleUpdateIsBad(w http.ResponseWriter, r *http.Requ
 	w.WriteHeader(http.StatusNoContent)
 }
 
-// HandleRecommendUsers おすすめユーザーを生成
+// デフォルトのおすすめユーザー数
+const DefaultRecommendLimit = 10
+
+// HandleRecommendUsers おすすめユーザーを順位付きで返す
 func (c *GeminiController) HandleRecommendUsers(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	authID := vars["auth_id"]
@@ -189,7 +468,7 @@ func (c *GeminiController) HandleRecommendUsers(w http.ResponseWriter, r *http.R
 	var req InstructionRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		log.Printf("[gemini_controller.go] リクエストデコード失敗 (auth_id: %s): %v", authID, err)
-		http.Error(w, "リクエスト形式が不正です", http.StatusBadRequest)
+		writeError(w, errInvalidJSON.Wrap(err), "")
 		return
 	}
 
@@ -199,16 +478,59 @@ func (c *GeminiController) HandleRecommendUsers(w http.ResponseWriter, r *http.R
 		instruction = *req.Instruction
 	}
 
-	part, err := c.geminiUseCase.RecommendUsers(authID, instruction)
+	limit := parseLimitWithDefault(r.URL.Query().Get("limit"), DefaultRecommendLimit)
+	recommendations, err := c.geminiUseCase.RecommendUsers(r.Context(), authID, instruction, limit)
 	if err != nil {
 		log.Printf("[gemini_controller.go] ユーザー推薦失敗 (auth_id: %s): %v", authID, err)
-		http.Error(w, "ユーザー推薦に失敗しました", http.StatusInternalServerError)
+		writeError(w, err, "ユーザー推薦に失敗しました")
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(part); err != nil {
+	if err := json.NewEncoder(w).Encode(recommendations); err != nil {
 		log.Printf("[gemini_controller.go] jsonエンコード失敗 (auth_id: %s): %v", authID, err)
-		http.Error(w, "レスポンスの生成に失敗しました", http.StatusInternalServerError)
+		writeError(w, err, "レスポンスの生成に失敗しました")
+	}
+}
+
+// HandleGetUsage ユーザーの当日のAI使用量と上限を返す
+func (c *GeminiController) HandleGetUsage(w http.ResponseWriter, r *http.Request) {
+	authID := mux.Vars(r)["auth_id"]
+
+	summary, err := c.quotaUseCase.GetUsage(r.Context(), authID)
+	if err != nil {
+		log.Printf("[gemini_controller.go] 使用量取得失敗 (auth_id: %s): %v", authID, err)
+		writeError(w, err, "使用量の取得に失敗しました")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(summary); err != nil {
+		log.Printf("[gemini_controller.go] jsonエンコード失敗 (auth_id: %s): %v", authID, err)
+		writeError(w, err, "レスポンスの生成に失敗しました")
+	}
+}
+
+// requestLocale クエリの locale、なければ Accept-Language ヘッダからプロンプトのロケールを決める
+func requestLocale(r *http.Request) string {
+	if locale := r.URL.Query().Get("locale"); locale != "" {
+		return prompt.NormalizeLocale(locale)
+	}
+	return prompt.NormalizeLocale(r.Header.Get("Accept-Language"))
+}
+
+// cacheStatus ヘルパー関数: キャッシュした結果なら "HIT"、そうでなければ "MISS" を返す
+func cacheStatus(cached bool) string {
+	if cached {
+		return "HIT"
+	}
+	return "MISS"
+}
+
+// stringOrEmpty ヘルパー関数: nil なら空文字列を返す
+func stringOrEmpty(s *string) string {
+	if s != nil {
+		return *s
 	}
+	return ""
 }
@@ -0,0 +1,52 @@
+package controller
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"twitter/model"
+	"twitter/usecase"
+
+	"github.com/gorilla/mux"
+)
+
+type ImageController struct {
+	imageUseCase *usecase.ImageUseCase
+}
+
+func NewImageController(imageUseCase *usecase.ImageUseCase) *ImageController {
+	return &ImageController{imageUseCase: imageUseCase}
+}
+
+// HandleGetPostImageAnalysis 投稿に添付された画像の代替テキストの提案と安全性の判定を取得
+func (c *ImageController) HandleGetPostImageAnalysis(w http.ResponseWriter, r *http.Request) {
+	c.serveAnalysis(w, r, model.ImageKindPost, mux.Vars(r)["post_id"])
+}
+
+// HandleGetProfileImageAnalysis プロフィール画像の代替テキストの提案と安全性の判定を取得
+func (c *ImageController) HandleGetProfileImageAnalysis(w http.ResponseWriter, r *http.Request) {
+	c.serveAnalysis(w, r, model.ImageKindProfile, mux.Vars(r)["user_id"])
+}
+
+// serveAnalysis ヘルパー関数: 画像の解析結果を JSON で返す (解析待ちなら 202)
+func (c *ImageController) serveAnalysis(w http.ResponseWriter, r *http.Request, kind, targetID string) {
+	analysis, err := c.imageUseCase.GetAnalysis(r.Context(), kind, targetID)
+	if err != nil {
+		log.Printf("[image_controller.go] 画像の解析結果取得失敗 (kind: %s, target_id: %s): %v", kind, targetID, err)
+		writeError(w, err, "画像の解析結果の取得に失敗しました")
+		return
+	}
+
+	resp, err := json.Marshal(analysis)
+	if err != nil {
+		log.Printf("[image_controller.go] JSONエンコード失敗: %v", err)
+		writeError(w, err, "レスポンス生成に失敗しました")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if analysis.Status == model.ImageAnalysisPending {
+		w.WriteHeader(http.StatusAccepted)
+	}
+	w.Write(resp)
+}
@@ -26,18 +26,18 @@ func (c *LikeController) HandleAddLike(w http.ResponseWriter, r *http.Request) {
 	var like model.Like
 	if err := json.NewDecoder(r.Body).Decode(&like); err != nil {
 		log.Printf("[like_controller.go] JSONデコード失敗: %v", err)
-		http.Error(w, "リクエストの形式が不正です", http.StatusBadRequest)
+		writeError(w, errInvalidJSON.Wrap(err), "")
 		return
 	}
 
 	if like.UserID == "" {
-		http.Error(w, "user_id が必要です", http.StatusBadRequest)
+		writeError(w, invalidParameter("user_id", "user_id が必要です"), "")
 		return
 	}
 
-	if err := c.likeUseCase.AddLike(like.UserID, postID); err != nil {
+	if err := c.likeUseCase.AddLike(r.Context(), like.UserID, postID); err != nil {
 		log.Printf("[like_controller.go] いいね追加失敗: %v", err)
-		http.Error(w, "いいね追加に失敗しました", http.StatusInternalServerError)
+		writeError(w, err, "いいね追加に失敗しました")
 		return
 	}
 
@@ -52,18 +52,18 @@ func (c *LikeController) HandleRemoveLike(w http.ResponseWriter, r *http.Request
 	var like model.Like
 	if err := json.NewDecoder(r.Body).Decode(&like); err != nil {
 		log.Printf("[like_controller.go] JSONデコード失敗: %v", err)
-		http.Error(w, "リクエストの形式が不正です", http.StatusBadRequest)
+		writeError(w, errInvalidJSON.Wrap(err), "")
 		return
 	}
 
 	if like.UserID == "" {
-		http.Error(w, "[like_controller.go] user_id が必要です", http.StatusBadRequest)
+		writeError(w, invalidParameter("user_id", "user_id が必要です"), "")
 		return
 	}
 
-	if err := c.likeUseCase.RemoveLike(like.UserID, postID); err != nil {
+	if err := c.likeUseCase.RemoveLike(r.Context(), like.UserID, postID); err != nil {
 		log.Printf("[like_controller.go] いいね削除失敗: %v", err)
-		http.Error(w, "いいね削除に失敗しました", http.StatusInternalServerError)
+		writeError(w, err, "いいね削除に失敗しました")
 		return
 	}
 
@@ -75,17 +75,17 @@ func (c *LikeController) HandleGetUsersByPostID(w http.ResponseWriter, r *http.R
 	vars := mux.Vars(r)
 	postID := vars["post_id"]
 
-	users, err := c.likeUseCase.GetUsersByPostID(postID)
+	users, err := c.likeUseCase.GetUsersByPostID(r.Context(), postID)
 	if err != nil {
 		log.Printf("[like_controller.go] いいねユーザー一覧取得失敗: %v", err)
-		http.Error(w, "いいねユーザー一覧の取得に失敗しました", http.StatusInternalServerError)
+		writeError(w, err, "いいねユーザー一覧の取得に失敗しました")
 		return
 	}
 
 	resp, err := json.Marshal(users)
 	if err != nil {
 		log.Printf("[like_controller.go] JSONエンコード失敗: %v", err)
-		http.Error(w, "レスポンス生成に失敗しました", http.StatusInternalServerError)
+		writeError(w, err, "レスポンス生成に失敗しました")
 		return
 	}
 
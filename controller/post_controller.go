@@ -23,21 +23,21 @@ func (c *PostController) HandleCreatePost(w http.ResponseWriter, r *http.Request
 	var req model.Post
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		log.Printf("[post_controller.go] JSONデコード失敗: %v", err)
-		http.Error(w, "リクエストの形式が不正です", http.StatusBadRequest)
+		writeError(w, errInvalidJSON.Wrap(err), "")
 		return
 	}
 
-	createdPost, err := c.postUseCase.CreatePost(req)
+	createdPost, err := c.postUseCase.CreatePost(r.Context(), req)
 	if err != nil {
 		log.Printf("[post_controller.go] 投稿作成失敗: %v", err)
-		http.Error(w, "投稿作成に失敗しました", http.StatusInternalServerError)
+		writeError(w, err, "投稿作成に失敗しました")
 		return
 	}
 
 	resp, err := json.Marshal(createdPost)
 	if err != nil {
 		log.Printf("[post_controller.go] JSONエンコード失敗: %v", err)
-		http.Error(w, "レスポンス生成に失敗しました", http.StatusInternalServerError)
+		writeError(w, err, "レスポンス生成に失敗しました")
 		return
 	}
 
@@ -46,27 +46,22 @@ func (c *PostController) HandleCreatePost(w http.ResponseWriter, r *http.Request
 	w.Write(resp)
 }
 
-// HandleGetPost 投稿の詳細を取得
+// HandleGetPost 投稿の詳細を取得 (存在しなければ 404、削除済みなら 410)
 func (c *PostController) HandleGetPost(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	postID := vars["post_id"]
 
-	post, err := c.postUseCase.GetPost(postID)
+	post, err := c.postUseCase.GetPost(r.Context(), postID)
 	if err != nil {
-		if err.Error() == "投稿が削除されています" {
-			log.Printf("[post_controller.go] 投稿削除済み")
-			http.Error(w, "投稿が削除されています", http.StatusGone)
-		} else {
-			log.Printf("[post_controller.go] 投稿取得失敗: %v", err)
-			http.Error(w, "投稿が見つかりません", http.StatusNotFound)
-		}
+		log.Printf("[post_controller.go] 投稿取得失敗 (post_id: %s): %v", postID, err)
+		writeError(w, err, "投稿の取得に失敗しました")
 		return
 	}
 
 	resp, err := json.Marshal(post)
 	if err != nil {
 		log.Printf("[post_controller.go] JSONエンコード失敗: %v", err)
-		http.Error(w, "レスポンス生成に失敗しました", http.StatusInternalServerError)
+		writeError(w, err, "レスポンス生成に失敗しました")
 		return
 	}
 
@@ -82,14 +77,14 @@ func (c *PostController) HandleUpdatePost(w http.ResponseWriter, r *http.Request
 	var req model.Post
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		log.Printf("[post_controller.go] JSONデコード失敗: %v", err)
-		http.Error(w, "リクエストの形式が不正です", http.StatusBadRequest)
+		writeError(w, errInvalidJSON.Wrap(err), "")
 		return
 	}
 	req.PostID = postID
 
-	if err := c.postUseCase.UpdatePost(req); err != nil {
+	if err := c.postUseCase.UpdatePost(r.Context(), req); err != nil {
 		log.Printf("[post_controller.go] 投稿更新失敗: %v", err)
-		http.Error(w, "投稿更新に失敗しました", http.StatusInternalServerError)
+		writeError(w, err, "投稿更新に失敗しました")
 		return
 	}
 
@@ -101,9 +96,9 @@ func (c *PostController) HandleDeletePost(w http.ResponseWriter, r *http.Request
 	vars := mux.Vars(r)
 	postID := vars["post_id"]
 
-	if err := c.postUseCase.DeletePost(postID); err != nil {
+	if err := c.postUseCase.DeletePost(r.Context(), postID); err != nil {
 		log.Printf("[post_controller.go] 投稿削除失敗: %v", err)
-		http.Error(w, "投稿削除に失敗しました", http.StatusInternalServerError)
+		writeError(w, err, "投稿削除に失敗しました")
 		return
 	}
 
@@ -118,24 +113,24 @@ func (c *PostController) HandleReplyPost(w http.ResponseWriter, r *http.Request)
 	var req model.Post
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		log.Printf("[post_controller.go] JSONデコード失敗: %v", err)
-		http.Error(w, "リクエストの形式が不正です", http.StatusBadRequest)
+		writeError(w, errInvalidJSON.Wrap(err), "")
 		return
 	}
 
 	// ポインタ型に変換
 	req.ParentPostID = &parentPostID
 
-	replyPost, err := c.postUseCase.ReplyPost(req)
+	replyPost, err := c.postUseCase.ReplyPost(r.Context(), req)
 	if err != nil {
 		log.Printf("[post_controller.go] リプライ投稿失敗: %v", err)
-		http.Error(w, "リプライ投稿に失敗しました", http.StatusInternalServerError)
+		writeError(w, err, "リプライ投稿に失敗しました")
 		return
 	}
 
 	resp, err := json.Marshal(replyPost)
 	if err != nil {
 		log.Printf("[post_controller.go] JSONエンコード失敗: %v", err)
-		http.Error(w, "レスポンス生成に失敗しました", http.StatusInternalServerError)
+		writeError(w, err, "レスポンス生成に失敗しました")
 		return
 	}
 
@@ -149,17 +144,17 @@ func (c *PostController) HandleGetChildrenPosts(w http.ResponseWriter, r *http.R
 	vars := mux.Vars(r)
 	parentPostID := vars["post_id"]
 
-	posts, err := c.postUseCase.GetChildrenPosts(parentPostID)
+	posts, err := c.postUseCase.GetChildrenPosts(r.Context(), parentPostID, r.URL.Query().Get("auth_id"))
 	if err != nil {
 		log.Printf("[post_controller.go] 子ポスト一覧取得失敗: %v", err)
-		http.Error(w, "子ポスト一覧の取得に失敗しました", http.StatusInternalServerError)
+		writeError(w, err, "子ポスト一覧の取得に失敗しました")
 		return
 	}
 
 	resp, err := json.Marshal(posts)
 	if err != nil {
 		log.Printf("[post_controller.go] JSONエンコード失敗: %v", err)
-		http.Error(w, "レスポンス生成に失敗しました", http.StatusInternalServerError)
+		writeError(w, err, "レスポンス生成に失敗しました")
 		return
 	}
 
@@ -0,0 +1,54 @@
+package controller
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"twitter/usecase"
+
+	"github.com/gorilla/mux"
+)
+
+// RecommendController おすすめユーザー用のコントローラー
+type RecommendController struct {
+	recommendUseCase *usecase.RecommendUseCase
+}
+
+func NewRecommendController(recommendUseCase *usecase.RecommendUseCase) *RecommendController {
+	return &RecommendController{recommendUseCase: recommendUseCase}
+}
+
+// HandleRecommendUsers フォローグラフからおすすめユーザーを取得 (limit, offset でページ分割)
+func (c *RecommendController) HandleRecommendUsers(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	authID := vars["auth_id"]
+
+	limit := parseLimitWithDefault(r.URL.Query().Get("limit"), DefaultRecommendLimit)
+	offset := 0
+	if offsetStr := r.URL.Query().Get("offset"); offsetStr != "" {
+		var err error
+		offset, err = strconv.Atoi(offsetStr)
+		if err != nil || offset < 0 {
+			writeError(w, invalidParameter("offset", "offset が不正です"), "")
+			return
+		}
+	}
+
+	page, err := c.recommendUseCase.RecommendUsers(r.Context(), authID, limit, offset)
+	if err != nil {
+		log.Printf("[recommend_controller.go] おすすめユーザー取得失敗 (auth_id: %s): %v", authID, err)
+		writeError(w, err, "おすすめユーザーの取得に失敗しました")
+		return
+	}
+
+	resp, err := json.Marshal(page)
+	if err != nil {
+		log.Printf("[recommend_controller.go] JSONエンコード失敗: %v", err)
+		writeError(w, err, "レスポンス生成に失敗しました")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(resp)
+}
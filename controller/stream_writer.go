@@ -0,0 +1,71 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// streamWriter 生成中のイベントを SSE (text/event-stream) または NDJSON (application/x-ndjson) で逐次書き出す
+// ヘッダは最初のイベントを送るときに書くので、それまではエラーを通常のレスポンスとして返せる
+type streamWriter struct {
+	w       http.ResponseWriter
+	flusher http.Flusher
+	ndjson  bool
+	started bool
+}
+
+// newStreamWriter Accept ヘッダに application/x-ndjson が含まれていれば NDJSON、そうでなければ SSE を使う
+func newStreamWriter(w http.ResponseWriter, r *http.Request) (*streamWriter, error) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return nil, fmt.Errorf("ストリーミングに対応していない ResponseWriter です")
+	}
+	return &streamWriter{
+		w:       w,
+		flusher: flusher,
+		ndjson:  strings.Contains(r.Header.Get("Accept"), "application/x-ndjson"),
+	}, nil
+}
+
+// Started 既にイベントを書き出し始めたか
+func (s *streamWriter) Started() bool {
+	return s.started
+}
+
+// Send イベントを1つ書き出してフラッシュする
+func (s *streamWriter) Send(event string, data interface{}) error {
+	if !s.started {
+		if s.ndjson {
+			s.w.Header().Set("Content-Type", "application/x-ndjson")
+		} else {
+			s.w.Header().Set("Content-Type", "text/event-stream")
+			s.w.Header().Set("Cache-Control", "no-cache")
+		}
+		s.w.WriteHeader(http.StatusOK)
+		s.started = true
+	}
+
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	if s.ndjson {
+		line, err := json.Marshal(struct {
+			Event string          `json:"event"`
+			Data  json.RawMessage `json:"data"`
+		}{Event: event, Data: payload})
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(s.w, "%s\n", line)
+		if err != nil {
+			return err
+		}
+	} else if _, err := fmt.Fprintf(s.w, "event: %s\ndata: %s\n\n", event, payload); err != nil {
+		return err
+	}
+	s.flusher.Flush()
+	return nil
+}
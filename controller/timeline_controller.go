@@ -22,17 +22,17 @@ func (c *TimelineController) HandleGetUserTimeline(w http.ResponseWriter, r *htt
 	vars := mux.Vars(r)
 	authID := vars["auth_id"]
 
-	posts, err := c.timelineUseCase.GetUserTimeline(authID)
+	posts, err := c.timelineUseCase.GetUserTimeline(r.Context(), authID)
 	if err != nil {
 		log.Printf("[timeline_controller.go] タイムライン取得失敗: %v", err)
-		http.Error(w, "タイムライン取得に失敗しました", http.StatusInternalServerError)
+		writeError(w, err, "タイムライン取得に失敗しました")
 		return
 	}
 
 	resp, err := json.Marshal(posts)
 	if err != nil {
 		log.Printf("[timeline_controller.go] JSONエンコード失敗: %v", err)
-		http.Error(w, "レスポンス生成に失敗しました", http.StatusInternalServerError)
+		writeError(w, err, "レスポンス生成に失敗しました")
 		return
 	}
 
@@ -45,17 +45,17 @@ func (c *TimelineController) HandleGetUserPosts(w http.ResponseWriter, r *http.R
 	vars := mux.Vars(r)
 	userID := vars["user_id"]
 
-	posts, err := c.timelineUseCase.GetUserPosts(userID)
+	posts, err := c.timelineUseCase.GetUserPosts(r.Context(), userID, r.URL.Query().Get("auth_id"))
 	if err != nil {
 		log.Printf("[timeline_controller.go] 投稿一覧取得失敗: %v", err)
-		http.Error(w, "投稿一覧取得に失敗しました", http.StatusInternalServerError)
+		writeError(w, err, "投稿一覧取得に失敗しました")
 		return
 	}
 
 	resp, err := json.Marshal(posts)
 	if err != nil {
 		log.Printf("[timeline_controller.go] JSONエンコード失敗: %v", err)
-		http.Error(w, "レスポンス生成に失敗しました", http.StatusInternalServerError)
+		writeError(w, err, "レスポンス生成に失敗しました")
 		return
 	}
 
@@ -68,17 +68,17 @@ func (c *TimelineController) HandleGetLikedPosts(w http.ResponseWriter, r *http.
 	vars := mux.Vars(r)
 	userID := vars["user_id"]
 
-	posts, err := c.timelineUseCase.GetLikedPosts(userID)
+	posts, err := c.timelineUseCase.GetLikedPosts(r.Context(), userID, r.URL.Query().Get("auth_id"))
 	if err != nil {
 		log.Printf("[timeline_controller.go] いいねした投稿一覧取得失敗: %v", err)
-		http.Error(w, "いいねした投稿一覧取得に失敗しました", http.StatusInternalServerError)
+		writeError(w, err, "いいねした投稿一覧取得に失敗しました")
 		return
 	}
 
 	resp, err := json.Marshal(posts)
 	if err != nil {
 		log.Printf("[timeline_controller.go] JSONエンコード失敗: %v", err)
-		http.Error(w, "レスポンス生成に失敗しました", http.StatusInternalServerError)
+		writeError(w, err, "レスポンス生成に失敗しました")
 		return
 	}
 
@@ -0,0 +1,25 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// エンドポイントごとの処理の締め切り (超えるとDBの問い合わせやAIの呼び出しを中断して 504 を返す)
+const (
+	DefaultTimeout = 10 * time.Second // DBの読み書きのみのエンドポイント
+	SearchTimeout  = 5 * time.Second  // キーワード検索・入力補完 (重い全文検索を早めに打ち切る)
+	AITimeout      = 60 * time.Second // AIを呼び出すエンドポイント (複数回の呼び出しや再試行を含む)
+	StreamTimeout  = 2 * time.Minute  // AIの生成結果を逐次返すエンドポイント
+)
+
+// WithTimeout リクエストの context に締め切りを設定してハンドラを呼び出す
+// クライアントの切断と同じく、締め切りを過ぎると context を使う処理が中断される
+func WithTimeout(timeout time.Duration, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+		next(w, r.WithContext(ctx))
+	}
+}
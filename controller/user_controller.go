@@ -26,17 +26,17 @@ func (c *UserController) HandleGetUser(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	userID := vars["user_id"]
 
-	user, err := c.userUseCase.GetUser(userID)
+	user, err := c.userUseCase.GetUser(r.Context(), userID)
 	if err != nil {
 		log.Printf("[user_controller.go] ユーザー取得失敗 (user_id: %s): %v", userID, err)
-		http.Error(w, "指定されたユーザーが見つかりません", http.StatusNotFound)
+		writeError(w, err, "ユーザーの取得に失敗しました")
 		return
 	}
 
 	resp, err := json.Marshal(user)
 	if err != nil {
 		log.Printf("[user_controller.go] JSONエンコード失敗: %v", err)
-		http.Error(w, "レスポンス生成に失敗しました", http.StatusInternalServerError)
+		writeError(w, err, "レスポンス生成に失敗しました")
 		return
 	}
 
@@ -50,27 +50,27 @@ func (c *UserController) HandleUpdateProfile(w http.ResponseWriter, r *http.Requ
 	var req model.User
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		log.Printf("[user_controller.go] JSONデコード失敗: %v", err)
-		http.Error(w, "リクエストの形式が不正です", http.StatusBadRequest)
+		writeError(w, errInvalidJSON.Wrap(err), "")
 		return
 	}
 
-	if err := c.userUseCase.UpdateProfile(req); err != nil {
+	if err := c.userUseCase.UpdateProfile(r.Context(), req); err != nil {
 		log.Printf("[user_controller.go] プロフィール更新失敗 (user_id: %s): %v", req.UserID, err)
-		http.Error(w, "プロフィール更新に失敗しました", http.StatusInternalServerError)
+		writeError(w, err, "プロフィール更新に失敗しました")
 		return
 	}
 
-	updatedUser, err := c.userUseCase.GetUpdatedUser(req.UserID)
+	updatedUser, err := c.userUseCase.GetUpdatedUser(r.Context(), req.UserID)
 	if err != nil {
 		log.Printf("[user_controller.go] 更新後のユーザー取得失敗 (user_id: %s): %v", req.UserID, err)
-		http.Error(w, "更新後のユーザー情報取得に失敗しました", http.StatusInternalServerError)
+		writeError(w, err, "更新後のユーザー情報取得に失敗しました")
 		return
 	}
 
 	resp, err := json.Marshal(updatedUser)
 	if err != nil {
 		log.Printf("[user_controller.go] JSONエンコード失敗: %v", err)
-		http.Error(w, "レスポンス生成に失敗しました", http.StatusInternalServerError)
+		writeError(w, err, "レスポンス生成に失敗しました")
 		return
 	}
 
@@ -85,9 +85,9 @@ const DefaultLimit = 100
 // HandleGetTopUsersByTweetCount ツイート数の多い順にユーザ一覧を取得
 func (c *UserController) HandleGetTopUsersByTweetCount(w http.ResponseWriter, r *http.Request) {
 	limit := parseLimit(r.URL.Query().Get("limit"))
-	users, err := c.userUseCase.GetTopUsersByTweetCount(limit)
+	users, err := c.userUseCase.GetTopUsersByTweetCount(r.Context(), limit)
 	if err != nil {
-		http.Error(w, "ユーザ一覧の取得に失敗しました", http.StatusInternalServerError)
+		writeError(w, err, "ユーザ一覧の取得に失敗しました")
 		return
 	}
 	json.NewEncoder(w).Encode(users)
@@ -96,9 +96,9 @@ func (c *UserController) HandleGetTopUsersByTweetCount(w http.ResponseWriter, r
 // HandleGetTopUsersByLikes いいね数の多い順にユーザ一覧を取得
 func (c *UserController) HandleGetTopUsersByLikes(w http.ResponseWriter, r *http.Request) {
 	limit := parseLimit(r.URL.Query().Get("limit"))
-	users, err := c.userUseCase.GetTopUsersByLikes(limit)
+	users, err := c.userUseCase.GetTopUsersByLikes(r.Context(), limit)
 	if err != nil {
-		http.Error(w, "ユーザ一覧の取得に失敗しました", http.StatusInternalServerError)
+		writeError(w, err, "ユーザ一覧の取得に失敗しました")
 		return
 	}
 	json.NewEncoder(w).Encode(users)
@@ -106,12 +106,17 @@ func (c *UserController) HandleGetTopUsersByLikes(w http.ResponseWriter, r *http
 
 // parseLimit リクエストのlimit値を解析し、デフォルト値や無効値を処理
 func parseLimit(limitStr string) int {
+	return parseLimitWithDefault(limitStr, DefaultLimit)
+}
+
+// parseLimitWithDefault リクエストのlimit値を解析し、空や無効値なら指定したデフォルト値を返す
+func parseLimitWithDefault(limitStr string, defaultLimit int) int {
 	if limitStr == "" {
-		return DefaultLimit
+		return defaultLimit
 	}
 	limit, err := strconv.Atoi(limitStr)
 	if err != nil || limit <= 0 {
-		return DefaultLimit
+		return defaultLimit
 	}
 	return limit
 }
@@ -1,6 +1,7 @@
 package dao
 
 import (
+	"context"
 	"database/sql"
 	"log"
 	"twitter/model"
@@ -14,7 +15,8 @@ func NewAuthDAO(db *sql.DB) *AuthDAO {
 	return &AuthDAO{db: db}
 }
 
-func (dao *AuthDAO) RegisterUser(user model.User) error {
+// RegisterUser ユーザーを登録 (登録済みのIDなら ErrUserExists)
+func (dao *AuthDAO) RegisterUser(ctx context.Context, user model.User) error {
 	// ポインタ型のフィールドを確認して値を取得
 	var bio, profileImgURL interface{}
 	if user.Bio != nil {
@@ -28,7 +30,7 @@ func (dao *AuthDAO) RegisterUser(user model.User) error {
 		profileImgURL = nil
 	}
 
-	_, err := dao.db.Exec(
+	_, err := conn(ctx, dao.db).ExecContext(ctx,
 		"INSERT INTO users (user_id, name, bio, profile_img_url) VALUES (?, ?, ?, ?)",
 		user.UserID,
 		user.Name,
@@ -37,6 +39,7 @@ func (dao *AuthDAO) RegisterUser(user model.User) error {
 	)
 	if err != nil {
 		log.Printf("[auth_dao.go] 以下のユーザー登録失敗 (user_id: %s, name: %s): %v", user.UserID, user.Name, err)
+		return translateDBError(err, ErrUserExists, nil)
 	}
-	return err
+	return nil
 }
@@ -0,0 +1,100 @@
+package dao
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+	"twitter/model"
+)
+
+// BlockDAO ユーザーのブロック用のDAO
+type BlockDAO struct {
+	db *sql.DB
+}
+
+func NewBlockDAO(db *sql.DB) *BlockDAO {
+	return &BlockDAO{db: db}
+}
+
+// AddBlock ブロックを追加 (ブロック済みなら ErrAlreadyBlocked、ユーザーが存在しなければ ErrUserNotFound)
+func (dao *BlockDAO) AddBlock(ctx context.Context, userID, blockedUserID string) error {
+	_, err := conn(ctx, dao.db).ExecContext(ctx, "INSERT INTO user_blocks (user_id, blocked_user_id, created_at) VALUES (?, ?, ?)", userID, blockedUserID, time.Now())
+	if err != nil {
+		log.Printf("[block_dao.go] ブロック追加失敗 (user_id: %s, blocked_user_id: %s): %v", userID, blockedUserID, err)
+		return translateDBError(err, ErrAlreadyBlocked, ErrUserNotFound)
+	}
+	return nil
+}
+
+// RemoveBlock ブロックを解除 (ブロックしていなければ ErrNotBlocked)
+func (dao *BlockDAO) RemoveBlock(ctx context.Context, userID, blockedUserID string) error {
+	result, err := conn(ctx, dao.db).ExecContext(ctx, "DELETE FROM user_blocks WHERE user_id = ? AND blocked_user_id = ?", userID, blockedUserID)
+	if err != nil {
+		log.Printf("[block_dao.go] ブロック解除失敗 (user_id: %s, blocked_user_id: %s): %v", userID, blockedUserID, err)
+		return err
+	}
+	return notFoundIfNoRows(result, ErrNotBlocked)
+}
+
+// GetBlockedUsers ユーザーがブロックしているユーザー一覧を新しい順に取得
+func (dao *BlockDAO) GetBlockedUsers(ctx context.Context, userID string) ([]model.User, error) {
+	rows, err := conn(ctx, dao.db).QueryContext(ctx, `
+		SELECT u.user_id, u.name, u.bio, u.profile_img_url, u.header_img_url
+		FROM users u
+		JOIN user_blocks b ON b.blocked_user_id = u.user_id
+		WHERE b.user_id = ?
+		ORDER BY b.created_at DESC`, userID)
+	if err != nil {
+		log.Printf("[block_dao.go] ブロック中のユーザー取得失敗 (user_id: %s): %v", userID, err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []model.User
+	for rows.Next() {
+		var user model.User
+		var bio, profileImgURL, headerImgURL sql.NullString
+		if err := rows.Scan(&user.UserID, &user.Name, &bio, &profileImgURL, &headerImgURL); err != nil {
+			log.Printf("[block_dao.go] ユーザーデータのScan失敗: %v", err)
+			return nil, err
+		}
+		user.Bio = nullableToPointer(bio)
+		user.ProfileImgURL = nullableToPointer(profileImgURL)
+		user.HeaderImgURL = nullableToPointer(headerImgURL)
+		users = append(users, user)
+	}
+	return users, rows.Err()
+}
+
+// GetBlockedUserIDs ユーザーがブロックしている、またはユーザーをブロックしているユーザーIDの集合を取得
+func (dao *BlockDAO) GetBlockedUserIDs(ctx context.Context, userID string) (map[string]bool, error) {
+	rows, err := conn(ctx, dao.db).QueryContext(ctx, `
+		SELECT blocked_user_id FROM user_blocks WHERE user_id = ?
+		UNION
+		SELECT user_id FROM user_blocks WHERE blocked_user_id = ?`, userID, userID)
+	if err != nil {
+		log.Printf("[block_dao.go] ブロック関係の取得失敗 (user_id: %s): %v", userID, err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	userIDs := make(map[string]bool)
+	for rows.Next() {
+		var blockedUserID string
+		if err := rows.Scan(&blockedUserID); err != nil {
+			log.Printf("[block_dao.go] ユーザーIDのScan失敗: %v", err)
+			return nil, err
+		}
+		userIDs[blockedUserID] = true
+	}
+	return userIDs, rows.Err()
+}
+
+// blockedSQL 指定カラムのユーザーと閲覧者のどちらかがもう一方をブロックしているかを判定するSQL条件 (閲覧者IDのプレースホルダを2つ含む)
+func blockedSQL(userColumn string) string {
+	return fmt.Sprintf(`EXISTS (
+			SELECT 1 FROM user_blocks b
+			WHERE (b.user_id = ? AND b.blocked_user_id = %[1]s) OR (b.user_id = %[1]s AND b.blocked_user_id = ?))`, userColumn)
+}
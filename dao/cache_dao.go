@@ -0,0 +1,74 @@
+package dao
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"twitter/model"
+)
+
+// CacheDAO AI生成結果のキャッシュを永続化するDAO
+type CacheDAO struct {
+	db *sql.DB
+}
+
+func NewCacheDAO(db *sql.DB) *CacheDAO {
+	return &CacheDAO{db: db}
+}
+
+// GetGeneration キャッシュキーに対応する有効期限内の生成結果を取得 (存在しない場合は nil)
+func (dao *CacheDAO) GetGeneration(ctx context.Context, cacheKey string) (*model.CachedGeneration, error) {
+	var generation model.CachedGeneration
+	err := conn(ctx, dao.db).QueryRowContext(ctx, `
+		SELECT cache_key, target_id, template_id, response, created_at, expires_at
+		FROM ai_cache
+		WHERE cache_key = ? AND expires_at > UTC_TIMESTAMP()`, cacheKey).Scan(
+		&generation.CacheKey,
+		&generation.TargetID,
+		&generation.TemplateID,
+		&generation.Response,
+		&generation.CreatedAt,
+		&generation.ExpiresAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		log.Printf("[cache_dao.go] キャッシュ取得失敗 (cache_key: %s): %v", cacheKey, err)
+		return nil, err
+	}
+	return &generation, nil
+}
+
+// SaveGeneration 生成結果を保存 (同じキーがあれば上書き)
+func (dao *CacheDAO) SaveGeneration(ctx context.Context, generation model.CachedGeneration) error {
+	_, err := conn(ctx, dao.db).ExecContext(ctx, `
+		INSERT INTO ai_cache (cache_key, target_id, template_id, response, created_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE
+			target_id = VALUES(target_id),
+			template_id = VALUES(template_id),
+			response = VALUES(response),
+			created_at = VALUES(created_at),
+			expires_at = VALUES(expires_at)`,
+		generation.CacheKey,
+		generation.TargetID,
+		generation.TemplateID,
+		generation.Response,
+		generation.CreatedAt,
+		generation.ExpiresAt,
+	)
+	if err != nil {
+		log.Printf("[cache_dao.go] キャッシュ保存失敗 (target_id: %s, template_id: %s): %v", generation.TargetID, generation.TemplateID, err)
+	}
+	return err
+}
+
+// DeleteByTarget 指定した対象 (ユーザーIDや投稿ID) のキャッシュを全て削除
+func (dao *CacheDAO) DeleteByTarget(ctx context.Context, targetID string) error {
+	_, err := conn(ctx, dao.db).ExecContext(ctx, "DELETE FROM ai_cache WHERE target_id = ?", targetID)
+	if err != nil {
+		log.Printf("[cache_dao.go] キャッシュ削除失敗 (target_id: %s): %v", targetID, err)
+	}
+	return err
+}
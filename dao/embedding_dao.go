@@ -0,0 +1,281 @@
+package dao
+
+import (
+	aiplatform "cloud.google.com/go/aiplatform/apiv1"
+	"cloud.google.com/go/aiplatform/apiv1/aiplatformpb"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"google.golang.org/api/option"
+	"google.golang.org/protobuf/types/known/structpb"
+	"log"
+	"time"
+	"twitter/config"
+	"twitter/model"
+)
+
+const (
+	embeddingBatchSize = 50 // 1リクエストあたりの最大テキスト数
+)
+
+// 埋め込みの用途 (Vertex AI の task_type)
+const (
+	EmbeddingTaskSimilarity = "SEMANTIC_SIMILARITY" // テキスト同士の類似度 (おすすめユーザー)
+	EmbeddingTaskDocument   = "RETRIEVAL_DOCUMENT"  // 検索される文書 (投稿)
+	EmbeddingTaskQuery      = "RETRIEVAL_QUERY"     // 検索クエリ
+)
+
+// EmbeddingDAO 埋め込みベクトル用のDAO
+type EmbeddingDAO struct {
+	db      *sql.DB
+	ai      config.AIConfig
+	breaker *circuitBreaker
+}
+
+func NewEmbeddingDAO(db *sql.DB, ai config.AIConfig) *EmbeddingDAO {
+	return &EmbeddingDAO{db: db, ai: ai, breaker: newCircuitBreaker("埋め込み")}
+}
+
+// EmbedTexts Vertex AI を使用してテキスト同士の類似度を測るための埋め込みベクトルを生成
+func (dao *EmbeddingDAO) EmbedTexts(ctx context.Context, texts []string) ([][]float32, error) {
+	return dao.EmbedTextsForTask(ctx, texts, EmbeddingTaskSimilarity)
+}
+
+// EmbedTextsForTask Vertex AI を使用して用途 taskType に合わせたテキストの埋め込みベクトルを生成
+// ctx の期限・キャンセルに従い、一時的な失敗は再試行する
+func (dao *EmbeddingDAO) EmbedTextsForTask(ctx context.Context, texts []string, taskType string) ([][]float32, error) {
+	client, err := aiplatform.NewPredictionClient(ctx, option.WithEndpoint(dao.ai.Location+"-aiplatform.googleapis.com:443"))
+	if err != nil {
+		return nil, fmt.Errorf("埋め込みクライアントの初期化失敗: %w", err)
+	}
+	defer client.Close()
+
+	endpoint := fmt.Sprintf("projects/%s/locations/%s/publishers/google/models/%s", dao.ai.ProjectID, dao.ai.Location, dao.ai.EmbeddingModel)
+	vectors := make([][]float32, 0, len(texts))
+	for start := 0; start < len(texts); start += embeddingBatchSize {
+		end := min(start+embeddingBatchSize, len(texts))
+
+		instances := make([]*structpb.Value, 0, end-start)
+		for _, text := range texts[start:end] {
+			instance, err := structpb.NewValue(map[string]interface{}{
+				"content":   text,
+				"task_type": taskType,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("埋め込みリクエストの作成失敗: %w", err)
+			}
+			instances = append(instances, instance)
+		}
+
+		var resp *aiplatformpb.PredictResponse
+		err := callWithRetry(ctx, dao.breaker, func(ctx context.Context) error {
+			var err error
+			resp, err = client.Predict(ctx, &aiplatformpb.PredictRequest{Endpoint: endpoint, Instances: instances})
+			return err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("埋め込みの生成失敗: %w", err)
+		}
+		if len(resp.Predictions) != len(instances) {
+			return nil, fmt.Errorf("埋め込みの件数が一致しません (要求: %d, 応答: %d)", len(instances), len(resp.Predictions))
+		}
+
+		for _, prediction := range resp.Predictions {
+			values := prediction.GetStructValue().GetFields()["embeddings"].GetStructValue().GetFields()["values"].GetListValue().GetValues()
+			if len(values) == 0 {
+				return nil, fmt.Errorf("埋め込みの応答が空です")
+			}
+			vector := make([]float32, len(values))
+			for i, v := range values {
+				vector[i] = float32(v.GetNumberValue())
+			}
+			vectors = append(vectors, vector)
+		}
+	}
+	return vectors, nil
+}
+
+// SaveUserEmbedding ユーザーの埋め込みベクトルを保存 (既存なら上書き)
+func (dao *EmbeddingDAO) SaveUserEmbedding(ctx context.Context, embedding model.UserEmbedding) error {
+	vector, err := json.Marshal(embedding.Vector)
+	if err != nil {
+		return fmt.Errorf("埋め込みベクトルのエンコード失敗: %w", err)
+	}
+
+	_, err = conn(ctx, dao.db).ExecContext(ctx, `
+		INSERT INTO user_embeddings (user_id, embedding, source_hash, updated_at) VALUES (?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE embedding = VALUES(embedding), source_hash = VALUES(source_hash), updated_at = VALUES(updated_at)`,
+		embedding.UserID,
+		vector,
+		embedding.SourceHash,
+		embedding.UpdatedAt,
+	)
+	if err != nil {
+		log.Printf("[embedding_dao.go] 以下の埋め込み保存失敗 (user_id: %s): %v", embedding.UserID, err)
+	}
+	return err
+}
+
+// GetUserEmbedding ユーザーの埋め込みベクトルを取得 (存在しない場合は nil)
+func (dao *EmbeddingDAO) GetUserEmbedding(ctx context.Context, userID string) (*model.UserEmbedding, error) {
+	var embedding model.UserEmbedding
+	var vector []byte
+
+	err := conn(ctx, dao.db).QueryRowContext(ctx,
+		"SELECT user_id, embedding, source_hash, updated_at FROM user_embeddings WHERE user_id = ?",
+		userID,
+	).Scan(&embedding.UserID, &vector, &embedding.SourceHash, &embedding.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		log.Printf("[embedding_dao.go] 以下の埋め込み取得失敗 (user_id: %s): %v", userID, err)
+		return nil, err
+	}
+
+	if err := json.Unmarshal(vector, &embedding.Vector); err != nil {
+		return nil, fmt.Errorf("埋め込みベクトルのデコード失敗: %w", err)
+	}
+	return &embedding, nil
+}
+
+// TouchUserEmbedding 埋め込み元テキストが変わっていないユーザーの、埋め込みを確認した日時を更新
+func (dao *EmbeddingDAO) TouchUserEmbedding(ctx context.Context, userID string, updatedAt time.Time) error {
+	_, err := conn(ctx, dao.db).ExecContext(ctx, "UPDATE user_embeddings SET updated_at = ? WHERE user_id = ?", updatedAt, userID)
+	if err != nil {
+		log.Printf("[embedding_dao.go] 以下の埋め込みの更新日時の更新失敗 (user_id: %s): %v", userID, err)
+	}
+	return err
+}
+
+// FetchUserEmbeddings 指定したユーザーの埋め込みベクトルを取得 (埋め込みの無いユーザーは含めない)
+func (dao *EmbeddingDAO) FetchUserEmbeddings(ctx context.Context, userIDs []string) (map[string]model.UserEmbedding, error) {
+	embeddings := make(map[string]model.UserEmbedding)
+	if len(userIDs) == 0 {
+		return embeddings, nil
+	}
+	args := make([]interface{}, len(userIDs))
+	for i, userID := range userIDs {
+		args[i] = userID
+	}
+
+	rows, err := conn(ctx, dao.db).QueryContext(ctx, `
+		SELECT user_id, embedding, source_hash, updated_at
+		FROM user_embeddings
+		WHERE user_id IN (`+placeholders(len(userIDs))+`)`, args...)
+	if err != nil {
+		log.Printf("[embedding_dao.go] ユーザーの埋め込み取得失敗: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var embedding model.UserEmbedding
+		var vector []byte
+
+		if err := rows.Scan(&embedding.UserID, &vector, &embedding.SourceHash, &embedding.UpdatedAt); err != nil {
+			log.Printf("[embedding_dao.go] 埋め込みデータのScan失敗: %v", err)
+			return nil, err
+		}
+		if err := json.Unmarshal(vector, &embedding.Vector); err != nil {
+			log.Printf("[embedding_dao.go] 埋め込みベクトルのデコード失敗 (user_id: %s): %v", embedding.UserID, err)
+			continue
+		}
+
+		embeddings[embedding.UserID] = embedding
+	}
+	return embeddings, rows.Err()
+}
+
+// SavePostEmbedding 投稿の埋め込みベクトルを保存 (既存なら上書き)
+// 投稿が削除されていれば保存せずに false を返す
+func (dao *EmbeddingDAO) SavePostEmbedding(ctx context.Context, embedding model.PostEmbedding) (bool, error) {
+	vector, err := json.Marshal(embedding.Vector)
+	if err != nil {
+		return false, fmt.Errorf("埋め込みベクトルのエンコード失敗: %w", err)
+	}
+
+	result, err := conn(ctx, dao.db).ExecContext(ctx, `
+		INSERT INTO post_embeddings (post_id, embedding, updated_at)
+		SELECT post_id, ?, ? FROM posts WHERE post_id = ? AND deleted_at IS NULL
+		ON DUPLICATE KEY UPDATE embedding = VALUES(embedding), updated_at = VALUES(updated_at)`,
+		vector,
+		embedding.UpdatedAt,
+		embedding.PostID,
+	)
+	if err != nil {
+		log.Printf("[embedding_dao.go] 以下の投稿の埋め込み保存失敗 (post_id: %s): %v", embedding.PostID, err)
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+// DeletePostEmbedding 投稿の埋め込みベクトルを削除
+func (dao *EmbeddingDAO) DeletePostEmbedding(ctx context.Context, postID string) error {
+	_, err := conn(ctx, dao.db).ExecContext(ctx, "DELETE FROM post_embeddings WHERE post_id = ?", postID)
+	if err != nil {
+		log.Printf("[embedding_dao.go] 以下の投稿の埋め込み削除失敗 (post_id: %s): %v", postID, err)
+	}
+	return err
+}
+
+// FetchPostEmbeddings 削除されていない投稿の埋め込みベクトルを全て取得し、1件ずつ fn に渡す
+func (dao *EmbeddingDAO) FetchPostEmbeddings(ctx context.Context, fn func(model.PostEmbedding)) error {
+	rows, err := conn(ctx, dao.db).QueryContext(ctx, `
+		SELECT e.post_id, e.embedding, e.updated_at
+		FROM post_embeddings e
+		JOIN posts p ON p.post_id = e.post_id
+		WHERE p.deleted_at IS NULL`)
+	if err != nil {
+		log.Printf("[embedding_dao.go] 投稿の埋め込み一覧取得失敗: %v", err)
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var embedding model.PostEmbedding
+		var vector []byte
+
+		if err := rows.Scan(&embedding.PostID, &vector, &embedding.UpdatedAt); err != nil {
+			log.Printf("[embedding_dao.go] 埋め込みデータのScan失敗: %v", err)
+			return err
+		}
+		if err := json.Unmarshal(vector, &embedding.Vector); err != nil {
+			log.Printf("[embedding_dao.go] 埋め込みベクトルのデコード失敗 (post_id: %s): %v", embedding.PostID, err)
+			continue
+		}
+		fn(embedding)
+	}
+	return rows.Err()
+}
+
+// FetchUnindexedPosts 埋め込みベクトルがまだ無い投稿を新しい順に最大 limit 件取得 (post_id と content のみ)
+func (dao *EmbeddingDAO) FetchUnindexedPosts(ctx context.Context, limit int) ([]model.Post, error) {
+	rows, err := conn(ctx, dao.db).QueryContext(ctx, `
+		SELECT p.post_id, p.content
+		FROM posts p
+		LEFT JOIN post_embeddings e ON e.post_id = p.post_id
+		WHERE p.deleted_at IS NULL AND e.post_id IS NULL
+		ORDER BY p.created_at DESC
+		LIMIT ?`, limit)
+	if err != nil {
+		log.Printf("[embedding_dao.go] 埋め込みの無い投稿の取得失敗: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var posts []model.Post
+	for rows.Next() {
+		var post model.Post
+		if err := rows.Scan(&post.PostID, &post.Content); err != nil {
+			log.Printf("[embedding_dao.go] 投稿データのScan失敗: %v", err)
+			return nil, err
+		}
+		posts = append(posts, post)
+	}
+	return posts, rows.Err()
+}
@@ -0,0 +1,66 @@
+package dao
+
+import (
+	"errors"
+	"github.com/go-sql-driver/mysql"
+	"twitter/apperr"
+)
+
+// MySQL のエラー番号
+const (
+	mysqlDuplicateEntry     = 1062 // 一意制約に違反した
+	mysqlNoReferencedRow    = 1452 // 外部キーの参照先が存在しない
+	mysqlNoReferencedRowOld = 1216 // 外部キーの参照先が存在しない (古いバージョン)
+	mysqlLockWaitTimeout    = 1205 // ロック待ちがタイムアウトした
+	mysqlDeadlock           = 1213 // デッドロックを検出してトランザクションがロールバックされた
+)
+
+// 投稿・ユーザー・フォロー・いいね・ブロックの失敗の種類
+var (
+	ErrPostNotFound      = apperr.NotFound("post_not_found", "投稿が存在しません")
+	ErrPostDeleted       = apperr.Gone("post_deleted", "投稿が削除されています")
+	ErrUserNotFound      = apperr.NotFound("user_not_found", "ユーザーが存在しません")
+	ErrUserExists        = apperr.Conflict("user_exists", "このユーザーIDは登録済みです")
+	ErrAlreadyFollowing  = apperr.Conflict("already_following", "既にフォローしています")
+	ErrNotFollowing      = apperr.NotFound("not_following", "フォローしていません")
+	ErrAlreadyLiked      = apperr.Conflict("already_liked", "既にいいねしています")
+	ErrNotLiked          = apperr.NotFound("not_liked", "いいねしていません")
+	ErrAlreadyBlocked    = apperr.Conflict("already_blocked", "既にブロックしています")
+	ErrNotBlocked        = apperr.NotFound("not_blocked", "ブロックしていません")
+	ErrFollowBlocked     = apperr.Forbidden("follow_blocked", "ブロック関係にあるユーザーはフォローできません")
+	ErrReferenceNotFound = apperr.NotFound("reference_not_found", "参照先のデータが存在しません")
+)
+
+// translateDBError ヘルパー関数: MySQL の制約違反を種類のあるエラーに変換する
+// 一意制約の違反は duplicate に、外部キーの参照先が無い場合は missingRef (nil なら ErrReferenceNotFound) に変換し、
+// それ以外はそのまま返す
+func translateDBError(err error, duplicate, missingRef *apperr.Error) error {
+	var mysqlErr *mysql.MySQLError
+	if !errors.As(err, &mysqlErr) {
+		return err
+	}
+	switch mysqlErr.Number {
+	case mysqlDuplicateEntry:
+		if duplicate != nil {
+			return duplicate.Wrap(err)
+		}
+	case mysqlNoReferencedRow, mysqlNoReferencedRowOld:
+		if missingRef == nil {
+			missingRef = ErrReferenceNotFound
+		}
+		return missingRef.Wrap(err)
+	}
+	return err
+}
+
+// notFoundIfNoRows ヘルパー関数: 更新・削除した行が無ければ notFound を返す
+func notFoundIfNoRows(result interface{ RowsAffected() (int64, error) }, notFound *apperr.Error) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return notFound
+	}
+	return nil
+}
@@ -1,9 +1,13 @@
 package dao
 
 import (
+	"context"
 	"database/sql"
 	"log"
+	"strings"
+	"time"
 	"twitter/model"
+	"twitter/search"
 )
 
 // FindDAO 検索用のDAO
@@ -15,23 +19,30 @@ func NewFindDAO(db *sql.DB) *FindDAO {
 	return &FindDAO{db: db}
 }
 
-// FindUsersByKey 指定したキーワードを name または bio に含むユーザーを検索
-func (dao *FindDAO) FindUsersByKey(key string) ([]model.User, error) {
-	rows, err := dao.db.Query(`
-		SELECT user_id, name, bio, profile_img_url, header_img_url
+// FindUsersByTerms 全ての語を name または bio に含むユーザーを関連度の高い順に最大 limit 件検索
+// 語が全文検索インデックス (ngram) で探せる長さなら MATCH ... AGAINST を使い、短い語を含むなら LIKE で探す (関連度は全て1)
+func (dao *FindDAO) FindUsersByTerms(ctx context.Context, terms []string, limit int) ([]model.UserHit, error) {
+	if len(terms) == 0 {
+		return nil, nil
+	}
+	relevance, condition, args := fullTextCondition([]string{"name", "bio"}, terms)
+	args = append(args, limit)
+
+	rows, err := conn(ctx, dao.db).QueryContext(ctx, `
+		SELECT user_id, name, bio, profile_img_url, header_img_url, `+relevance+` AS relevance
 		FROM users
-		WHERE name LIKE ? OR bio LIKE ?`,
-		"%"+key+"%", "%"+key+"%",
-	)
+		WHERE `+condition+` AND NOT `+restrictedSQL("user_id", model.RestrictionSuspend)+`
+		ORDER BY relevance DESC
+		LIMIT ?`, args...)
 	if err != nil {
-		log.Printf("[find_dao.go] ユーザー検索失敗 (key: %s): %v", key, err)
+		log.Printf("[find_dao.go] ユーザー検索失敗 (terms: %v): %v", terms, err)
 		return nil, err
 	}
 	defer rows.Close()
 
-	var users []model.User
+	var users []model.UserHit
 	for rows.Next() {
-		var user model.User
+		var user model.UserHit
 		var bio, profileImgURL, headerImgURL sql.NullString
 
 		if err := rows.Scan(
@@ -40,6 +51,7 @@ func (dao *FindDAO) FindUsersByKey(key string) ([]model.User, error) {
 			&bio,
 			&profileImgURL,
 			&headerImgURL,
+			&user.Score,
 		); err != nil {
 			log.Printf("[find_dao.go] ユーザーデータのScan失敗: %v", err)
 			return nil, err
@@ -51,19 +63,104 @@ func (dao *FindDAO) FindUsersByKey(key string) ([]model.User, error) {
 
 		users = append(users, user)
 	}
+	if err := rows.Err(); err != nil {
+		log.Printf("[find_dao.go] ユーザー検索失敗 (terms: %v): %v", terms, err)
+		return nil, translateDBError(err, nil, nil)
+	}
 	return users, nil
 }
 
-// FindPostsByKey 指定したキーワードを content に含む投稿を検索
-func (dao *FindDAO) FindPostsByKey(key string) ([]model.Post, error) {
-	rows, err := dao.db.Query(`
+// FindPostsByQuery 解析済みの検索クエリに一致する投稿を関連度の高い順に最大 limit 件検索 (viewerID は閲覧者のID)
+// クエリはプレースホルダを使ったSQLに変換する (語の扱いは postQueryCondition を参照)
+func (dao *FindDAO) FindPostsByQuery(ctx context.Context, query *search.Query, viewerID string, limit int) ([]model.PostHit, error) {
+	return dao.findPosts(ctx, query, viewerID, "TRUE", nil, "relevance DESC, p.created_at DESC", limit)
+}
+
+// FindNewPostsByQuery 検索クエリに一致し after より後に投稿されたものを古い順に最大 limit 件検索 (viewerID は閲覧者のID)
+// after が nil なら最近の投稿を新しい順に返す
+// 古い順に返すので、limit 件で打ち切られても最後の投稿の日時から続きを取得できる
+func (dao *FindDAO) FindNewPostsByQuery(ctx context.Context, query *search.Query, viewerID string, after *time.Time, limit int) ([]model.PostHit, error) {
+	if after == nil {
+		return dao.findPosts(ctx, query, viewerID, "TRUE", nil, "p.created_at DESC, p.post_id DESC", limit)
+	}
+	return dao.findPosts(ctx, query, viewerID, "p.created_at > ?", []interface{}{*after}, "p.created_at ASC, p.post_id ASC", limit)
+}
+
+// findPosts ヘルパー関数: 検索クエリと追加の条件 (extra) に一致する投稿を order の順に最大 limit 件検索
+func (dao *FindDAO) findPosts(ctx context.Context, query *search.Query, viewerID, extra string, extraArgs []interface{}, order string, limit int) ([]model.PostHit, error) {
+	if query == nil {
+		return nil, nil
+	}
+	relevance, condition, args := postQueryCondition(query)
+	args = append(args, extraArgs...)
+	args = append(args, viewerID, limit)
+
+	rows, err := conn(ctx, dao.db).QueryContext(ctx, `
+		SELECT p.post_id, p.user_id, p.content, p.img_url, p.created_at, p.edited_at, p.parent_post_id, p.is_bad, `+relevance+` AS relevance
+		FROM posts p
+		WHERE `+condition+` AND `+extra+` AND p.deleted_at IS NULL AND `+visibleAuthorSQL("p.user_id")+`
+		ORDER BY `+order+`
+		LIMIT ?`, args...)
+	if err != nil {
+		log.Printf("[find_dao.go] 投稿検索失敗 (query: %+v): %v", *query, err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var posts []model.PostHit
+	for rows.Next() {
+		var post model.PostHit
+		var imgURL, parentPostID sql.NullString
+		var editedAt sql.NullTime
+
+		if err := rows.Scan(
+			&post.PostID,
+			&post.UserID,
+			&post.Content,
+			&imgURL,
+			&post.CreatedAt,
+			&editedAt,
+			&parentPostID,
+			&post.IsBad,
+			&post.Score,
+		); err != nil {
+			log.Printf("[find_dao.go] 投稿データのScan失敗: %v", err)
+			return nil, err
+		}
+
+		// NULL 値の処理
+		post.ImgURL = nullableToPointer(imgURL)
+		post.ParentPostID = nullableToPointer(parentPostID)
+		if editedAt.Valid {
+			post.EditedAt = &editedAt.Time
+		}
+
+		posts = append(posts, post)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("[find_dao.go] 投稿検索失敗 (query: %+v): %v", *query, err)
+		return nil, translateDBError(err, nil, nil)
+	}
+	return posts, nil
+}
+
+// GetPostsByIDs 指定したIDの投稿のうち、削除されておらず閲覧者から見えるものを取得 (順序は保証しない、viewerID は閲覧者のID)
+func (dao *FindDAO) GetPostsByIDs(ctx context.Context, postIDs []string, viewerID string) ([]model.Post, error) {
+	if len(postIDs) == 0 {
+		return nil, nil
+	}
+	args := make([]interface{}, 0, len(postIDs)+1)
+	for _, postID := range postIDs {
+		args = append(args, postID)
+	}
+	args = append(args, viewerID)
+
+	rows, err := conn(ctx, dao.db).QueryContext(ctx, `
 		SELECT post_id, user_id, content, img_url, created_at, edited_at, parent_post_id, is_bad
 		FROM posts
-		WHERE content LIKE ? AND deleted_at IS NULL`,
-		"%"+key+"%",
-	)
+		WHERE post_id IN (`+placeholders(len(postIDs))+`) AND deleted_at IS NULL AND `+visibleAuthorSQL("user_id"), args...)
 	if err != nil {
-		log.Printf("[find_dao.go] 投稿検索失敗 (key: %s): %v", key, err)
+		log.Printf("[find_dao.go] ID指定の投稿取得失敗: %v", err)
 		return nil, err
 	}
 	defer rows.Close()
@@ -88,7 +185,6 @@ func (dao *FindDAO) FindPostsByKey(key string) ([]model.Post, error) {
 			return nil, err
 		}
 
-		// NULL 値の処理
 		post.ImgURL = nullableToPointer(imgURL)
 		post.ParentPostID = nullableToPointer(parentPostID)
 		if editedAt.Valid {
@@ -97,5 +193,234 @@ func (dao *FindDAO) FindPostsByKey(key string) ([]model.Post, error) {
 
 		posts = append(posts, post)
 	}
+	if err := rows.Err(); err != nil {
+		log.Printf("[find_dao.go] ID指定の投稿取得失敗: %v", err)
+		return nil, translateDBError(err, nil, nil)
+	}
 	return posts, nil
 }
+
+// fullTextCondition ヘルパー関数: 全ての語を columns のいずれかに含む条件と関連度のSQL、そのプレースホルダの値を返す
+// 全ての語が全文検索インデックス (ngram、columns の組み合わせで作成済み) で探せる長さなら MATCH ... AGAINST を使い、
+// 短い語を含むなら LIKE を使う (関連度は1)
+func fullTextCondition(columns []string, terms []string) (string, string, []interface{}) {
+	if search.Indexable(terms) {
+		// 各語を "" で囲んで語句として探し、+ で全ての語を必須にする
+		quoted := make([]string, len(terms))
+		for i, term := range terms {
+			quoted[i] = `+"` + term + `"`
+		}
+		query := strings.Join(quoted, " ")
+		match := "MATCH(" + strings.Join(columns, ", ") + ") AGAINST(? IN BOOLEAN MODE)"
+		return match, match, []interface{}{query, query}
+	}
+
+	var conditions []string
+	var args []interface{}
+	for _, term := range terms {
+		var columnConditions []string
+		for _, column := range columns {
+			columnConditions = append(columnConditions, column+" LIKE ?")
+			args = append(args, "%"+escapeLike(term)+"%")
+		}
+		conditions = append(conditions, "("+strings.Join(columnConditions, " OR ")+")")
+	}
+	return "1", strings.Join(conditions, " AND "), args
+}
+
+// postQueryCondition ヘルパー関数: 検索クエリを投稿 (別名 p) の条件と関連度のSQL、そのプレースホルダの値に変換する
+// 含むべき語が全て全文検索インデックスで探せる長さなら MATCH ... AGAINST を使い、短い語を含むなら LIKE を使う (関連度は1)
+// 除外する語は部分一致で除くため、常に NOT LIKE を使う
+func postQueryCondition(query *search.Query) (string, string, []interface{}) {
+	relevance := "1"
+	var relevanceArgs, args []interface{}
+	var conditions []string
+
+	if len(query.Clauses) > 0 && search.Indexable(query.PositiveTerms()) {
+		// 各語を "" で囲んで語句として探し、OR でつないだ語は () でまとめていずれかを必須にする
+		parts := make([]string, len(query.Clauses))
+		for i, clause := range query.Clauses {
+			quoted := make([]string, len(clause))
+			for j, term := range clause {
+				quoted[j] = `"` + term.Text + `"`
+			}
+			parts[i] = "+" + quoted[0]
+			if len(quoted) > 1 {
+				parts[i] = "+(" + strings.Join(quoted, " ") + ")"
+			}
+		}
+		match := "MATCH(p.content) AGAINST(? IN BOOLEAN MODE)"
+		relevance = match
+		relevanceArgs = []interface{}{strings.Join(parts, " ")}
+		conditions = append(conditions, match)
+		args = append(args, relevanceArgs...)
+	} else {
+		for _, clause := range query.Clauses {
+			alternatives := make([]string, len(clause))
+			for i, term := range clause {
+				alternatives[i] = "p.content LIKE ?"
+				args = append(args, "%"+escapeLike(term.Text)+"%")
+			}
+			conditions = append(conditions, "("+strings.Join(alternatives, " OR ")+")")
+		}
+	}
+
+	for _, term := range query.Excluded {
+		conditions = append(conditions, "p.content NOT LIKE ?")
+		args = append(args, "%"+escapeLike(term.Text)+"%")
+	}
+	if len(query.From) > 0 {
+		conditions = append(conditions, "p.user_id IN ("+placeholders(len(query.From))+")")
+		for _, userID := range query.From {
+			args = append(args, userID)
+		}
+	}
+	if len(query.To) > 0 {
+		conditions = append(conditions, `EXISTS (
+			SELECT 1 FROM posts parent
+			WHERE parent.post_id = p.parent_post_id AND parent.user_id IN (`+placeholders(len(query.To))+`)
+		)`)
+		for _, userID := range query.To {
+			args = append(args, userID)
+		}
+	}
+	if query.HasImage != nil {
+		if *query.HasImage {
+			conditions = append(conditions, "(p.img_url IS NOT NULL AND p.img_url <> '')")
+		} else {
+			conditions = append(conditions, "(p.img_url IS NULL OR p.img_url = '')")
+		}
+	}
+	if query.IsReply != nil {
+		if *query.IsReply {
+			conditions = append(conditions, "p.parent_post_id IS NOT NULL")
+		} else {
+			conditions = append(conditions, "p.parent_post_id IS NULL")
+		}
+	}
+	if query.Since != nil {
+		conditions = append(conditions, "p.created_at >= ?")
+		args = append(args, *query.Since)
+	}
+	if query.Until != nil {
+		conditions = append(conditions, "p.created_at < ?")
+		args = append(args, *query.Until)
+	}
+	if query.MinLikes > 0 {
+		conditions = append(conditions, "(SELECT COUNT(*) FROM likes l WHERE l.post_id = p.post_id) >= ?")
+		args = append(args, query.MinLikes)
+	}
+
+	if len(conditions) == 0 {
+		conditions = append(conditions, "TRUE")
+	}
+	// 関連度は SELECT 句で WHERE 句より前に現れるため、その値を先頭に置く
+	return relevance, strings.Join(conditions, " AND "), append(relevanceArgs, args...)
+}
+
+// placeholders ヘルパー関数: n 個のプレースホルダを , でつないだ文字列
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+// escapeLike ヘルパー関数: LIKE のワイルドカード (%, _) とエスケープ文字をエスケープする
+func escapeLike(text string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(text)
+}
+
+// FetchUserNames 入力補完の索引に使う、全ユーザーのIDと名前を取得 (ユーザーID -> 名前)
+func (dao *FindDAO) FetchUserNames(ctx context.Context) (map[string]string, error) {
+	rows, err := conn(ctx, dao.db).QueryContext(ctx, "SELECT user_id, name FROM users")
+	if err != nil {
+		log.Printf("[find_dao.go] ユーザー名の一覧取得失敗: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	names := make(map[string]string)
+	for rows.Next() {
+		var userID, name string
+		if err := rows.Scan(&userID, &name); err != nil {
+			log.Printf("[find_dao.go] ユーザー名のScan失敗: %v", err)
+			return nil, err
+		}
+		names[userID] = name
+	}
+	return names, rows.Err()
+}
+
+// FetchRecentPostContents since 以降の削除されておらず不適切でない投稿の内容を新しい順に最大 limit 件取得
+func (dao *FindDAO) FetchRecentPostContents(ctx context.Context, since time.Time, limit int) ([]string, error) {
+	rows, err := conn(ctx, dao.db).QueryContext(ctx, `
+		SELECT content
+		FROM posts
+		WHERE created_at >= ? AND deleted_at IS NULL AND is_bad = FALSE
+		ORDER BY created_at DESC
+		LIMIT ?`, since, limit)
+	if err != nil {
+		log.Printf("[find_dao.go] 最近の投稿の取得失敗: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var contents []string
+	for rows.Next() {
+		var content string
+		if err := rows.Scan(&content); err != nil {
+			log.Printf("[find_dao.go] 投稿内容のScan失敗: %v", err)
+			return nil, err
+		}
+		contents = append(contents, content)
+	}
+	return contents, rows.Err()
+}
+
+// FetchUserSuggestions 指定したユーザーのうち閲覧者から見えるものについて、フォロワー数と閲覧者のフォロー関係を取得 (順序は保証しない)
+func (dao *FindDAO) FetchUserSuggestions(ctx context.Context, userIDs []string, viewerID string) ([]model.UserSuggestion, error) {
+	if len(userIDs) == 0 {
+		return nil, nil
+	}
+	args := []interface{}{viewerID, viewerID}
+	for _, userID := range userIDs {
+		args = append(args, userID)
+	}
+	args = append(args, viewerID)
+
+	rows, err := conn(ctx, dao.db).QueryContext(ctx, `
+		SELECT u.user_id, u.name, u.profile_img_url,
+			(SELECT COUNT(*) FROM followers f WHERE f.following_user_id = u.user_id) AS follower_count,
+			EXISTS (SELECT 1 FROM followers f WHERE f.user_id = ? AND f.following_user_id = u.user_id) AS following,
+			(
+				SELECT COUNT(*)
+				FROM followers f1
+				JOIN followers f2 ON f2.user_id = f1.following_user_id
+				WHERE f1.user_id = ? AND f2.following_user_id = u.user_id
+			) AS followed_by_following
+		FROM users u
+		WHERE u.user_id IN (`+placeholders(len(userIDs))+`) AND `+visibleAuthorSQL("u.user_id"), args...)
+	if err != nil {
+		log.Printf("[find_dao.go] 入力補完のユーザー取得失敗: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []model.UserSuggestion
+	for rows.Next() {
+		var user model.UserSuggestion
+		var profileImgURL sql.NullString
+		if err := rows.Scan(
+			&user.UserID,
+			&user.Name,
+			&profileImgURL,
+			&user.FollowerCount,
+			&user.Following,
+			&user.FollowedByFollowing,
+		); err != nil {
+			log.Printf("[find_dao.go] ユーザーデータのScan失敗: %v", err)
+			return nil, err
+		}
+		user.ProfileImgURL = nullableToPointer(profileImgURL)
+		users = append(users, user)
+	}
+	return users, rows.Err()
+}
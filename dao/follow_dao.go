@@ -1,6 +1,7 @@
 package dao
 
 import (
+	"context"
 	"database/sql"
 	"log"
 	"time"
@@ -15,24 +16,34 @@ func NewFollowDAO(db *sql.DB) *FollowDAO {
 	return &FollowDAO{db: db}
 }
 
-func (dao *FollowDAO) AddFollow(userID, followingUserID string) error {
-	_, err := dao.db.Exec("INSERT INTO followers (user_id, following_user_id, created_at) VALUES (?, ?, ?)", userID, followingUserID, time.Now())
+// AddFollow フォローを追加 (フォロー済みなら ErrAlreadyFollowing、ユーザーが存在しなければ ErrUserNotFound、
+// どちらかがもう一方をブロックしていれば ErrFollowBlocked)
+func (dao *FollowDAO) AddFollow(ctx context.Context, userID, followingUserID string) error {
+	// ブロックの確認と追加を1文で行い、確認した後にブロックされてもフォローが残らないようにする
+	result, err := conn(ctx, dao.db).ExecContext(ctx, `
+		INSERT INTO followers (user_id, following_user_id, created_at)
+		SELECT t.user_id, t.following_user_id, ?
+		FROM (SELECT ? AS user_id, ? AS following_user_id) t
+		WHERE NOT `+blockedSQL("t.following_user_id"), time.Now(), userID, followingUserID, userID, userID)
 	if err != nil {
 		log.Printf("[follow_dao.go] 以下のフォロー追加失敗 (user_id: %s, following_user_id: %s): %v", userID, followingUserID, err)
+		return translateDBError(err, ErrAlreadyFollowing, ErrUserNotFound)
 	}
-	return err
+	return notFoundIfNoRows(result, ErrFollowBlocked)
 }
 
-func (dao *FollowDAO) RemoveFollow(userID, followingUserID string) error {
-	_, err := dao.db.Exec("DELETE FROM followers WHERE user_id = ? AND following_user_id = ?", userID, followingUserID)
+// RemoveFollow フォローを解除 (フォローしていなければ ErrNotFollowing)
+func (dao *FollowDAO) RemoveFollow(ctx context.Context, userID, followingUserID string) error {
+	result, err := conn(ctx, dao.db).ExecContext(ctx, "DELETE FROM followers WHERE user_id = ? AND following_user_id = ?", userID, followingUserID)
 	if err != nil {
 		log.Printf("[follow_dao.go] 以下のフォロー解除失敗 (user_id: %s, following_user_id: %s): %v", userID, followingUserID, err)
+		return err
 	}
-	return err
+	return notFoundIfNoRows(result, ErrNotFollowing)
 }
 
-func (dao *FollowDAO) GetFollowers(userID string) ([]model.User, error) {
-	rows, err := dao.db.Query(`SELECT u.user_id, u.name, u.bio, u.profile_img_url, u.header_img_url FROM users u INNER JOIN followers f ON u.user_id = f.user_id WHERE f.following_user_id = ?`, userID)
+func (dao *FollowDAO) GetFollowers(ctx context.Context, userID string) ([]model.User, error) {
+	rows, err := conn(ctx, dao.db).QueryContext(ctx, `SELECT u.user_id, u.name, u.bio, u.profile_img_url, u.header_img_url FROM users u INNER JOIN followers f ON u.user_id = f.user_id WHERE f.following_user_id = ?`, userID)
 	if err != nil {
 		log.Printf("[follow_dao.go] 以下のフォロワー一覧取得失敗 (user_id: %s): %v", userID, err)
 		return nil, err
@@ -60,8 +71,8 @@ func (dao *FollowDAO) GetFollowers(userID string) ([]model.User, error) {
 	return users, nil
 }
 
-func (dao *FollowDAO) GetFollowing(userID string) ([]model.User, error) {
-	rows, err := dao.db.Query(`SELECT u.user_id, u.name, u.bio, u.profile_img_url, u.header_img_url FROM users u INNER JOIN followers f ON u.user_id = f.following_user_id WHERE f.user_id = ?`, userID)
+func (dao *FollowDAO) GetFollowing(ctx context.Context, userID string) ([]model.User, error) {
+	rows, err := conn(ctx, dao.db).QueryContext(ctx, `SELECT u.user_id, u.name, u.bio, u.profile_img_url, u.header_img_url FROM users u INNER JOIN followers f ON u.user_id = f.following_user_id WHERE f.user_id = ?`, userID)
 	if err != nil {
 		log.Printf("[follow_dao.go] 以下のフォロー中一覧取得失敗 (user_id: %s): %v", userID, err)
 		return nil, err
@@ -90,8 +101,8 @@ func (dao *FollowDAO) GetFollowing(userID string) ([]model.User, error) {
 }
 
 // GetFollowGraph フォローグラフを取得
-func (dao *FollowDAO) GetFollowGraph() ([]model.Follow, error) {
-	rows, err := dao.db.Query("SELECT user_id, following_user_id FROM followers")
+func (dao *FollowDAO) GetFollowGraph(ctx context.Context) ([]model.Follow, error) {
+	rows, err := conn(ctx, dao.db).QueryContext(ctx, "SELECT user_id, following_user_id FROM followers")
 	if err != nil {
 		log.Printf("[follow_dao.go] フォローグラフの取得失敗: %v", err)
 		return nil, err
@@ -110,3 +121,78 @@ func (dao *FollowDAO) GetFollowGraph() ([]model.Follow, error) {
 
 	return follows, nil
 }
+
+// RemoveFollowsBetween 2人のユーザーの間のフォローを両方向とも解除 (フォローしていなくてもエラーにしない)
+func (dao *FollowDAO) RemoveFollowsBetween(ctx context.Context, userID, otherUserID string) error {
+	_, err := conn(ctx, dao.db).ExecContext(ctx, `
+		DELETE FROM followers
+		WHERE (user_id = ? AND following_user_id = ?) OR (user_id = ? AND following_user_id = ?)`,
+		userID, otherUserID, otherUserID, userID)
+	if err != nil {
+		log.Printf("[follow_dao.go] フォロー関係の解除失敗 (user_id: %s, other_user_id: %s): %v", userID, otherUserID, err)
+	}
+	return err
+}
+
+// GetFollowNeighbourhood ユーザーから2ホップ以内のフォロー関係 (ユーザーのフォローと、フォロー中のユーザーのフォロー) を最大 limit 件取得
+// 上限を超える場合もユーザー自身のフォローは必ず含め、2ホップ目はフォロー先のフォロワー数が多い順に残す
+// (同数なら ID 順にし、同じグラフからは常に同じ結果を返す)
+func (dao *FollowDAO) GetFollowNeighbourhood(ctx context.Context, userID string, limit int) ([]model.Follow, error) {
+	rows, err := conn(ctx, dao.db).QueryContext(ctx, `
+		SELECT f.user_id, f.following_user_id
+		FROM followers f
+		WHERE f.user_id = ? OR f.user_id IN (SELECT following_user_id FROM followers WHERE user_id = ?)
+		ORDER BY f.user_id = ? DESC,
+			(SELECT COUNT(*) FROM followers c WHERE c.following_user_id = f.following_user_id) DESC,
+			f.user_id ASC, f.following_user_id ASC
+		LIMIT ?`, userID, userID, userID, limit)
+	if err != nil {
+		log.Printf("[follow_dao.go] 近傍のフォロー関係の取得失敗 (user_id: %s): %v", userID, err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var follows []model.Follow
+	for rows.Next() {
+		var follow model.Follow
+		if err := rows.Scan(&follow.UserID, &follow.FollowingUserID); err != nil {
+			log.Printf("[follow_dao.go] フォローグラフデータのScan失敗: %v", err)
+			return nil, err
+		}
+		follows = append(follows, follow)
+	}
+	return follows, rows.Err()
+}
+
+// GetTopFollowedUsers フォロワー数の多いユーザーを最大 limit 件取得 (Score はフォロワー数、User は user_id のみ)
+// 閲覧者自身・閲覧者がフォロー中のユーザー・閲覧者とブロック関係にあるユーザー・凍結中やシャドウバン中のユーザーは除く
+func (dao *FollowDAO) GetTopFollowedUsers(ctx context.Context, viewerID string, limit int) ([]model.UserRecommendation, error) {
+	rows, err := conn(ctx, dao.db).QueryContext(ctx, `
+		SELECT f.following_user_id, COUNT(*) AS followers
+		FROM followers f
+		WHERE f.following_user_id <> ?
+		AND NOT EXISTS (SELECT 1 FROM followers mine WHERE mine.user_id = ? AND mine.following_user_id = f.following_user_id)
+		AND NOT `+blockedSQL("f.following_user_id")+`
+		AND `+activeUserSQL("f.following_user_id")+`
+		GROUP BY f.following_user_id
+		ORDER BY followers DESC, f.following_user_id ASC
+		LIMIT ?`, viewerID, viewerID, viewerID, viewerID, limit)
+	if err != nil {
+		log.Printf("[follow_dao.go] フォロワー数の多いユーザーの取得失敗 (viewer_id: %s): %v", viewerID, err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []model.UserRecommendation
+	for rows.Next() {
+		var user model.UserRecommendation
+		var followers int
+		if err := rows.Scan(&user.User.UserID, &followers); err != nil {
+			log.Printf("[follow_dao.go] ユーザーIDのScan失敗: %v", err)
+			return nil, err
+		}
+		user.Score = float64(followers)
+		users = append(users, user)
+	}
+	return users, rows.Err()
+}
@@ -4,54 +4,218 @@ import (
 	"cloud.google.com/go/vertexai/genai"
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"google.golang.org/api/iterator"
 	"log"
+	"sort"
+	"strings"
+	"time"
+	"twitter/config"
 	"twitter/model"
 )
 
-const (
-	location  = "asia-northeast1"
-	modelName = "gemini-1.5-flash-002"
-	projectID = "term6-yoshiaki-tanabe" // ① 自分のプロジェクトIDを指定する
-)
+// maxConversationDepth 会話をたどる返信の深さの上限
+const maxConversationDepth = 50
 
 type GeminiDAO struct {
-	db *sql.DB
+	db      *sql.DB
+	ai      config.AIConfig
+	breaker *circuitBreaker
 }
 
-func NewGeminiDAO(db *sql.DB) *GeminiDAO {
-	return &GeminiDAO{db: db}
+func NewGeminiDAO(db *sql.DB, ai config.AIConfig) *GeminiDAO {
+	return &GeminiDAO{db: db, ai: ai, breaker: newCircuitBreaker("Gemini")}
 }
 
-// GenerateResponseFromPrompt Geminiを使用してプロンプトに対するレスポンスを生成
-func (dao *GeminiDAO) GenerateResponseFromPrompt(prompt string) (*genai.Part, error) {
-	ctx := context.Background()
-	client, err := genai.NewClient(ctx, projectID, location)
+// GenerateResponseFromPrompt Geminiを使用してプロンプトに対するレスポンスを生成し、トークン使用量と共に返す
+// ctx の期限・キャンセルに従い、一時的な失敗は再試行する
+func (dao *GeminiDAO) GenerateResponseFromPrompt(ctx context.Context, prompt string) (*genai.Part, *model.GenerationUsage, error) {
+	client, err := genai.NewClient(ctx, dao.ai.ProjectID, dao.ai.Location)
 	if err != nil {
-		return nil, fmt.Errorf("Geminiクライアントの初期化失敗: %w", err)
+		return nil, nil, fmt.Errorf("Geminiクライアントの初期化失敗: %w", err)
+	}
+	defer client.Close()
+
+	gemini := client.GenerativeModel(dao.ai.GenerationModel)
+	var text string
+	var usage *model.GenerationUsage
+	err = callWithRetry(ctx, dao.breaker, func(ctx context.Context) error {
+		resp, err := gemini.GenerateContent(ctx, genai.Text(prompt))
+		if err != nil {
+			return err
+		}
+
+		// Candidates配列を確認
+		if len(resp.Candidates) == 0 {
+			return ErrAIEmptyResponse
+		}
+		text, err = candidateText(resp.Candidates[0])
+		usage = usageFromMetadata(resp.UsageMetadata)
+		return err
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("Geminiによる生成失敗: %w", err)
+	}
+
+	var part genai.Part = genai.Text(text)
+	return &part, usage, nil
+}
+
+// GenerateJSONFromImage Geminiに画像とプロンプトを渡してJSON形式の応答を生成し、トークン使用量と共に返す
+// ctx の期限・キャンセルに従い、一時的な失敗は再試行する
+func (dao *GeminiDAO) GenerateJSONFromImage(ctx context.Context, prompt, mimeType string, image []byte) (string, *model.GenerationUsage, error) {
+	client, err := genai.NewClient(ctx, dao.ai.ProjectID, dao.ai.Location)
+	if err != nil {
+		return "", nil, fmt.Errorf("Geminiクライアントの初期化失敗: %w", err)
+	}
+	defer client.Close()
+
+	gemini := client.GenerativeModel(dao.ai.GenerationModel)
+	gemini.ResponseMIMEType = "application/json"
+	var text string
+	var usage *model.GenerationUsage
+	err = callWithRetry(ctx, dao.breaker, func(ctx context.Context) error {
+		resp, err := gemini.GenerateContent(ctx, genai.Blob{MIMEType: mimeType, Data: image}, genai.Text(prompt))
+		if err != nil {
+			return err
+		}
+		if len(resp.Candidates) == 0 {
+			return ErrAIEmptyResponse
+		}
+		text, err = candidateText(resp.Candidates[0])
+		usage = usageFromMetadata(resp.UsageMetadata)
+		return err
+	})
+	if err != nil {
+		return "", nil, fmt.Errorf("Geminiによる画像の解析失敗: %w", err)
+	}
+	return text, usage, nil
+}
+
+// GenerateCandidatesFromPrompt Geminiを使用してプロンプトに対する複数の候補を生成し、各候補のテキストとトークン使用量を返す
+// ブロックされた・空の候補は除き、全ての候補がそうならエラーを返す
+func (dao *GeminiDAO) GenerateCandidatesFromPrompt(ctx context.Context, prompt string, count int) ([]string, *model.GenerationUsage, error) {
+	client, err := genai.NewClient(ctx, dao.ai.ProjectID, dao.ai.Location)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Geminiクライアントの初期化失敗: %w", err)
 	}
+	defer client.Close()
+
+	gemini := client.GenerativeModel(dao.ai.GenerationModel)
+	gemini.SetCandidateCount(int32(count))
+	var texts []string
+	var usage *model.GenerationUsage
+	err = callWithRetry(ctx, dao.breaker, func(ctx context.Context) error {
+		resp, err := gemini.GenerateContent(ctx, genai.Text(prompt))
+		if err != nil {
+			return err
+		}
+
+		texts = nil
+		var lastErr error = ErrAIEmptyResponse
+		for _, candidate := range resp.Candidates {
+			text, err := candidateText(candidate)
+			if err != nil {
+				lastErr = err
+				continue
+			}
+			texts = append(texts, text)
+		}
+		usage = usageFromMetadata(resp.UsageMetadata)
+		if len(texts) == 0 {
+			return lastErr
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("Geminiによる生成失敗: %w", err)
+	}
+	return texts, usage, nil
+}
 
-	gemini := client.GenerativeModel(modelName)
-	resp, err := gemini.GenerateContent(ctx, genai.Text(prompt))
+// StreamResponseFromPrompt Geminiを使用してプロンプトに対するレスポンスを生成し、生成されたテキストを届いた順に onText に渡す
+// ctx がキャンセルされると生成も中断する。テキストを送る前の一時的な失敗だけ再試行する
+// 失敗・中断した場合も、Gemini を呼び出していればそれまでの使用量を返す (呼び出す前に失敗した場合は nil)
+func (dao *GeminiDAO) StreamResponseFromPrompt(ctx context.Context, prompt string, onText func(text string) error) (*model.GenerationUsage, error) {
+	client, err := genai.NewClient(ctx, dao.ai.ProjectID, dao.ai.Location)
 	if err != nil {
-		return nil, fmt.Errorf("Geminiによる生成失敗: %w", err)
+		return nil, fmt.Errorf("Geminiクライアントの初期化失敗: %w", err)
 	}
+	defer client.Close()
+
+	gemini := client.GenerativeModel(dao.ai.GenerationModel)
+	var usage *model.GenerationUsage
+	err = callWithRetry(ctx, dao.breaker, func(ctx context.Context) error {
+		if usage == nil {
+			usage = &model.GenerationUsage{}
+		}
+		iter := gemini.GenerateContentStream(ctx, genai.Text(prompt))
+		sent := false
+		for {
+			resp, err := iter.Next()
+			if err == iterator.Done {
+				break
+			}
+			if err != nil {
+				if sent {
+					return &noRetryError{err: err}
+				}
+				return err
+			}
 
-	// Candidates配列を確認
-	if len(resp.Candidates) == 0 {
-		return nil, fmt.Errorf("Geminiからの応答が空です")
+			if len(resp.Candidates) > 0 {
+				candidate := resp.Candidates[0]
+				if isBlockedFinishReason(candidate.FinishReason) {
+					return &noRetryError{err: fmt.Errorf("%w (finish_reason: %s)", ErrAISafetyBlocked, candidate.FinishReason)}
+				}
+				if candidate.Content != nil {
+					for _, part := range candidate.Content.Parts {
+						if text, ok := part.(genai.Text); ok && text != "" {
+							if err := onText(string(text)); err != nil {
+								return &abortError{err: err}
+							}
+							sent = true
+						}
+					}
+				}
+			}
+			// 使用量は最後のレスポンスに累計が入る
+			if resp.UsageMetadata != nil {
+				usage = usageFromMetadata(resp.UsageMetadata)
+			}
+		}
+		if !sent {
+			return ErrAIEmptyResponse
+		}
+		return nil
+	})
+	if err != nil {
+		return usage, fmt.Errorf("Geminiによるストリーミング生成失敗: %w", err)
 	}
+	return usage, nil
+}
 
-	return &resp.Candidates[0].Content.Parts[0], nil
+// usageFromMetadata ヘルパー関数: レスポンスの使用量メタデータをトークン使用量に変換 (メタデータが無ければ0)
+func usageFromMetadata(metadata *genai.UsageMetadata) *model.GenerationUsage {
+	if metadata == nil {
+		return &model.GenerationUsage{}
+	}
+	return &model.GenerationUsage{
+		PromptTokens:   int(metadata.PromptTokenCount),
+		ResponseTokens: int(metadata.CandidatesTokenCount),
+		TotalTokens:    int(metadata.TotalTokenCount),
+	}
 }
 
-// FetchUserPostContents 指定ユーザーの投稿内容を取得 (content のみ)
-func (dao *GeminiDAO) FetchUserPostContents(userID string) ([]string, error) {
-	rows, err := dao.db.Query(`
+// FetchUserPostContents 指定ユーザーの最近の投稿内容を新しい順に指定件数まで取得 (content のみ)
+func (dao *GeminiDAO) FetchUserPostContents(ctx context.Context, userID string, limit int) ([]string, error) {
+	rows, err := conn(ctx, dao.db).QueryContext(ctx, `
 		SELECT content 
 		FROM posts 
 		WHERE user_id = ? AND deleted_at IS NULL 
-		ORDER BY created_at DESC`, userID)
+		ORDER BY created_at DESC 
+		LIMIT ?`, userID, limit)
 	if err != nil {
 		log.Printf("[gemini_dao.go] 以下の投稿一覧取得失敗 (user_id: %s): %v", userID, err)
 		return nil, err
@@ -73,18 +237,18 @@ func (dao *GeminiDAO) FetchUserPostContents(userID string) ([]string, error) {
 }
 
 // GetPostContent 指定した投稿IDの内容を文字列として取得
-func (dao *GeminiDAO) GetPostContent(postID string) (string, error) {
+func (dao *GeminiDAO) GetPostContent(ctx context.Context, postID string) (string, error) {
 	var content string
 
 	// 投稿内容を取得
-	err := dao.db.QueryRow(
+	err := conn(ctx, dao.db).QueryRowContext(ctx,
 		"SELECT content FROM posts WHERE post_id = ? AND deleted_at IS NULL",
 		postID,
 	).Scan(&content)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			log.Printf("[gemini_dao.go] 投稿が見つからない (post_id: %s)", postID)
-			return "", fmt.Errorf("投稿が存在しません")
+			return "", ErrPostNotFound
 		}
 		log.Printf("[gemini_dao.go] 投稿内容の取得失敗 (post_id: %s): %v", postID, err)
 		return "", err
@@ -94,8 +258,8 @@ func (dao *GeminiDAO) GetPostContent(postID string) (string, error) {
 }
 
 // UpdateIsBad 指定した投稿の is_bad カラムを更新
-func (dao *GeminiDAO) UpdateIsBad(postID string, isBad bool) error {
-	_, err := dao.db.Exec(
+func (dao *GeminiDAO) UpdateIsBad(ctx context.Context, postID string, isBad bool) error {
+	_, err := conn(ctx, dao.db).ExecContext(ctx,
 		"UPDATE posts SET is_bad = ? WHERE post_id = ? AND deleted_at IS NULL",
 		isBad,
 		postID,
@@ -108,17 +272,29 @@ func (dao *GeminiDAO) UpdateIsBad(postID string, isBad bool) error {
 	return nil
 }
 
-// FetchUnfollowedUsers 指定ユーザーがフォローしていないユーザーのID、名前、自己紹介を取得
-func (dao *GeminiDAO) FetchUnfollowedUsers(authID string) ([]model.User, error) {
-	rows, err := dao.db.Query(`
-		SELECT u.user_id, u.name, u.bio
+// FetchUnfollowedUsers 指定ユーザーがフォローしていないユーザーのID、名前、自己紹介、プロフィール画像を最大 limit 件取得
+// 友達の友達として経由する人数、フォロワー数の多い順に返す (ブロック関係にあるユーザーは除く)
+func (dao *GeminiDAO) FetchUnfollowedUsers(ctx context.Context, authID string, limit int) ([]model.User, error) {
+	rows, err := conn(ctx, dao.db).QueryContext(ctx, `
+		SELECT u.user_id, u.name, u.bio, u.profile_img_url
 		FROM users u
+		LEFT JOIN (
+			SELECT f2.following_user_id AS user_id, COUNT(*) AS mutual_count
+			FROM followers f1
+			JOIN followers f2 ON f1.following_user_id = f2.user_id
+			WHERE f1.user_id = ?
+			GROUP BY f2.following_user_id
+		) m ON m.user_id = u.user_id
 		WHERE u.user_id NOT IN (
 			SELECT f.following_user_id
 			FROM followers f
 			WHERE f.user_id = ?
 		) AND u.user_id != ?
-	`, authID, authID)
+		AND `+activeUserSQL("u.user_id")+` AND NOT `+blockedSQL("u.user_id")+`
+		ORDER BY COALESCE(m.mutual_count, 0) DESC,
+			(SELECT COUNT(*) FROM followers fc WHERE fc.following_user_id = u.user_id) DESC,
+			u.user_id ASC
+		LIMIT ?`, authID, authID, authID, authID, authID, limit)
 
 	if err != nil {
 		log.Printf("[gemini_dao.go] 未フォローのユーザー取得失敗 (auth_id: %s): %v", authID, err)
@@ -129,17 +305,311 @@ func (dao *GeminiDAO) FetchUnfollowedUsers(authID string) ([]model.User, error)
 	var users []model.User
 	for rows.Next() {
 		var user model.User
-		var bio sql.NullString
+		var bio, profileImgURL sql.NullString
 
-		if err := rows.Scan(&user.UserID, &user.Name, &bio); err != nil {
+		if err := rows.Scan(&user.UserID, &user.Name, &bio, &profileImgURL); err != nil {
 			log.Printf("[gemini_dao.go] ユーザーデータのScan失敗: %v", err)
 			return nil, err
 		}
 
 		// NULL値を処理
 		user.Bio = nullableToPointer(bio)
+		user.ProfileImgURL = nullableToPointer(profileImgURL)
 
 		users = append(users, user)
 	}
-	return users, nil
+	return users, rows.Err()
+}
+
+// FetchMutualFollowCounts 指定ユーザーのフォロー中ユーザーがフォローしている未フォローユーザーと、その人数を多い順に最大 limit 件取得
+func (dao *GeminiDAO) FetchMutualFollowCounts(ctx context.Context, authID string, limit int) (map[string]int, error) {
+	rows, err := conn(ctx, dao.db).QueryContext(ctx, `
+		SELECT f2.following_user_id, COUNT(*) AS mutual_count
+		FROM followers f1
+		JOIN followers f2 ON f1.following_user_id = f2.user_id
+		WHERE f1.user_id = ? AND f2.following_user_id != ?
+		AND f2.following_user_id NOT IN (
+			SELECT f3.following_user_id
+			FROM followers f3
+			WHERE f3.user_id = ?
+		)
+		GROUP BY f2.following_user_id
+		ORDER BY mutual_count DESC, f2.following_user_id ASC
+		LIMIT ?`, authID, authID, authID, limit)
+	if err != nil {
+		log.Printf("[gemini_dao.go] 友達の友達の取得失敗 (auth_id: %s): %v", authID, err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var userID string
+		var count int
+
+		if err := rows.Scan(&userID, &count); err != nil {
+			log.Printf("[gemini_dao.go] 友達の友達データのScan失敗: %v", err)
+			return nil, err
+		}
+
+		counts[userID] = count
+	}
+	return counts, rows.Err()
+}
+
+// GetUserProfile 指定ユーザーのID、名前、自己紹介を取得
+func (dao *GeminiDAO) GetUserProfile(ctx context.Context, userID string) (*model.User, error) {
+	var user model.User
+	var bio sql.NullString
+
+	err := conn(ctx, dao.db).QueryRowContext(ctx, "SELECT user_id, name, bio FROM users WHERE user_id = ?", userID).Scan(&user.UserID, &user.Name, &bio)
+	if err != nil {
+		log.Printf("[gemini_dao.go] 以下のユーザー取得失敗 (user_id: %s): %v", userID, err)
+		return nil, err
+	}
+
+	user.Bio = nullableToPointer(bio)
+	return &user, nil
+}
+
+// RecordGeneration 生成に使ったプロンプトテンプレートを記録
+func (dao *GeminiDAO) RecordGeneration(ctx context.Context, generation model.GenerationLog) error {
+	_, err := conn(ctx, dao.db).ExecContext(ctx,
+		"INSERT INTO generation_logs (generation_id, target_id, template_id, template_version, locale, created_at) VALUES (?, ?, ?, ?, ?, ?)",
+		generation.GenerationID,
+		generation.TargetID,
+		generation.TemplateID,
+		generation.TemplateVersion,
+		generation.Locale,
+		generation.CreatedAt,
+	)
+	if err != nil {
+		log.Printf("[gemini_dao.go] 生成記録の保存失敗 (target_id: %s, template: %s@v%d/%s): %v", generation.TargetID, generation.TemplateID, generation.TemplateVersion, generation.Locale, err)
+	}
+	return err
+}
+
+// FetchConversation 投稿が属する会話 (返信をたどった根の投稿とその全ての返信) を古い順に最大 limit 件取得 (viewerID は閲覧者のID)
+// 削除された投稿・閲覧者から見えない投稿者の投稿・不適切と判定された投稿は結果に含めないが、その先の返信はたどる
+// 戻り値の2つ目は根の投稿ID
+func (dao *GeminiDAO) FetchConversation(ctx context.Context, postID, viewerID string, limit int) ([]model.Post, string, error) {
+	var deleted bool
+	err := conn(ctx, dao.db).QueryRowContext(ctx, "SELECT deleted_at IS NOT NULL FROM posts WHERE post_id = ?", postID).Scan(&deleted)
+	if err == sql.ErrNoRows || err == nil && deleted {
+		log.Printf("[gemini_dao.go] 投稿が見つからない (post_id: %s)", postID)
+		return nil, "", ErrPostNotFound
+	}
+	if err != nil {
+		log.Printf("[gemini_dao.go] 投稿の取得失敗 (post_id: %s): %v", postID, err)
+		return nil, "", err
+	}
+
+	// 親をたどって根の投稿を探す (親が物理削除されていればそこで止める)
+	rootID := postID
+	for depth := 0; depth < maxConversationDepth; depth++ {
+		var parentID sql.NullString
+		err := conn(ctx, dao.db).QueryRowContext(ctx, `
+			SELECT p.parent_post_id 
+			FROM posts p 
+			WHERE p.post_id = ? AND EXISTS (SELECT 1 FROM posts parent WHERE parent.post_id = p.parent_post_id)`, rootID).Scan(&parentID)
+		if err == sql.ErrNoRows || err == nil && !parentID.Valid {
+			break
+		}
+		if err != nil {
+			log.Printf("[gemini_dao.go] 親投稿の取得失敗 (post_id: %s): %v", rootID, err)
+			return nil, "", err
+		}
+		rootID = parentID.String
+	}
+
+	// 根から返信を幅優先でたどる
+	var posts []model.Post
+	frontier := []string{rootID}
+	rootQuery := true
+	for depth := 0; len(frontier) > 0 && len(posts) < limit && depth < maxConversationDepth; depth++ {
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(frontier)), ", ")
+		args := []interface{}{viewerID}
+		for _, id := range frontier {
+			args = append(args, id)
+		}
+		condition := "p.parent_post_id IN (" + placeholders + ")"
+		if rootQuery {
+			condition = "p.post_id = ?"
+			rootQuery = false
+		}
+
+		rows, err := conn(ctx, dao.db).QueryContext(ctx, `
+			SELECT p.post_id, p.user_id, p.content, p.img_url, p.created_at, p.edited_at, p.parent_post_id, p.is_bad, 
+			(p.deleted_at IS NULL AND `+visibleAuthorSQL("p.user_id")+`) AS visible 
+			FROM posts p 
+			WHERE `+condition+` 
+			ORDER BY p.created_at ASC`, args...)
+		if err != nil {
+			log.Printf("[gemini_dao.go] 会話の返信取得失敗 (root_post_id: %s): %v", rootID, err)
+			return nil, "", err
+		}
+
+		var next []string
+		for rows.Next() {
+			var post model.Post
+			var imgURL, parentPostID sql.NullString
+			var editedAt sql.NullTime
+			var visible bool
+
+			if err := rows.Scan(
+				&post.PostID,
+				&post.UserID,
+				&post.Content,
+				&imgURL,
+				&post.CreatedAt,
+				&editedAt,
+				&parentPostID,
+				&post.IsBad,
+				&visible,
+			); err != nil {
+				rows.Close()
+				log.Printf("[gemini_dao.go] 投稿データのScan失敗: %v", err)
+				return nil, "", err
+			}
+
+			next = append(next, post.PostID)
+			if !visible || post.IsBad || len(posts) >= limit {
+				continue
+			}
+			post.ImgURL = nullableToPointer(imgURL)
+			post.ParentPostID = nullableToPointer(parentPostID)
+			if editedAt.Valid {
+				post.EditedAt = &editedAt.Time
+			}
+			posts = append(posts, post)
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			log.Printf("[gemini_dao.go] 会話の返信取得失敗 (root_post_id: %s): %v", rootID, err)
+			return nil, "", err
+		}
+		frontier = next
+	}
+
+	sort.SliceStable(posts, func(i, j int) bool { return posts[i].CreatedAt.Before(posts[j].CreatedAt) })
+	return posts, rootID, nil
+}
+
+// FetchPostWithAncestors 投稿と、その親の投稿を親をたどって最大 depth 件、近い順に取得 (viewerID は閲覧者のID)
+// 投稿が削除されている・閲覧者から見えない・不適切と判定されている場合は ErrPostNotFound
+// 親のうち見えない投稿は結果に含めないが、その先の親はたどる (親が物理削除されていればそこで止める)
+func (dao *GeminiDAO) FetchPostWithAncestors(ctx context.Context, postID, viewerID string, depth int) (*model.Post, []model.Post, error) {
+	target, visible, err := dao.fetchConversationPost(ctx, postID, viewerID)
+	if err == nil && (!visible || target.IsBad) {
+		err = ErrPostNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var ancestors []model.Post
+	for current := target; current.ParentPostID != nil && len(ancestors) < depth; {
+		parent, visible, err := dao.fetchConversationPost(ctx, *current.ParentPostID, viewerID)
+		if errors.Is(err, ErrPostNotFound) {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		if visible && !parent.IsBad {
+			ancestors = append(ancestors, *parent)
+		}
+		current = parent
+	}
+	return target, ancestors, nil
+}
+
+// fetchConversationPost ヘルパー関数: 投稿と、閲覧者から見えるか (削除されておらず投稿者が見える) を取得 (存在しなければ ErrPostNotFound)
+func (dao *GeminiDAO) fetchConversationPost(ctx context.Context, postID, viewerID string) (*model.Post, bool, error) {
+	var post model.Post
+	var imgURL, parentPostID sql.NullString
+	var editedAt sql.NullTime
+	var visible bool
+
+	err := conn(ctx, dao.db).QueryRowContext(ctx, `
+		SELECT p.post_id, p.user_id, p.content, p.img_url, p.created_at, p.edited_at, p.parent_post_id, p.is_bad, 
+		(p.deleted_at IS NULL AND `+visibleAuthorSQL("p.user_id")+`) AS visible 
+		FROM posts p 
+		WHERE p.post_id = ?`, viewerID, postID).Scan(
+		&post.PostID,
+		&post.UserID,
+		&post.Content,
+		&imgURL,
+		&post.CreatedAt,
+		&editedAt,
+		&parentPostID,
+		&post.IsBad,
+		&visible,
+	)
+	if err == sql.ErrNoRows {
+		log.Printf("[gemini_dao.go] 投稿が見つからない (post_id: %s)", postID)
+		return nil, false, ErrPostNotFound
+	}
+	if err != nil {
+		log.Printf("[gemini_dao.go] 投稿の取得失敗 (post_id: %s): %v", postID, err)
+		return nil, false, err
+	}
+
+	post.ImgURL = nullableToPointer(imgURL)
+	post.ParentPostID = nullableToPointer(parentPostID)
+	if editedAt.Valid {
+		post.EditedAt = &editedAt.Time
+	}
+	return &post, visible, nil
+}
+
+// FetchMissedTimelinePosts フォロー中のユーザーが since 以降に投稿した内容を、いいねの多い順に最大 limit 件取得
+// 自分の投稿・閲覧者から見えない投稿者の投稿・不適切と判定された投稿は含めない
+func (dao *GeminiDAO) FetchMissedTimelinePosts(ctx context.Context, userID string, since time.Time, limit int) ([]model.Post, error) {
+	rows, err := conn(ctx, dao.db).QueryContext(ctx, `
+		SELECT p.post_id, p.user_id, p.content, p.img_url, p.created_at, p.edited_at, p.parent_post_id, p.is_bad 
+		FROM posts p 
+		JOIN followers f ON f.user_id = ? AND f.following_user_id = p.user_id 
+		LEFT JOIN likes l ON l.post_id = p.post_id 
+		WHERE p.deleted_at IS NULL AND p.is_bad = FALSE AND p.created_at > ? 
+		AND `+visibleAuthorSQL("p.user_id")+` 
+		GROUP BY p.post_id 
+		ORDER BY COUNT(l.user_id) DESC, p.created_at DESC 
+		LIMIT ?`, userID, since, userID, limit)
+	if err != nil {
+		log.Printf("[gemini_dao.go] 見逃した投稿の取得失敗 (user_id: %s): %v", userID, err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var posts []model.Post
+	for rows.Next() {
+		var post model.Post
+		var imgURL, parentPostID sql.NullString
+		var editedAt sql.NullTime
+
+		if err := rows.Scan(
+			&post.PostID,
+			&post.UserID,
+			&post.Content,
+			&imgURL,
+			&post.CreatedAt,
+			&editedAt,
+			&parentPostID,
+			&post.IsBad,
+		); err != nil {
+			log.Printf("[gemini_dao.go] 投稿データのScan失敗: %v", err)
+			return nil, err
+		}
+
+		post.ImgURL = nullableToPointer(imgURL)
+		post.ParentPostID = nullableToPointer(parentPostID)
+		if editedAt.Valid {
+			post.EditedAt = &editedAt.Time
+		}
+
+		posts = append(posts, post)
+	}
+	return posts, rows.Err()
 }
@@ -0,0 +1,220 @@
+package dao
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+	"twitter/apperr"
+	"twitter/model"
+)
+
+// 画像の取得に使う値
+const (
+	imageFetchTimeout = 10 * time.Second
+	maxImageBytes     = 5 << 20 // Gemini に渡す画像の最大サイズ (5MB)
+	maxImageRedirects = 3       // 画像の取得で従うリダイレクトの最大回数
+)
+
+// 画像の取得の失敗の種類
+var (
+	ErrImageURLNotAllowed = apperr.New(apperr.KindUnprocessable, "image_url_not_allowed", "取得できない画像のURLです")
+	ErrUnsupportedImage   = apperr.New(apperr.KindUnprocessable, "unsupported_image", "対応していない画像の形式です")
+	ErrImageTooLarge      = apperr.New(apperr.KindUnprocessable, "image_too_large", "画像が大きすぎます")
+)
+
+// supportedImageTypes Gemini に渡せる画像の形式
+var supportedImageTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/webp": true,
+}
+
+// ImageDAO 画像の取得と、画像の解析結果を保存するDAO
+type ImageDAO struct {
+	db           *sql.DB
+	client       *http.Client
+	allowedHosts map[string]bool // 空なら https の公開アドレスならどのホストでもよい
+}
+
+// NewImageDAO 画像の取得先のホストは hosts (設定 IMAGE_ALLOWED_HOSTS) で制限できる
+// 内部のアドレス (ループバック・プライベート・リンクローカル) には接続しない
+// リダイレクト先も同じ条件 (https・許可したホスト) で確認し、maxImageRedirects 回まで従う
+func NewImageDAO(db *sql.DB, hosts []string) *ImageDAO {
+	allowedHosts := make(map[string]bool)
+	for _, host := range hosts {
+		allowedHosts[strings.ToLower(host)] = true
+	}
+
+	dialer := &net.Dialer{Timeout: imageFetchTimeout, Control: denyInternalAddress}
+	transport := &http.Transport{
+		DialContext:         dialer.DialContext,
+		TLSHandshakeTimeout: imageFetchTimeout,
+	}
+	dao := &ImageDAO{db: db, allowedHosts: allowedHosts}
+	dao.client = &http.Client{
+		Transport: transport,
+		Timeout:   imageFetchTimeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) > maxImageRedirects {
+				return fmt.Errorf("%w: リダイレクトが多すぎます", ErrImageURLNotAllowed)
+			}
+			return dao.checkImageURL(req.URL)
+		},
+	}
+	return dao
+}
+
+// FetchImage URLの画像を取得し、形式 (MIMEタイプ) と内容を返す
+func (dao *ImageDAO) FetchImage(ctx context.Context, imgURL string) (string, []byte, error) {
+	parsed, err := url.Parse(imgURL)
+	if err != nil {
+		return "", nil, fmt.Errorf("%w: %s", ErrImageURLNotAllowed, imgURL)
+	}
+	if err := dao.checkImageURL(parsed); err != nil {
+		return "", nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, imgURL, nil)
+	if err != nil {
+		return "", nil, fmt.Errorf("%w: %v", ErrImageURLNotAllowed, err)
+	}
+	resp, err := dao.client.Do(req)
+	if err != nil {
+		log.Printf("[image_dao.go] 画像の取得失敗 (url: %s): %v", imgURL, err)
+		return "", nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", nil, fmt.Errorf("画像の取得失敗 (url: %s, status: %d)", imgURL, resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxImageBytes+1))
+	if err != nil {
+		log.Printf("[image_dao.go] 画像の読み込み失敗 (url: %s): %v", imgURL, err)
+		return "", nil, err
+	}
+	if len(data) > maxImageBytes {
+		return "", nil, fmt.Errorf("%w (%dMBまで)", ErrImageTooLarge, maxImageBytes>>20)
+	}
+
+	// Content-Type ヘッダは信用せず、内容から形式を判定する
+	mimeType := http.DetectContentType(data)
+	if !supportedImageTypes[mimeType] {
+		return "", nil, fmt.Errorf("%w: %s", ErrUnsupportedImage, mimeType)
+	}
+	return mimeType, data, nil
+}
+
+// checkImageURL ヘルパー関数: 取得してよい画像のURL (https で、許可したホスト) か確認する
+func (dao *ImageDAO) checkImageURL(u *url.URL) error {
+	if u.Scheme != "https" || u.Hostname() == "" {
+		return fmt.Errorf("%w: %s", ErrImageURLNotAllowed, u)
+	}
+	if len(dao.allowedHosts) > 0 && !dao.allowedHosts[strings.ToLower(u.Hostname())] {
+		return fmt.Errorf("%w: %s", ErrImageURLNotAllowed, u)
+	}
+	return nil
+}
+
+// SaveImageAnalysis 画像の解析結果を保存 (同じ対象があれば上書き)
+func (dao *ImageDAO) SaveImageAnalysis(ctx context.Context, analysis model.ImageAnalysis) error {
+	_, err := conn(ctx, dao.db).ExecContext(ctx, `
+		INSERT INTO image_analyses (kind, target_id, img_url, status, alt_text, is_unsafe, categories, error, updated_at, analyzed_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE
+			img_url = VALUES(img_url),
+			status = VALUES(status),
+			alt_text = VALUES(alt_text),
+			is_unsafe = VALUES(is_unsafe),
+			categories = VALUES(categories),
+			error = VALUES(error),
+			updated_at = VALUES(updated_at),
+			analyzed_at = VALUES(analyzed_at)`,
+		analysis.Kind,
+		analysis.TargetID,
+		analysis.ImgURL,
+		analysis.Status,
+		sqlNullString(analysis.AltText),
+		analysis.IsUnsafe,
+		strings.Join(analysis.Categories, ","),
+		sqlNullString(analysis.Error),
+		analysis.UpdatedAt,
+		analysis.AnalyzedAt,
+	)
+	if err != nil {
+		log.Printf("[image_dao.go] 画像の解析結果の保存失敗 (kind: %s, target_id: %s): %v", analysis.Kind, analysis.TargetID, err)
+	}
+	return err
+}
+
+// DeleteImageAnalysis 画像の解析結果を削除 (存在しなくてもエラーにしない)
+func (dao *ImageDAO) DeleteImageAnalysis(ctx context.Context, kind, targetID string) error {
+	_, err := conn(ctx, dao.db).ExecContext(ctx, "DELETE FROM image_analyses WHERE kind = ? AND target_id = ?", kind, targetID)
+	if err != nil {
+		log.Printf("[image_dao.go] 画像の解析結果の削除失敗 (kind: %s, target_id: %s): %v", kind, targetID, err)
+	}
+	return err
+}
+
+// GetImageAnalysis 画像の解析結果を取得 (存在しない場合は nil)
+func (dao *ImageDAO) GetImageAnalysis(ctx context.Context, kind, targetID string) (*model.ImageAnalysis, error) {
+	var analysis model.ImageAnalysis
+	var altText, errorMessage sql.NullString
+	var categories string
+	var analyzedAt sql.NullTime
+
+	err := conn(ctx, dao.db).QueryRowContext(ctx, `
+		SELECT kind, target_id, img_url, status, alt_text, is_unsafe, categories, error, updated_at, analyzed_at
+		FROM image_analyses
+		WHERE kind = ? AND target_id = ?`, kind, targetID).Scan(
+		&analysis.Kind,
+		&analysis.TargetID,
+		&analysis.ImgURL,
+		&analysis.Status,
+		&altText,
+		&analysis.IsUnsafe,
+		&categories,
+		&errorMessage,
+		&analysis.UpdatedAt,
+		&analyzedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		log.Printf("[image_dao.go] 画像の解析結果の取得失敗 (kind: %s, target_id: %s): %v", kind, targetID, err)
+		return nil, err
+	}
+
+	analysis.AltText = nullableToPointer(altText)
+	analysis.Error = nullableToPointer(errorMessage)
+	analysis.Categories = []string{}
+	if categories != "" {
+		analysis.Categories = strings.Split(categories, ",")
+	}
+	if analyzedAt.Valid {
+		analysis.AnalyzedAt = &analyzedAt.Time
+	}
+	return &analysis, nil
+}
+
+// denyInternalAddress ヘルパー関数: 接続先が内部のアドレスなら接続を拒否する (名前解決後のアドレスで判定するのでリダイレクトにも効く)
+func denyInternalAddress(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsUnspecified() {
+		return fmt.Errorf("%w: 内部のアドレス %s には接続できません", ErrImageURLNotAllowed, host)
+	}
+	return nil
+}
@@ -2,53 +2,57 @@ package dao
 
 import (
 	"database/sql"
-	"fmt"
 	_ "github.com/go-sql-driver/mysql"
 	"log"
-	"os"
 	"sync"
+	"twitter/config"
 )
 
 var (
+	settings   *config.Config
 	dbInstance *sql.DB
 	once       sync.Once
 
 	authDAOInstance     *AuthDAO
 	followDAOInstance   *FollowDAO
+	blockDAOInstance    *BlockDAO
 	likeDAOInstance     *LikeDAO
 	postDAOInstance     *PostDAO
 	timelineDAOInstance *TimelineDAO
 	userDAOInstance     *UserDAO
 	findDAOInstance     *FindDAO
 	geminiDAOInstance   *GeminiDAO
+
+	restrictionDAOInstance *RestrictionDAO
+	embeddingDAOInstance   *EmbeddingDAO
+	usageDAOInstance       *UsageDAO
+	cacheDAOInstance       *CacheDAO
+	imageDAOInstance       *ImageDAO
+	savedSearchDAOInstance *SavedSearchDAO
+	unitOfWorkInstance     *UnitOfWork
 )
 
+// Configure DB の接続先と各 DAO の設定を登録する (InitDB や Get〜DAO より先に呼ぶ)
+func Configure(cfg *config.Config) {
+	settings = cfg
+}
+
 func InitDB() *sql.DB {
 	once.Do(func() {
-		mode := os.Getenv("MODE")
-		var dsn string
-		if mode == "production" {
-			// ローカル用
-			user := "uttc_user"
-			password := "uttc_password"
-			host := "localhost:3306"
-			database := "uttc_hackathon_local_db"
-			dsn = fmt.Sprintf("%s:%s@tcp(%s)/%s?parseTime=true", user, password, host, database)
-			log.Println("[init_dao.go] モード: ローカル")
-		} else {
-			// 環境変数から設定を取得（デプロイ用）
-			user := os.Getenv("MYSQL_USER")
-			password := os.Getenv("MYSQL_PWD")
-			host := os.Getenv("MYSQL_HOST")
-			database := os.Getenv("MYSQL_DATABASE")
-			dsn = fmt.Sprintf("%s:%s@%s/%s?parseTime=true", user, password, host, database)
-			log.Println("[init_dao.go] モード: デプロイ")
+		if settings == nil {
+			log.Fatal("[init_dao.go] 設定が登録されていません (dao.Configure を先に呼ぶ)")
 		}
+		cfg := settings.DB
+		log.Printf("[init_dao.go] 接続先: %s/%s", cfg.Host, cfg.Database)
 
-		db, err := sql.Open("mysql", dsn)
+		db, err := sql.Open("mysql", cfg.DSN())
 		if err != nil {
 			log.Fatalf("[init_dao.go] データベース接続失敗: %v", err)
 		}
+		db.SetMaxOpenConns(cfg.MaxOpenConns)
+		db.SetMaxIdleConns(cfg.MaxIdleConns)
+		db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+		db.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
 		if err := db.Ping(); err != nil {
 			log.Fatalf("[init_dao.go] データベースへのPing失敗: %v", err)
 		}
@@ -80,6 +84,13 @@ func GetFollowDAO() *FollowDAO {
 	return followDAOInstance
 }
 
+func GetBlockDAO() *BlockDAO {
+	if blockDAOInstance == nil {
+		blockDAOInstance = NewBlockDAO(InitDB())
+	}
+	return blockDAOInstance
+}
+
 func GetLikeDAO() *LikeDAO {
 	if likeDAOInstance == nil {
 		likeDAOInstance = NewLikeDAO(InitDB())
@@ -117,11 +128,60 @@ func GetFindDAO() *FindDAO {
 
 func GetGeminiDAO() *GeminiDAO {
 	if geminiDAOInstance == nil {
-		geminiDAOInstance = NewGeminiDAO(InitDB())
+		geminiDAOInstance = NewGeminiDAO(InitDB(), settings.AI)
 	}
 	return geminiDAOInstance
 }
 
+func GetRestrictionDAO() *RestrictionDAO {
+	if restrictionDAOInstance == nil {
+		restrictionDAOInstance = NewRestrictionDAO(InitDB())
+	}
+	return restrictionDAOInstance
+}
+
+func GetEmbeddingDAO() *EmbeddingDAO {
+	if embeddingDAOInstance == nil {
+		embeddingDAOInstance = NewEmbeddingDAO(InitDB(), settings.AI)
+	}
+	return embeddingDAOInstance
+}
+
+func GetUsageDAO() *UsageDAO {
+	if usageDAOInstance == nil {
+		usageDAOInstance = NewUsageDAO(InitDB())
+	}
+	return usageDAOInstance
+}
+
+func GetCacheDAO() *CacheDAO {
+	if cacheDAOInstance == nil {
+		cacheDAOInstance = NewCacheDAO(InitDB())
+	}
+	return cacheDAOInstance
+}
+
+func GetImageDAO() *ImageDAO {
+	if imageDAOInstance == nil {
+		imageDAOInstance = NewImageDAO(InitDB(), settings.Image.AllowedHosts)
+	}
+	return imageDAOInstance
+}
+
+func GetSavedSearchDAO() *SavedSearchDAO {
+	if savedSearchDAOInstance == nil {
+		savedSearchDAOInstance = NewSavedSearchDAO(InitDB())
+	}
+	return savedSearchDAOInstance
+}
+
+func GetUnitOfWork() *UnitOfWork {
+	if unitOfWorkInstance == nil {
+		unitOfWorkInstance = NewUnitOfWork(InitDB())
+	}
+	return unitOfWorkInstance
+}
+
 // ヘルパー関数: sql.NullString をポインタ型に変換
 func nullableToPointer(ns sql.NullString) *string {
 	if ns.Valid {
@@ -1,6 +1,7 @@
 package dao
 
 import (
+	"context"
 	"database/sql"
 	"log"
 	"time"
@@ -15,27 +16,29 @@ func NewLikeDAO(db *sql.DB) *LikeDAO {
 	return &LikeDAO{db: db}
 }
 
-// AddLike 投稿にいいねを追加
-func (dao *LikeDAO) AddLike(userID, postID string) error {
-	_, err := dao.db.Exec("INSERT INTO likes (user_id, post_id, created_at) VALUES (?, ?, ?)", userID, postID, time.Now())
+// AddLike 投稿にいいねを追加 (いいね済みなら ErrAlreadyLiked、投稿が存在しなければ ErrPostNotFound)
+func (dao *LikeDAO) AddLike(ctx context.Context, userID, postID string) error {
+	_, err := conn(ctx, dao.db).ExecContext(ctx, "INSERT INTO likes (user_id, post_id, created_at) VALUES (?, ?, ?)", userID, postID, time.Now())
 	if err != nil {
 		log.Printf("[like_dao.go] 以下のいいね追加失敗 (user_id: %s, post_id: %s): %v", userID, postID, err)
+		return translateDBError(err, ErrAlreadyLiked, ErrPostNotFound)
 	}
-	return err
+	return nil
 }
 
-// RemoveLike 投稿のいいねを削除
-func (dao *LikeDAO) RemoveLike(userID, postID string) error {
-	_, err := dao.db.Exec("DELETE FROM likes WHERE user_id = ? AND post_id = ?", userID, postID)
+// RemoveLike 投稿のいいねを削除 (いいねしていなければ ErrNotLiked)
+func (dao *LikeDAO) RemoveLike(ctx context.Context, userID, postID string) error {
+	result, err := conn(ctx, dao.db).ExecContext(ctx, "DELETE FROM likes WHERE user_id = ? AND post_id = ?", userID, postID)
 	if err != nil {
 		log.Printf("[like_dao.go] 以下のいいね削除失敗 (user_id: %s, post_id: %s): %v", userID, postID, err)
+		return err
 	}
-	return err
+	return notFoundIfNoRows(result, ErrNotLiked)
 }
 
-// GetUsersByPostID 投稿にいいねしたユーザー一覧を取得
-func (dao *LikeDAO) GetUsersByPostID(postID string) ([]model.User, error) {
-	rows, err := dao.db.Query(`SELECT u.user_id, u.name, u.bio, u.profile_img_url, u.header_img_url FROM users u INNER JOIN likes l ON u.user_id = l.user_id WHERE l.post_id = ?`, postID)
+// GetUsersByPostID 投稿にいいねしたユーザー一覧を取得 (削除済みの投稿のいいねは返さない)
+func (dao *LikeDAO) GetUsersByPostID(ctx context.Context, postID string) ([]model.User, error) {
+	rows, err := conn(ctx, dao.db).QueryContext(ctx, `SELECT u.user_id, u.name, u.bio, u.profile_img_url, u.header_img_url FROM users u INNER JOIN likes l ON u.user_id = l.user_id INNER JOIN posts p ON l.post_id = p.post_id WHERE l.post_id = ? AND p.deleted_at IS NULL`, postID)
 	if err != nil {
 		log.Printf("[like_dao.go] 以下のいいねユーザー一覧取得失敗 (post_id: %s): %v", postID, err)
 		return nil, err
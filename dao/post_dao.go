@@ -1,8 +1,8 @@
 package dao
 
 import (
+	"context"
 	"database/sql"
-	"errors"
 	"log"
 	"time"
 	"twitter/model"
@@ -17,8 +17,8 @@ func NewPostDAO(db *sql.DB) *PostDAO {
 }
 
 // CreatePost 新しい投稿を作成
-func (dao *PostDAO) CreatePost(post model.Post) (*model.Post, error) {
-	_, err := dao.db.Exec(
+func (dao *PostDAO) CreatePost(ctx context.Context, post model.Post) (*model.Post, error) {
+	_, err := conn(ctx, dao.db).ExecContext(ctx,
 		"INSERT INTO posts (post_id, user_id, content, img_url, created_at, parent_post_id, is_bad) VALUES (?, ?, ?, ?, ?, ?, ?)",
 		post.PostID,
 		post.UserID,
@@ -30,19 +30,20 @@ func (dao *PostDAO) CreatePost(post model.Post) (*model.Post, error) {
 	)
 	if err != nil {
 		log.Printf("[post_dao.go] 以下の投稿作成失敗 (post_id: %s, user_id: %s, content: %s): %v", post.PostID, post.UserID, post.Content, err)
-		return nil, err
+		return nil, translateDBError(err, nil, nil)
 	}
 	return &post, nil
 }
 
-// GetPost 投稿の詳細を取得
-func (dao *PostDAO) GetPost(postID string) (*model.Post, error) {
+// GetPost 投稿の詳細を取得 (存在しなければ ErrPostNotFound、削除済みなら ErrPostDeleted)
+func (dao *PostDAO) GetPost(ctx context.Context, postID string) (*model.Post, error) {
 	var post model.Post
 	var imgURL, parentPostID sql.NullString
 	var deletedAt, editedAt sql.NullTime
 
-	err := dao.db.QueryRow(
-		"SELECT post_id, user_id, content, img_url, created_at, edited_at, parent_post_id, deleted_at, is_bad FROM posts WHERE post_id = ?",
+	// 凍結中のユーザーの投稿は存在しないものとして扱う
+	err := conn(ctx, dao.db).QueryRowContext(ctx,
+		"SELECT post_id, user_id, content, img_url, created_at, edited_at, parent_post_id, deleted_at, is_bad FROM posts WHERE post_id = ? AND NOT "+restrictedSQL("user_id", model.RestrictionSuspend),
 		postID,
 	).Scan(
 		&post.PostID,
@@ -57,7 +58,7 @@ func (dao *PostDAO) GetPost(postID string) (*model.Post, error) {
 	)
 	if err == sql.ErrNoRows {
 		log.Printf("[post_dao.go] 以下の投稿が見つからない (post_id: %s)", postID)
-		return nil, err
+		return nil, ErrPostNotFound
 	} else if err != nil {
 		log.Printf("[post_dao.go] 以下の投稿取得失敗 (post_id: %s): %v", postID, err)
 		return nil, err
@@ -65,7 +66,7 @@ func (dao *PostDAO) GetPost(postID string) (*model.Post, error) {
 
 	// 削除済みチェック
 	if deletedAt.Valid {
-		return nil, errors.New("投稿が削除されています")
+		return nil, ErrPostDeleted
 	}
 
 	// NULL 値の処理
@@ -78,10 +79,10 @@ func (dao *PostDAO) GetPost(postID string) (*model.Post, error) {
 	return &post, nil
 }
 
-// UpdatePost 投稿を更新
-func (dao *PostDAO) UpdatePost(post model.Post) error {
+// UpdatePost 投稿を更新 (存在しないか削除済みなら ErrPostNotFound)
+func (dao *PostDAO) UpdatePost(ctx context.Context, post model.Post) error {
 	editedAt := time.Now()
-	_, err := dao.db.Exec(
+	result, err := conn(ctx, dao.db).ExecContext(ctx,
 		"UPDATE posts SET content = ?, img_url = ?, edited_at = ? WHERE post_id = ? AND deleted_at IS NULL",
 		post.Content,
 		sqlNullString(post.ImgURL),
@@ -90,24 +91,41 @@ func (dao *PostDAO) UpdatePost(post model.Post) error {
 	)
 	if err != nil {
 		log.Printf("[post_dao.go] 以下の投稿更新失敗 (post_id: %s): %v", post.PostID, err)
+		return err
+	}
+	return notFoundIfNoRows(result, ErrPostNotFound)
+}
+
+// GetPostAuthorID 投稿者のIDを取得 (削除済みの投稿も対象、存在しなければ ErrPostNotFound)
+func (dao *PostDAO) GetPostAuthorID(ctx context.Context, postID string) (string, error) {
+	var userID string
+	err := conn(ctx, dao.db).QueryRowContext(ctx, "SELECT user_id FROM posts WHERE post_id = ?", postID).Scan(&userID)
+	if err == sql.ErrNoRows {
+		return "", ErrPostNotFound
+	}
+	if err != nil {
+		log.Printf("[post_dao.go] 以下の投稿の投稿者取得失敗 (post_id: %s): %v", postID, err)
+		return "", err
 	}
-	return err
+	return userID, nil
 }
 
-// DeletePost 投稿を削除 (論理削除)
-func (dao *PostDAO) DeletePost(postID string) error {
-	_, err := dao.db.Exec("UPDATE posts SET deleted_at = ? WHERE post_id = ?", time.Now(), postID)
+// DeletePost 投稿を削除 (論理削除、存在しなければ ErrPostNotFound)
+func (dao *PostDAO) DeletePost(ctx context.Context, postID string) error {
+	result, err := conn(ctx, dao.db).ExecContext(ctx, "UPDATE posts SET deleted_at = ? WHERE post_id = ?", time.Now(), postID)
 	if err != nil {
 		log.Printf("[post_dao.go] 以下の投稿削除失敗 (post_id: %s): %v", postID, err)
+		return err
 	}
-	return err
+	return notFoundIfNoRows(result, ErrPostNotFound)
 }
 
-// GetChildrenPosts 子ポストを取得
-func (dao *PostDAO) GetChildrenPosts(parentPostID string) ([]model.Post, error) {
-	rows, err := dao.db.Query(
-		"SELECT post_id, user_id, content, img_url, created_at, edited_at, parent_post_id, is_bad FROM posts WHERE parent_post_id = ? AND deleted_at IS NULL",
+// GetChildrenPosts 子ポストを取得 (viewerID は閲覧者のID)
+func (dao *PostDAO) GetChildrenPosts(ctx context.Context, parentPostID, viewerID string) ([]model.Post, error) {
+	rows, err := conn(ctx, dao.db).QueryContext(ctx,
+		"SELECT post_id, user_id, content, img_url, created_at, edited_at, parent_post_id, is_bad FROM posts WHERE parent_post_id = ? AND deleted_at IS NULL AND "+visibleAuthorSQL("user_id"),
 		parentPostID,
+		viewerID,
 	)
 	if err != nil {
 		log.Printf("[post_dao.go] 子ポスト一覧取得失敗 (parent_post_id: %s): %v", parentPostID, err)
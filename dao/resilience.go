@@ -0,0 +1,240 @@
+package dao
+
+import (
+	"cloud.google.com/go/vertexai/genai"
+	"context"
+	"errors"
+	"fmt"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"log"
+	"math/rand"
+	"sync"
+	"time"
+	"twitter/apperr"
+)
+
+// Vertex AI の呼び出しに使う値
+const (
+	aiCallTimeout    = 30 * time.Second // 1回の呼び出し (再試行を含む) の最大時間
+	aiMaxAttempts    = 3                // 再試行を含めた最大試行回数
+	aiBackoffBase    = 300 * time.Millisecond
+	aiBackoffMax     = 3 * time.Second
+	breakerThreshold = 5                // 連続でこの回数失敗したら遮断する
+	breakerCooldown  = 30 * time.Second // 遮断してから試しに呼び出すまでの時間
+)
+
+// Vertex AI 呼び出しの失敗の種類 (メッセージはそのままクライアントに返す)
+var (
+	ErrAISafetyBlocked = apperr.New(apperr.KindUnprocessable, "ai_safety_blocked", "安全性フィルタにより生成できませんでした。内容や指示を変えてお試しください")
+	ErrAIEmptyResponse = apperr.New(apperr.KindUpstream, "ai_empty_response", "AIからの応答が空でした")
+	ErrAIUpstream      = apperr.New(apperr.KindUpstream, "ai_upstream", "AIの呼び出しに失敗しました")
+	ErrAITimeout       = apperr.New(apperr.KindTimeout, "ai_timeout", "AIの応答がタイムアウトしました")
+	ErrAIUnavailable   = apperr.New(apperr.KindUnavailable, "ai_unavailable", "AIが一時的に利用できません。しばらくしてからお試しください")
+)
+
+// CircuitOpenError 障害中のため呼び出さずに失敗した (RetryAfter 後に再試行できる)
+type CircuitOpenError struct {
+	RetryAfter time.Duration
+}
+
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("%s (%s後に再試行できます)", ErrAIUnavailable, e.RetryAfter.Round(time.Second))
+}
+
+func (e *CircuitOpenError) Unwrap() error {
+	return ErrAIUnavailable
+}
+
+// abortError 呼び出し側の理由 (クライアントへの書き込み失敗など) で中断した (再試行せず、障害としても数えない)
+type abortError struct {
+	err error
+}
+
+func (e *abortError) Error() string { return e.err.Error() }
+
+func (e *abortError) Unwrap() error { return e.err }
+
+// noRetryError 再試行できない失敗 (ストリーミングで既にテキストを送った後の失敗など)
+type noRetryError struct {
+	err error
+}
+
+func (e *noRetryError) Error() string { return e.err.Error() }
+
+func (e *noRetryError) Unwrap() error { return e.err }
+
+// circuitBreaker 連続して失敗したら一定時間呼び出しを遮断し、その後1回だけ試しに呼び出す
+type circuitBreaker struct {
+	name      string
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+	probing   bool // 遮断明けの試しの呼び出し中
+}
+
+func newCircuitBreaker(name string) *circuitBreaker {
+	return &circuitBreaker{name: name}
+}
+
+// allow 呼び出してよいか判定する (遮断中なら CircuitOpenError)
+func (b *circuitBreaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.failures < breakerThreshold {
+		return nil
+	}
+	now := time.Now()
+	if now.Before(b.openUntil) {
+		return &CircuitOpenError{RetryAfter: b.openUntil.Sub(now)}
+	}
+	if b.probing {
+		return &CircuitOpenError{RetryAfter: time.Second}
+	}
+	b.probing = true
+	return nil
+}
+
+// record 呼び出しの結果を記録する
+// 成功したときだけ失敗の回数を戻し、上流の障害以外の失敗 (キャンセル・安全性フィルタ・中断など) は回数を変えない
+func (b *circuitBreaker) record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+	if err == nil {
+		if b.failures >= breakerThreshold {
+			log.Printf("[resilience.go] %s の遮断を解除", b.name)
+		}
+		b.failures = 0
+		return
+	}
+	if !errors.Is(err, ErrAIUpstream) && !errors.Is(err, ErrAITimeout) {
+		return
+	}
+	b.failures++
+	if b.failures >= breakerThreshold {
+		b.openUntil = time.Now().Add(breakerCooldown)
+		log.Printf("[resilience.go] %s が連続%d回失敗したため%s遮断: %v", b.name, b.failures, breakerCooldown, err)
+	}
+}
+
+// callWithRetry 呼び出し元のコンテキストに期限を付け、再試行できる失敗ならジッター付きの指数バックオフで再試行する
+// 遮断中なら呼び出さずに失敗する
+func callWithRetry(ctx context.Context, breaker *circuitBreaker, call func(ctx context.Context) error) error {
+	if err := breaker.allow(); err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, aiCallTimeout)
+	defer cancel()
+
+	var err error
+	for attempt := 1; attempt <= aiMaxAttempts; attempt++ {
+		err = classifyAIError(ctx, call(ctx))
+		if err == nil || !isRetryable(err) || attempt == aiMaxAttempts {
+			break
+		}
+
+		wait := backoff(attempt)
+		log.Printf("[resilience.go] %s の呼び出し失敗、%s後に再試行 (%d/%d): %v", breaker.name, wait.Round(time.Millisecond), attempt, aiMaxAttempts, err)
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			err = classifyAIError(ctx, ctx.Err())
+			attempt = aiMaxAttempts
+		}
+	}
+	breaker.record(err)
+	return err
+}
+
+// classifyAIError Vertex AI の失敗を種類ごとのエラーに変換する
+func classifyAIError(ctx context.Context, err error) error {
+	if err == nil {
+		return nil
+	}
+	var abort *abortError
+	if errors.As(err, &abort) {
+		return abort.err
+	}
+	if errors.Is(err, ErrAISafetyBlocked) || errors.Is(err, ErrAIEmptyResponse) || errors.Is(err, ErrAIUpstream) || errors.Is(err, ErrAITimeout) {
+		return err
+	}
+
+	var blocked *genai.BlockedError
+	if errors.As(err, &blocked) {
+		return fmt.Errorf("%w: %v", ErrAISafetyBlocked, err)
+	}
+	// 呼び出し元 (クライアントの切断など) によるキャンセルはそのまま返す
+	if errors.Is(err, context.Canceled) {
+		return err
+	}
+	if errors.Is(err, context.DeadlineExceeded) || ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("%w: %v", ErrAITimeout, err)
+	}
+	if s, ok := status.FromError(err); ok && s.Code() == codes.DeadlineExceeded {
+		return fmt.Errorf("%w: %v", ErrAITimeout, err)
+	}
+	return fmt.Errorf("%w: %w", ErrAIUpstream, err)
+}
+
+// isRetryable 再試行すれば成功する見込みがある失敗か判定する
+func isRetryable(err error) bool {
+	if errors.Is(err, ErrAITimeout) {
+		return false // 全体の期限を使い切っている
+	}
+	var noRetry *noRetryError
+	if !errors.Is(err, ErrAIUpstream) || errors.As(err, &noRetry) {
+		return false
+	}
+	s, ok := status.FromError(err)
+	if !ok {
+		return false
+	}
+	switch s.Code() {
+	case codes.Unavailable, codes.ResourceExhausted, codes.Aborted, codes.Internal, codes.Unknown:
+		return true
+	}
+	return false
+}
+
+// backoff ヘルパー関数: attempt 回目の失敗の後に待つ時間 (指数バックオフにフルジッターを掛ける)
+func backoff(attempt int) time.Duration {
+	ceiling := min(aiBackoffBase<<(attempt-1), aiBackoffMax)
+	return time.Duration(rand.Int63n(int64(ceiling)) + 1)
+}
+
+// isBlockedFinishReason ヘルパー関数: 安全性などのフィルタによって生成が止まったか判定する
+func isBlockedFinishReason(reason genai.FinishReason) bool {
+	switch reason {
+	case genai.FinishReasonSafety, genai.FinishReasonRecitation, genai.FinishReasonBlocklist,
+		genai.FinishReasonProhibitedContent, genai.FinishReasonSpii:
+		return true
+	}
+	return false
+}
+
+// candidateText ヘルパー関数: 候補のテキストを連結して返す (ブロックされた・空の候補はエラー)
+func candidateText(candidate *genai.Candidate) (string, error) {
+	if candidate == nil {
+		return "", ErrAIEmptyResponse
+	}
+	if isBlockedFinishReason(candidate.FinishReason) {
+		return "", fmt.Errorf("%w (finish_reason: %s)", ErrAISafetyBlocked, candidate.FinishReason)
+	}
+	if candidate.Content == nil || len(candidate.Content.Parts) == 0 {
+		return "", fmt.Errorf("%w (finish_reason: %s)", ErrAIEmptyResponse, candidate.FinishReason)
+	}
+
+	text := ""
+	for _, part := range candidate.Content.Parts {
+		if t, ok := part.(genai.Text); ok {
+			text += string(t)
+		}
+	}
+	if text == "" {
+		return "", fmt.Errorf("%w (finish_reason: %s)", ErrAIEmptyResponse, candidate.FinishReason)
+	}
+	return text, nil
+}
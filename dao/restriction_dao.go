@@ -0,0 +1,186 @@
+package dao
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+	"twitter/model"
+)
+
+// RestrictionDAO ユーザー制限 (凍結・シャドウバン) 用のDAO
+type RestrictionDAO struct {
+	db *sql.DB
+}
+
+func NewRestrictionDAO(db *sql.DB) *RestrictionDAO {
+	return &RestrictionDAO{db: db}
+}
+
+// AddRestriction 制限を追加 (同じ種類の有効な制限は解除してから追加、ユーザーが存在しなければ ErrUserNotFound)
+// 解除と追加の2文を実行するため、UnitOfWork.Do の中で呼び出すこと
+func (dao *RestrictionDAO) AddRestriction(ctx context.Context, restriction model.Restriction) error {
+	if _, err := conn(ctx, dao.db).ExecContext(ctx,
+		"UPDATE user_restrictions SET lifted_at = ? WHERE user_id = ? AND kind = ? AND lifted_at IS NULL",
+		restriction.CreatedAt,
+		restriction.UserID,
+		restriction.Kind,
+	); err != nil {
+		log.Printf("[restriction_dao.go] 既存制限の解除失敗 (user_id: %s, kind: %s): %v", restriction.UserID, restriction.Kind, err)
+		return err
+	}
+
+	_, err := conn(ctx, dao.db).ExecContext(ctx,
+		"INSERT INTO user_restrictions (restriction_id, user_id, kind, reason, created_at, expires_at) VALUES (?, ?, ?, ?, ?, ?)",
+		restriction.RestrictionID,
+		restriction.UserID,
+		restriction.Kind,
+		restriction.Reason,
+		restriction.CreatedAt,
+		restriction.ExpiresAt,
+	)
+	if err != nil {
+		log.Printf("[restriction_dao.go] 以下の制限追加失敗 (user_id: %s, kind: %s): %v", restriction.UserID, restriction.Kind, err)
+		return translateDBError(err, nil, ErrUserNotFound)
+	}
+	return nil
+}
+
+// LiftRestriction 指定した種類の有効な制限を解除
+func (dao *RestrictionDAO) LiftRestriction(ctx context.Context, userID, kind string) error {
+	_, err := conn(ctx, dao.db).ExecContext(ctx,
+		"UPDATE user_restrictions SET lifted_at = ? WHERE user_id = ? AND kind = ? AND lifted_at IS NULL",
+		time.Now(),
+		userID,
+		kind,
+	)
+	if err != nil {
+		log.Printf("[restriction_dao.go] 以下の制限解除失敗 (user_id: %s, kind: %s): %v", userID, kind, err)
+	}
+	return err
+}
+
+// GetActiveRestriction 指定した種類の有効な制限を取得 (存在しない場合は nil)
+func (dao *RestrictionDAO) GetActiveRestriction(ctx context.Context, userID, kind string) (*model.Restriction, error) {
+	var restriction model.Restriction
+	var expiresAt sql.NullTime
+
+	err := conn(ctx, dao.db).QueryRowContext(ctx, `
+		SELECT restriction_id, user_id, kind, reason, created_at, expires_at
+		FROM user_restrictions
+		WHERE user_id = ? AND kind = ? AND lifted_at IS NULL
+		AND (expires_at IS NULL OR expires_at > UTC_TIMESTAMP())
+		ORDER BY created_at DESC
+		LIMIT 1`, userID, kind).Scan(
+		&restriction.RestrictionID,
+		&restriction.UserID,
+		&restriction.Kind,
+		&restriction.Reason,
+		&restriction.CreatedAt,
+		&expiresAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		log.Printf("[restriction_dao.go] 有効な制限の取得失敗 (user_id: %s, kind: %s): %v", userID, kind, err)
+		return nil, err
+	}
+
+	if expiresAt.Valid {
+		restriction.ExpiresAt = &expiresAt.Time
+	}
+	return &restriction, nil
+}
+
+// GetRestrictions 指定ユーザーの制限履歴を取得
+func (dao *RestrictionDAO) GetRestrictions(ctx context.Context, userID string) ([]model.Restriction, error) {
+	rows, err := conn(ctx, dao.db).QueryContext(ctx, `
+		SELECT restriction_id, user_id, kind, reason, created_at, expires_at, lifted_at
+		FROM user_restrictions
+		WHERE user_id = ?
+		ORDER BY created_at DESC`, userID)
+	if err != nil {
+		log.Printf("[restriction_dao.go] 以下の制限履歴取得失敗 (user_id: %s): %v", userID, err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var restrictions []model.Restriction
+	for rows.Next() {
+		var restriction model.Restriction
+		var expiresAt, liftedAt sql.NullTime
+
+		if err := rows.Scan(
+			&restriction.RestrictionID,
+			&restriction.UserID,
+			&restriction.Kind,
+			&restriction.Reason,
+			&restriction.CreatedAt,
+			&expiresAt,
+			&liftedAt,
+		); err != nil {
+			log.Printf("[restriction_dao.go] 制限データのScan失敗: %v", err)
+			return nil, err
+		}
+
+		// NULL 値の処理
+		if expiresAt.Valid {
+			restriction.ExpiresAt = &expiresAt.Time
+		}
+		if liftedAt.Valid {
+			restriction.LiftedAt = &liftedAt.Time
+		}
+
+		restrictions = append(restrictions, restriction)
+	}
+	return restrictions, nil
+}
+
+// GetRestrictedUserIDs 有効な制限 (凍結・シャドウバン) がかかっているユーザーIDの集合を取得
+func (dao *RestrictionDAO) GetRestrictedUserIDs(ctx context.Context) (map[string]bool, error) {
+	rows, err := conn(ctx, dao.db).QueryContext(ctx, `
+		SELECT DISTINCT user_id
+		FROM user_restrictions
+		WHERE lifted_at IS NULL AND (expires_at IS NULL OR expires_at > UTC_TIMESTAMP())`)
+	if err != nil {
+		log.Printf("[restriction_dao.go] 制限中のユーザー取得失敗: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	userIDs := make(map[string]bool)
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err != nil {
+			log.Printf("[restriction_dao.go] ユーザーIDのScan失敗: %v", err)
+			return nil, err
+		}
+		userIDs[userID] = true
+	}
+	return userIDs, nil
+}
+
+// restrictedSQL 指定カラムのユーザーに有効な制限があるかを判定するSQL条件
+func restrictedSQL(userColumn, kind string) string {
+	return fmt.Sprintf(`EXISTS (
+			SELECT 1 FROM user_restrictions r
+			WHERE r.user_id = %s AND r.kind = '%s' AND r.lifted_at IS NULL
+			AND (r.expires_at IS NULL OR r.expires_at > UTC_TIMESTAMP()))`, userColumn, kind)
+}
+
+// activeUserSQL 凍結もシャドウバンもされていないユーザーかを判定するSQL条件
+func activeUserSQL(userColumn string) string {
+	return fmt.Sprintf("NOT %s AND NOT %s",
+		restrictedSQL(userColumn, model.RestrictionSuspend),
+		restrictedSQL(userColumn, model.RestrictionShadowBan))
+}
+
+// visibleAuthorSQL 閲覧者から投稿者が見えるかを判定するSQL条件 (閲覧者IDのプレースホルダを1つ含む)
+// 凍結ユーザーは誰からも見えず、シャドウバンされたユーザーは本人からのみ見える
+func visibleAuthorSQL(userColumn string) string {
+	return fmt.Sprintf("NOT %s AND (NOT %s OR %s = ?)",
+		restrictedSQL(userColumn, model.RestrictionSuspend),
+		restrictedSQL(userColumn, model.RestrictionShadowBan),
+		userColumn)
+}
@@ -0,0 +1,194 @@
+package dao
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+	"twitter/apperr"
+	"twitter/model"
+)
+
+// 保存した検索の失敗の種類
+var (
+	ErrSavedSearchNotFound   = apperr.NotFound("saved_search_not_found", "保存した検索が存在しません")
+	ErrSavedSearchExists     = apperr.Conflict("saved_search_exists", "同じクエリの検索を保存済みです")
+	ErrSearchHistoryNotFound = apperr.NotFound("search_history_not_found", "検索履歴にありません")
+)
+
+// SavedSearchDAO 保存した検索と検索履歴用のDAO
+type SavedSearchDAO struct {
+	db *sql.DB
+}
+
+func NewSavedSearchDAO(db *sql.DB) *SavedSearchDAO {
+	return &SavedSearchDAO{db: db}
+}
+
+// CreateSavedSearch 検索を保存 (同じクエリを保存済みなら ErrSavedSearchExists)
+func (dao *SavedSearchDAO) CreateSavedSearch(ctx context.Context, search model.SavedSearch) error {
+	_, err := conn(ctx, dao.db).ExecContext(ctx,
+		"INSERT INTO saved_searches (search_id, user_id, query, created_at, last_seen_at) VALUES (?, ?, ?, ?, ?)",
+		search.SearchID,
+		search.UserID,
+		search.Query,
+		search.CreatedAt,
+		search.LastSeenAt,
+	)
+	if err != nil {
+		log.Printf("[saved_search_dao.go] 検索の保存失敗 (user_id: %s, query: %s): %v", search.UserID, search.Query, err)
+		return translateDBError(err, ErrSavedSearchExists, ErrUserNotFound)
+	}
+	return nil
+}
+
+// GetSavedSearch ユーザーが保存した検索を取得 (存在しない場合は ErrSavedSearchNotFound)
+func (dao *SavedSearchDAO) GetSavedSearch(ctx context.Context, userID, searchID string) (*model.SavedSearch, error) {
+	row := conn(ctx, dao.db).QueryRowContext(ctx, `
+		SELECT search_id, user_id, query, created_at, last_seen_at
+		FROM saved_searches
+		WHERE user_id = ? AND search_id = ?`, userID, searchID)
+	return scanSavedSearch(row)
+}
+
+// ListSavedSearches ユーザーが保存した検索を新しい順に取得
+func (dao *SavedSearchDAO) ListSavedSearches(ctx context.Context, userID string) ([]model.SavedSearch, error) {
+	rows, err := conn(ctx, dao.db).QueryContext(ctx, `
+		SELECT search_id, user_id, query, created_at, last_seen_at
+		FROM saved_searches
+		WHERE user_id = ?
+		ORDER BY created_at DESC`, userID)
+	if err != nil {
+		log.Printf("[saved_search_dao.go] 保存した検索の一覧取得失敗 (user_id: %s): %v", userID, err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	searches := []model.SavedSearch{}
+	for rows.Next() {
+		search, err := scanSavedSearch(rows)
+		if err != nil {
+			return nil, err
+		}
+		searches = append(searches, *search)
+	}
+	return searches, rows.Err()
+}
+
+// CountSavedSearches ユーザーが保存した検索の数
+func (dao *SavedSearchDAO) CountSavedSearches(ctx context.Context, userID string) (int, error) {
+	var count int
+	if err := conn(ctx, dao.db).QueryRowContext(ctx, "SELECT COUNT(*) FROM saved_searches WHERE user_id = ?", userID).Scan(&count); err != nil {
+		log.Printf("[saved_search_dao.go] 保存した検索の数の取得失敗 (user_id: %s): %v", userID, err)
+		return 0, err
+	}
+	return count, nil
+}
+
+// MarkSavedSearchSeen 保存した検索の結果を最後に見た日時を更新
+func (dao *SavedSearchDAO) MarkSavedSearchSeen(ctx context.Context, searchID string, seenAt time.Time) error {
+	_, err := conn(ctx, dao.db).ExecContext(ctx, "UPDATE saved_searches SET last_seen_at = ? WHERE search_id = ?", seenAt, searchID)
+	if err != nil {
+		log.Printf("[saved_search_dao.go] 保存した検索の既読更新失敗 (search_id: %s): %v", searchID, err)
+	}
+	return err
+}
+
+// DeleteSavedSearch ユーザーが保存した検索を削除 (存在しない場合は ErrSavedSearchNotFound)
+func (dao *SavedSearchDAO) DeleteSavedSearch(ctx context.Context, userID, searchID string) error {
+	result, err := conn(ctx, dao.db).ExecContext(ctx, "DELETE FROM saved_searches WHERE user_id = ? AND search_id = ?", userID, searchID)
+	if err != nil {
+		log.Printf("[saved_search_dao.go] 保存した検索の削除失敗 (user_id: %s, search_id: %s): %v", userID, searchID, err)
+		return err
+	}
+	return notFoundIfNoRows(result, ErrSavedSearchNotFound)
+}
+
+// RecordSearch 検索履歴に追加し (同じクエリは日時を更新)、新しい順に keep 件を超えた古い履歴を削除
+func (dao *SavedSearchDAO) RecordSearch(ctx context.Context, userID, query string, searchedAt time.Time, keep int) error {
+	if _, err := conn(ctx, dao.db).ExecContext(ctx, `
+		INSERT INTO search_history (user_id, query, searched_at) VALUES (?, ?, ?)
+		ON DUPLICATE KEY UPDATE searched_at = VALUES(searched_at)`, userID, query, searchedAt); err != nil {
+		log.Printf("[saved_search_dao.go] 検索履歴の追加失敗 (user_id: %s): %v", userID, err)
+		return err
+	}
+
+	// MySQL は DELETE の対象と同じテーブルを LIMIT 付きのサブクエリで参照できないため、派生テーブルを挟む
+	_, err := conn(ctx, dao.db).ExecContext(ctx, `
+		DELETE FROM search_history
+		WHERE user_id = ? AND searched_at < (
+			SELECT searched_at FROM (
+				SELECT searched_at FROM search_history
+				WHERE user_id = ?
+				ORDER BY searched_at DESC
+				LIMIT 1 OFFSET ?
+			) AS oldest_kept
+		)`, userID, userID, keep-1)
+	if err != nil {
+		log.Printf("[saved_search_dao.go] 古い検索履歴の削除失敗 (user_id: %s): %v", userID, err)
+	}
+	return err
+}
+
+// ListSearchHistory ユーザーの検索履歴を新しい順に最大 limit 件取得
+func (dao *SavedSearchDAO) ListSearchHistory(ctx context.Context, userID string, limit int) ([]model.SearchHistoryEntry, error) {
+	rows, err := conn(ctx, dao.db).QueryContext(ctx, `
+		SELECT query, searched_at
+		FROM search_history
+		WHERE user_id = ?
+		ORDER BY searched_at DESC
+		LIMIT ?`, userID, limit)
+	if err != nil {
+		log.Printf("[saved_search_dao.go] 検索履歴の取得失敗 (user_id: %s): %v", userID, err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	history := []model.SearchHistoryEntry{}
+	for rows.Next() {
+		var entry model.SearchHistoryEntry
+		if err := rows.Scan(&entry.Query, &entry.SearchedAt); err != nil {
+			log.Printf("[saved_search_dao.go] 検索履歴のScan失敗: %v", err)
+			return nil, err
+		}
+		history = append(history, entry)
+	}
+	return history, rows.Err()
+}
+
+// DeleteSearchHistory 検索履歴から指定したクエリを削除 (履歴に無ければ ErrSearchHistoryNotFound)
+func (dao *SavedSearchDAO) DeleteSearchHistory(ctx context.Context, userID, query string) error {
+	result, err := conn(ctx, dao.db).ExecContext(ctx, "DELETE FROM search_history WHERE user_id = ? AND query = ?", userID, query)
+	if err != nil {
+		log.Printf("[saved_search_dao.go] 検索履歴の削除失敗 (user_id: %s): %v", userID, err)
+		return err
+	}
+	return notFoundIfNoRows(result, ErrSearchHistoryNotFound)
+}
+
+// ClearSearchHistory ユーザーの検索履歴を全て削除
+func (dao *SavedSearchDAO) ClearSearchHistory(ctx context.Context, userID string) error {
+	_, err := conn(ctx, dao.db).ExecContext(ctx, "DELETE FROM search_history WHERE user_id = ?", userID)
+	if err != nil {
+		log.Printf("[saved_search_dao.go] 検索履歴の全削除失敗 (user_id: %s): %v", userID, err)
+	}
+	return err
+}
+
+// scanSavedSearch ヘルパー関数: 保存した検索の1行を読み込む (行が無ければ ErrSavedSearchNotFound)
+func scanSavedSearch(row interface{ Scan(...interface{}) error }) (*model.SavedSearch, error) {
+	var search model.SavedSearch
+	var lastSeenAt sql.NullTime
+	err := row.Scan(&search.SearchID, &search.UserID, &search.Query, &search.CreatedAt, &lastSeenAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrSavedSearchNotFound
+	}
+	if err != nil {
+		log.Printf("[saved_search_dao.go] 保存した検索のScan失敗: %v", err)
+		return nil, err
+	}
+	if lastSeenAt.Valid {
+		search.LastSeenAt = &lastSeenAt.Time
+	}
+	return &search, nil
+}
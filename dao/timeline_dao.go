@@ -1,6 +1,7 @@
 package dao
 
 import (
+	"context"
 	"database/sql"
 	"log"
 	"twitter/model"
@@ -15,15 +16,16 @@ func NewTimelineDAO(db *sql.DB) *TimelineDAO {
 }
 
 // FetchUserTimeline ログインユーザーのタイムラインを取得
-func (dao *TimelineDAO) FetchUserTimeline(userID string) ([]model.Post, error) {
-	rows, err := dao.db.Query(`
+func (dao *TimelineDAO) FetchUserTimeline(ctx context.Context, userID string) ([]model.Post, error) {
+	rows, err := conn(ctx, dao.db).QueryContext(ctx, `
 		SELECT p.post_id, p.user_id, p.content, p.img_url, p.created_at, p.edited_at, p.parent_post_id, p.is_bad 
 		FROM posts p 
 		WHERE p.deleted_at IS NULL 
 		AND (p.user_id = ? OR EXISTS (
 			SELECT 1 FROM followers f WHERE f.user_id = ? AND f.following_user_id = p.user_id
 		)) 
-		ORDER BY p.created_at DESC`, userID, userID)
+		AND `+visibleAuthorSQL("p.user_id")+` 
+		ORDER BY p.created_at DESC`, userID, userID, userID)
 	if err != nil {
 		log.Printf("[timeline_dao.go] 以下のタイムライン取得失敗 (user_id: %s): %v", userID, err)
 		return nil, err
@@ -62,13 +64,14 @@ func (dao *TimelineDAO) FetchUserTimeline(userID string) ([]model.Post, error) {
 	return posts, nil
 }
 
-// FetchUserPosts 指定ユーザーの投稿一覧を取得
-func (dao *TimelineDAO) FetchUserPosts(userID string) ([]model.Post, error) {
-	rows, err := dao.db.Query(`
+// FetchUserPosts 指定ユーザーの投稿一覧を取得 (viewerID は閲覧者のID)
+func (dao *TimelineDAO) FetchUserPosts(ctx context.Context, userID, viewerID string) ([]model.Post, error) {
+	rows, err := conn(ctx, dao.db).QueryContext(ctx, `
 		SELECT post_id, user_id, content, img_url, created_at, edited_at, parent_post_id, is_bad 
 		FROM posts 
 		WHERE user_id = ? AND deleted_at IS NULL 
-		ORDER BY created_at DESC`, userID)
+		AND `+visibleAuthorSQL("user_id")+` 
+		ORDER BY created_at DESC`, userID, viewerID)
 	if err != nil {
 		log.Printf("[timeline_dao.go] 以下の投稿一覧取得失敗 (user_id: %s): %v", userID, err)
 		return nil, err
@@ -107,14 +110,15 @@ func (dao *TimelineDAO) FetchUserPosts(userID string) ([]model.Post, error) {
 	return posts, nil
 }
 
-// FetchLikedPosts 指定ユーザーのいいねした投稿一覧を取得
-func (dao *TimelineDAO) FetchLikedPosts(userID string) ([]model.Post, error) {
-	rows, err := dao.db.Query(`
+// FetchLikedPosts 指定ユーザーのいいねした投稿一覧を取得 (viewerID は閲覧者のID)
+func (dao *TimelineDAO) FetchLikedPosts(ctx context.Context, userID, viewerID string) ([]model.Post, error) {
+	rows, err := conn(ctx, dao.db).QueryContext(ctx, `
 		SELECT p.post_id, p.user_id, p.content, p.img_url, p.created_at, p.edited_at, p.parent_post_id, p.is_bad 
 		FROM posts p
 		JOIN likes l ON p.post_id = l.post_id
 		WHERE l.user_id = ? AND p.deleted_at IS NULL
-		ORDER BY l.created_at DESC`, userID)
+		AND `+visibleAuthorSQL("p.user_id")+`
+		ORDER BY l.created_at DESC`, userID, viewerID)
 	if err != nil {
 		log.Printf("[timeline_dao.go] いいねした投稿取得失敗 (user_id: %s): %v", userID, err)
 		return nil, err
@@ -0,0 +1,103 @@
+package dao
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/go-sql-driver/mysql"
+	"log"
+	"time"
+)
+
+const (
+	txMaxAttempts  = 3                     // デッドロックなどで失敗したトランザクションを実行する最大回数
+	txRetryBackoff = 50 * time.Millisecond // 再実行までの待ち時間 (回数に比例して延ばす)
+)
+
+// querier DB とトランザクションに共通する問い合わせの操作
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// txKey context に実行中のトランザクションを持たせるためのキー
+type txKey struct{}
+
+// conn ヘルパー関数: ctx が UnitOfWork.Do の中ならそのトランザクションを、そうでなければ db を返す
+// DAO は全ての問い合わせをこれを通して行い、呼び出し側のトランザクションに参加する
+func conn(ctx context.Context, db *sql.DB) querier {
+	if tx, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return tx
+	}
+	return db
+}
+
+// UnitOfWork 複数の DAO の操作を1つのトランザクションで実行する
+type UnitOfWork struct {
+	db *sql.DB
+}
+
+func NewUnitOfWork(db *sql.DB) *UnitOfWork {
+	return &UnitOfWork{db: db}
+}
+
+// Do fn を1つのトランザクションで実行する
+// fn に渡す ctx を使った DAO の操作は全て同じトランザクションで行い、fn がエラーを返すかパニックすればロールバックする
+// デッドロックやロック待ちのタイムアウトで失敗した場合は fn を最初から実行し直すため、
+// キャッシュの無効化など DB 以外の副作用は fn に含めず、Do が成功した後に行う
+// 既に Do の中で呼ばれた場合は外側のトランザクションに参加する (再実行は外側で行う)
+func (u *UnitOfWork) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return fn(ctx)
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := u.run(ctx, fn)
+		if err == nil || attempt >= txMaxAttempts || !isRetryableTxError(err) {
+			return err
+		}
+		log.Printf("[tx.go] トランザクションを再実行 (%d回目の失敗): %v", attempt, err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Duration(attempt) * txRetryBackoff):
+		}
+	}
+}
+
+// run ヘルパー関数: トランザクションを開始して fn を実行し、成功すればコミット、失敗すればロールバックする
+func (u *UnitOfWork) run(ctx context.Context, fn func(ctx context.Context) error) error {
+	tx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Printf("[tx.go] トランザクション開始失敗: %v", err)
+		return err
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	if err := fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			log.Printf("[tx.go] ロールバック失敗: %v", rollbackErr)
+		}
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		log.Printf("[tx.go] コミット失敗: %v", err)
+		return err
+	}
+	return nil
+}
+
+// isRetryableTxError ヘルパー関数: トランザクションを実行し直せば成功しうる失敗 (デッドロック・ロック待ちのタイムアウト) か
+func isRetryableTxError(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	if !errors.As(err, &mysqlErr) {
+		return false
+	}
+	return mysqlErr.Number == mysqlDeadlock || mysqlErr.Number == mysqlLockWaitTimeout
+}
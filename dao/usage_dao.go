@@ -0,0 +1,143 @@
+package dao
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+	"twitter/model"
+)
+
+// UsageDAO AI使用量・使用量の上限用のDAO
+type UsageDAO struct {
+	db *sql.DB
+}
+
+func NewUsageDAO(db *sql.DB) *UsageDAO {
+	return &UsageDAO{db: db}
+}
+
+// RecordUsage AI呼び出し1回分の使用量を記録 (ユーザーが存在しなければ ErrUserNotFound)
+func (dao *UsageDAO) RecordUsage(ctx context.Context, usage model.AIUsage) error {
+	_, err := conn(ctx, dao.db).ExecContext(ctx,
+		"INSERT INTO ai_usage (usage_id, user_id, operation, prompt_tokens, response_tokens, total_tokens, created_at) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		usage.UsageID,
+		sqlNullString(usage.UserID),
+		usage.Operation,
+		usage.PromptTokens,
+		usage.ResponseTokens,
+		usage.TotalTokens,
+		usage.CreatedAt,
+	)
+	if err != nil {
+		log.Printf("[usage_dao.go] 使用量の記録失敗 (operation: %s): %v", usage.Operation, err)
+		return translateDBError(err, nil, ErrUserNotFound)
+	}
+	return nil
+}
+
+// GetUserUsageSince 指定ユーザーの since 以降の使用量の合計を取得
+func (dao *UsageDAO) GetUserUsageSince(ctx context.Context, userID string, since time.Time) (*model.UsageTotals, error) {
+	var totals model.UsageTotals
+	err := conn(ctx, dao.db).QueryRowContext(ctx, `
+		SELECT COUNT(*), COALESCE(SUM(prompt_tokens), 0), COALESCE(SUM(response_tokens), 0), COALESCE(SUM(total_tokens), 0)
+		FROM ai_usage
+		WHERE user_id = ? AND created_at >= ?`, userID, since).Scan(
+		&totals.Requests,
+		&totals.PromptTokens,
+		&totals.ResponseTokens,
+		&totals.TotalTokens,
+	)
+	if err != nil {
+		log.Printf("[usage_dao.go] 以下のユーザーの使用量取得失敗 (user_id: %s): %v", userID, err)
+		return nil, err
+	}
+	return &totals, nil
+}
+
+// GetGlobalUsageSince 全体の since 以降の使用量の合計を取得
+func (dao *UsageDAO) GetGlobalUsageSince(ctx context.Context, since time.Time) (*model.UsageTotals, error) {
+	var totals model.UsageTotals
+	err := conn(ctx, dao.db).QueryRowContext(ctx, `
+		SELECT COUNT(*), COALESCE(SUM(prompt_tokens), 0), COALESCE(SUM(response_tokens), 0), COALESCE(SUM(total_tokens), 0)
+		FROM ai_usage
+		WHERE created_at >= ?`, since).Scan(
+		&totals.Requests,
+		&totals.PromptTokens,
+		&totals.ResponseTokens,
+		&totals.TotalTokens,
+	)
+	if err != nil {
+		log.Printf("[usage_dao.go] 全体の使用量取得失敗: %v", err)
+		return nil, err
+	}
+	return &totals, nil
+}
+
+// GetUserRequestsSince 指定ユーザーの since 以降の呼び出し回数と、そのうち最も古い呼び出し日時を取得
+func (dao *UsageDAO) GetUserRequestsSince(ctx context.Context, userID string, since time.Time) (int, *time.Time, error) {
+	var count int
+	var oldest sql.NullTime
+	err := conn(ctx, dao.db).QueryRowContext(ctx,
+		"SELECT COUNT(*), MIN(created_at) FROM ai_usage WHERE user_id = ? AND created_at >= ?",
+		userID,
+		since,
+	).Scan(&count, &oldest)
+	if err != nil {
+		log.Printf("[usage_dao.go] 以下のユーザーの呼び出し回数取得失敗 (user_id: %s): %v", userID, err)
+		return 0, nil, err
+	}
+	if !oldest.Valid {
+		return count, nil, nil
+	}
+	return count, &oldest.Time, nil
+}
+
+// GetQuotaOverride 指定ユーザーの上限の上書き設定を取得 (存在しない場合は nil)
+func (dao *UsageDAO) GetQuotaOverride(ctx context.Context, userID string) (*model.QuotaOverride, error) {
+	var override model.QuotaOverride
+	err := conn(ctx, dao.db).QueryRowContext(ctx,
+		"SELECT user_id, requests_per_day, tokens_per_day, reason, updated_at FROM ai_quota_overrides WHERE user_id = ?",
+		userID,
+	).Scan(&override.UserID, &override.RequestsPerDay, &override.TokensPerDay, &override.Reason, &override.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		log.Printf("[usage_dao.go] 以下のユーザーの上限取得失敗 (user_id: %s): %v", userID, err)
+		return nil, err
+	}
+	return &override, nil
+}
+
+// SetQuotaOverride 指定ユーザーの上限を上書き (既にあれば更新、ユーザーが存在しなければ ErrUserNotFound)
+func (dao *UsageDAO) SetQuotaOverride(ctx context.Context, override model.QuotaOverride) error {
+	_, err := conn(ctx, dao.db).ExecContext(ctx, `
+		INSERT INTO ai_quota_overrides (user_id, requests_per_day, tokens_per_day, reason, updated_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE
+			requests_per_day = VALUES(requests_per_day),
+			tokens_per_day = VALUES(tokens_per_day),
+			reason = VALUES(reason),
+			updated_at = VALUES(updated_at)`,
+		override.UserID,
+		override.RequestsPerDay,
+		override.TokensPerDay,
+		override.Reason,
+		override.UpdatedAt,
+	)
+	if err != nil {
+		log.Printf("[usage_dao.go] 以下のユーザーの上限設定失敗 (user_id: %s): %v", override.UserID, err)
+		return translateDBError(err, nil, ErrUserNotFound)
+	}
+	return nil
+}
+
+// DeleteQuotaOverride 指定ユーザーの上限の上書きを削除 (デフォルトの上限に戻す)
+func (dao *UsageDAO) DeleteQuotaOverride(ctx context.Context, userID string) error {
+	_, err := conn(ctx, dao.db).ExecContext(ctx, "DELETE FROM ai_quota_overrides WHERE user_id = ?", userID)
+	if err != nil {
+		log.Printf("[usage_dao.go] 以下のユーザーの上限削除失敗 (user_id: %s): %v", userID, err)
+	}
+	return err
+}
@@ -1,8 +1,10 @@
 package dao
 
 import (
+	"context"
 	"database/sql"
 	"log"
+	"strings"
 	"twitter/model"
 )
 
@@ -14,16 +16,17 @@ func NewUserDAO(db *sql.DB) *UserDAO {
 	return &UserDAO{db: db}
 }
 
-// GetUser ユーザー詳細を取得
-func (dao *UserDAO) GetUser(userID string) (*model.User, error) {
+// GetUser ユーザー詳細を取得 (存在しないか凍結中なら ErrUserNotFound)
+func (dao *UserDAO) GetUser(ctx context.Context, userID string) (*model.User, error) {
 	var user model.User
 	var bio, profileImgURL, headerImgURL, location sql.NullString
 	var birthday sql.NullTime
 
-	err := dao.db.QueryRow(`
+	// 凍結中のユーザーは存在しないものとして扱う
+	err := conn(ctx, dao.db).QueryRowContext(ctx, `
 		SELECT user_id, name, bio, profile_img_url, header_img_url, location, birthday 
 		FROM users 
-		WHERE user_id = ?`, userID).Scan(
+		WHERE user_id = ? AND NOT `+restrictedSQL("user_id", model.RestrictionSuspend), userID).Scan(
 		&user.UserID,
 		&user.Name,
 		&bio,
@@ -32,6 +35,9 @@ func (dao *UserDAO) GetUser(userID string) (*model.User, error) {
 		&location,
 		&birthday,
 	)
+	if err == sql.ErrNoRows {
+		return nil, ErrUserNotFound
+	}
 	if err != nil {
 		log.Printf("[user_dao.go] 以下のユーザー取得失敗 (user_id: %s): %v", userID, err)
 		return nil, err
@@ -50,8 +56,8 @@ func (dao *UserDAO) GetUser(userID string) (*model.User, error) {
 }
 
 // UpdateUser ユーザー情報を更新
-func (dao *UserDAO) UpdateUser(user model.User) error {
-	_, err := dao.db.Exec(`
+func (dao *UserDAO) UpdateUser(ctx context.Context, user model.User) error {
+	_, err := conn(ctx, dao.db).ExecContext(ctx, `
 		UPDATE users 
 		SET name = ?, bio = ?, profile_img_url = ?, header_img_url = ?, location = ?, birthday = ? 
 		WHERE user_id = ?`,
@@ -64,17 +70,18 @@ func (dao *UserDAO) UpdateUser(user model.User) error {
 		user.UserID,
 	)
 	if err != nil {
-		log.Printf("[user_dao.go] 以下のユーザー更新失敗 (user_id: %s, name: %s, bio: %s): %v", user.UserID, user.Name, user.Bio, err)
+		log.Printf("[user_dao.go] 以下のユーザー更新失敗 (user_id: %s, name: %s): %v", user.UserID, user.Name, err)
 	}
 	return err
 }
 
 // GetTopUsersByTweetCount ツイート数の多い順にユーザ一覧を取得
-func (dao *UserDAO) GetTopUsersByTweetCount(limit int) ([]model.User, error) {
-	rows, err := dao.db.Query(`
+func (dao *UserDAO) GetTopUsersByTweetCount(ctx context.Context, limit int) ([]model.User, error) {
+	rows, err := conn(ctx, dao.db).QueryContext(ctx, `
 		SELECT u.user_id, u.name, u.bio, u.profile_img_url, u.header_img_url, COUNT(p.post_id) AS tweet_count 
 		FROM users u 
 		LEFT JOIN posts p ON u.user_id = p.user_id AND p.deleted_at IS NULL 
+		WHERE `+activeUserSQL("u.user_id")+` 
 		GROUP BY u.user_id 
 		ORDER BY tweet_count DESC 
 		LIMIT ?`, limit)
@@ -112,13 +119,13 @@ func (dao *UserDAO) GetTopUsersByTweetCount(limit int) ([]model.User, error) {
 }
 
 // GetTopUsersByLikes いいね数の多い順にユーザ一覧を取得
-func (dao *UserDAO) GetTopUsersByLikes(limit int) ([]model.User, error) {
-	rows, err := dao.db.Query(`
+func (dao *UserDAO) GetTopUsersByLikes(ctx context.Context, limit int) ([]model.User, error) {
+	rows, err := conn(ctx, dao.db).QueryContext(ctx, `
 		SELECT u.user_id, u.name, u.bio, u.profile_img_url, u.header_img_url, COUNT(l.post_id) AS like_count 
 		FROM users u 
 		LEFT JOIN posts p ON u.user_id = p.user_id 
 		LEFT JOIN likes l ON p.post_id = l.post_id 
-		WHERE p.deleted_at IS NULL 
+		WHERE p.deleted_at IS NULL AND `+activeUserSQL("u.user_id")+` 
 		GROUP BY u.user_id 
 		ORDER BY like_count DESC 
 		LIMIT ?`, limit)
@@ -154,3 +161,61 @@ func (dao *UserDAO) GetTopUsersByLikes(limit int) ([]model.User, error) {
 	}
 	return users, nil
 }
+
+// UserExists ユーザーが登録されているかを確認 (凍結中のユーザーも含む)
+func (dao *UserDAO) UserExists(ctx context.Context, userID string) (bool, error) {
+	var exists bool
+	err := conn(ctx, dao.db).QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM users WHERE user_id = ?)", userID).Scan(&exists)
+	if err != nil {
+		log.Printf("[user_dao.go] ユーザーの存在確認失敗 (user_id: %s): %v", userID, err)
+		return false, err
+	}
+	return exists, nil
+}
+
+// GetUsersByIDs 指定したIDのユーザー一覧を取得 (順序は保証しない)
+func (dao *UserDAO) GetUsersByIDs(ctx context.Context, userIDs []string) ([]model.User, error) {
+	if len(userIDs) == 0 {
+		return nil, nil
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(userIDs)), ", ")
+	args := make([]interface{}, len(userIDs))
+	for i, userID := range userIDs {
+		args[i] = userID
+	}
+
+	rows, err := conn(ctx, dao.db).QueryContext(ctx, `
+		SELECT user_id, name, bio, profile_img_url, header_img_url 
+		FROM users 
+		WHERE user_id IN (`+placeholders+`)`, args...)
+	if err != nil {
+		log.Printf("[user_dao.go] ID指定のユーザー一覧取得失敗: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []model.User
+	for rows.Next() {
+		var user model.User
+		var bio, profileImgURL, headerImgURL sql.NullString
+
+		if err := rows.Scan(
+			&user.UserID,
+			&user.Name,
+			&bio,
+			&profileImgURL,
+			&headerImgURL,
+		); err != nil {
+			log.Printf("[user_dao.go] ユーザーデータのScan失敗: %v", err)
+			return nil, err
+		}
+
+		// NULL 値の処理
+		user.Bio = nullableToPointer(bio)
+		user.ProfileImgURL = nullableToPointer(profileImgURL)
+		user.HeaderImgURL = nullableToPointer(headerImgURL)
+
+		users = append(users, user)
+	}
+	return users, nil
+}
@@ -1,13 +1,19 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
+	"twitter/config"
 	"twitter/controller"
 	"twitter/dao"
+	"twitter/migrate"
+	"twitter/prompt"
 	"twitter/usecase"
 
 	"github.com/gorilla/handlers"
@@ -15,82 +21,191 @@ import (
 )
 
 func main() {
+	// 設定読み込み (環境変数・設定ファイル、マイグレーションでは AI の設定は不要)
+	command := ""
+	if len(os.Args) > 1 {
+		command = os.Args[1]
+	}
+	scope := config.ForServer
+	if command == "migrate" {
+		scope = config.ForMigrate
+	}
+	cfg, err := config.Load(scope)
+	if err != nil {
+		log.Fatalf("[main.go] 設定読み込み失敗: %v", err)
+	}
+	// 実際に使う設定の表示 (例: ./main config)
+	if command == "config" {
+		cfg.Print(os.Stdout)
+		return
+	}
+	var effective strings.Builder
+	cfg.Print(&effective)
+	log.Printf("[main.go] 設定:\n%s", effective.String())
+	dao.Configure(cfg)
+
+	// マイグレーション (例: ./main migrate up)
+	if command == "migrate" {
+		err := migrate.Run(context.Background(), dao.InitDB(), os.Args[2:], os.Stdout)
+		dao.CloseDB()
+		if err != nil {
+			log.Fatalf("[main.go] マイグレーション失敗: %v", err)
+		}
+		return
+	}
+	if pending, err := migrate.Pending(context.Background(), dao.InitDB()); err != nil {
+		log.Printf("[main.go] スキーマのバージョン確認失敗: %v", err)
+	} else if pending > 0 {
+		log.Printf("[main.go] 未適用のマイグレーションが%d件あります (./main migrate up で適用)", pending)
+	}
+
 	// DAO初期化
 	authDAO := dao.GetAuthDAO()
 	followDAO := dao.GetFollowDAO()
+	blockDAO := dao.GetBlockDAO()
 	likeDAO := dao.GetLikeDAO()
 	postDAO := dao.GetPostDAO()
 	timelineDAO := dao.GetTimelineDAO()
 	userDAO := dao.GetUserDAO()
 	findDAO := dao.GetFindDAO()
 	geminiDAO := dao.GetGeminiDAO()
+	restrictionDAO := dao.GetRestrictionDAO()
+	embeddingDAO := dao.GetEmbeddingDAO()
+	usageDAO := dao.GetUsageDAO()
+	cacheDAO := dao.GetCacheDAO()
+	imageDAO := dao.GetImageDAO()
+	savedSearchDAO := dao.GetSavedSearchDAO()
+	unitOfWork := dao.GetUnitOfWork()
+	// プロンプトテンプレート読み込み
+	prompts, err := prompt.Load(cfg.Prompt.Versions, prompt.Limits{
+		ContextTokenBudget: cfg.Prompt.ContextTokenBudget,
+		MaxInstructionLen:  cfg.Prompt.MaxInstructionLen,
+		MaxTempTextLen:     cfg.Prompt.MaxTempTextLen,
+	})
+	if err != nil {
+		log.Fatalf("[main.go] プロンプトテンプレート読み込み失敗: %v", err)
+	}
+
 	// UseCase初期化
-	authUseCase := usecase.NewAuthUseCase(authDAO)
-	followUseCase := usecase.NewFollowUseCase(followDAO)
-	likeUseCase := usecase.NewLikeUseCase(likeDAO)
-	postUseCase := usecase.NewPostUseCase(postDAO)
+	restrictionGuard := usecase.NewRestrictionGuard(restrictionDAO)
+	followUseCase := usecase.NewFollowUseCase(followDAO, restrictionGuard)
+	blockUseCase := usecase.NewBlockUseCase(unitOfWork, blockDAO, followDAO)
+	likeUseCase := usecase.NewLikeUseCase(likeDAO, restrictionGuard)
+	generationCache := usecase.NewGenerationCache(cacheDAO, cfg.Cache)
 	timelineUseCase := usecase.NewTimelineUseCase(timelineDAO)
-	userUseCase := usecase.NewUserUseCase(userDAO)
-	findUseCase := usecase.NewFindUseCase(findDAO)
-	geminiUseCase := usecase.NewGeminiUseCase(geminiDAO)
+	findUseCase := usecase.NewFindUseCase(findDAO, savedSearchDAO)
+	quotaUseCase := usecase.NewQuotaUseCase(usageDAO, userDAO, restrictionGuard, cfg.Quota)
+	geminiUseCase := usecase.NewGeminiUseCase(geminiDAO, embeddingDAO, prompts, quotaUseCase, generationCache)
+	imageUseCase := usecase.NewImageUseCase(imageDAO, geminiUseCase)
+	suggestUseCase := usecase.NewSuggestUseCase(findDAO)
+	authUseCase := usecase.NewAuthUseCase(authDAO, restrictionDAO, imageUseCase, suggestUseCase)
+	semanticSearchUseCase := usecase.NewSemanticSearchUseCase(embeddingDAO, findDAO, quotaUseCase)
+	postUseCase := usecase.NewPostUseCase(unitOfWork, postDAO, embeddingDAO, imageDAO, generationCache, imageUseCase, semanticSearchUseCase, suggestUseCase, restrictionGuard)
+	userUseCase := usecase.NewUserUseCase(userDAO, imageUseCase, suggestUseCase, restrictionGuard)
+	adminUseCase := usecase.NewAdminUseCase(unitOfWork, restrictionDAO, usageDAO, generationCache)
+	recommendUseCase := usecase.NewRecommendUseCase(followDAO, userDAO, restrictionDAO, blockDAO)
 	// Controller初期化
 	authController := controller.NewAuthController(authUseCase)
 	followController := controller.NewFollowController(followUseCase)
+	blockController := controller.NewBlockController(blockUseCase)
 	likeController := controller.NewLikeController(likeUseCase)
 	postController := controller.NewPostController(postUseCase)
 	timelineController := controller.NewTimelineController(timelineUseCase)
 	userController := controller.NewUserController(userUseCase)
-	findController := controller.NewFindController(findUseCase)
-	geminiController := controller.NewGeminiController(geminiUseCase)
+	findController := controller.NewFindController(findUseCase, semanticSearchUseCase, suggestUseCase)
+	geminiController := controller.NewGeminiController(geminiUseCase, quotaUseCase)
+	adminController := controller.NewAdminController(adminUseCase, cfg.AdminToken)
+	recommendController := controller.NewRecommendController(recommendUseCase)
+	imageController := controller.NewImageController(imageUseCase)
 
 	// ルーター初期化
 	router := mux.NewRouter()
 
 	// ユーザー関連エンドポイント
-	router.HandleFunc("/auth/register", authController.Handle).Methods("POST")
-	router.HandleFunc("/user/{user_id}", userController.HandleGetUser).Methods("GET")
-	router.HandleFunc("/user/update-profile", userController.HandleUpdateProfile).Methods("PUT")
+	router.HandleFunc("/auth/register", controller.WithTimeout(controller.DefaultTimeout, authController.Handle)).Methods("POST")
+	router.HandleFunc("/auth/check/{user_id}", controller.WithTimeout(controller.DefaultTimeout, authController.HandleCheckLogin)).Methods("GET")
+	router.HandleFunc("/user/{user_id}", controller.WithTimeout(controller.DefaultTimeout, userController.HandleGetUser)).Methods("GET")
+	router.HandleFunc("/user/update-profile", controller.WithTimeout(controller.DefaultTimeout, userController.HandleUpdateProfile)).Methods("PUT")
+	router.HandleFunc("/user/{user_id}/image_analysis", controller.WithTimeout(controller.DefaultTimeout, imageController.HandleGetProfileImageAnalysis)).Methods("GET")
 	// +ユーザランキング関連エンドポイント
-	router.HandleFunc("/users/top/tweets", userController.HandleGetTopUsersByTweetCount).Methods("GET")
-	router.HandleFunc("/users/top/likes", userController.HandleGetTopUsersByLikes).Methods("GET")
+	router.HandleFunc("/users/top/tweets", controller.WithTimeout(controller.DefaultTimeout, userController.HandleGetTopUsersByTweetCount)).Methods("GET")
+	router.HandleFunc("/users/top/likes", controller.WithTimeout(controller.DefaultTimeout, userController.HandleGetTopUsersByLikes)).Methods("GET")
 
 	// 投稿関連エンドポイント
-	router.HandleFunc("/post/create", postController.HandleCreatePost).Methods("POST")
-	router.HandleFunc("/post/{post_id}", postController.HandleGetPost).Methods("GET")
-	router.HandleFunc("/post/{post_id}/update", postController.HandleUpdatePost).Methods("PUT")
-	router.HandleFunc("/post/{post_id}/delete", postController.HandleDeletePost).Methods("DELETE")
-	router.HandleFunc("/post/{post_id}/reply", postController.HandleReplyPost).Methods("POST")
-	router.HandleFunc("/post/{post_id}/children", postController.HandleGetChildrenPosts).Methods("GET")
+	router.HandleFunc("/post/create", controller.WithTimeout(controller.DefaultTimeout, postController.HandleCreatePost)).Methods("POST")
+	router.HandleFunc("/post/{post_id}", controller.WithTimeout(controller.DefaultTimeout, postController.HandleGetPost)).Methods("GET")
+	router.HandleFunc("/post/{post_id}/update", controller.WithTimeout(controller.DefaultTimeout, postController.HandleUpdatePost)).Methods("PUT")
+	router.HandleFunc("/post/{post_id}/delete", controller.WithTimeout(controller.DefaultTimeout, postController.HandleDeletePost)).Methods("DELETE")
+	router.HandleFunc("/post/{post_id}/reply", controller.WithTimeout(controller.DefaultTimeout, postController.HandleReplyPost)).Methods("POST")
+	router.HandleFunc("/post/{post_id}/children", controller.WithTimeout(controller.DefaultTimeout, postController.HandleGetChildrenPosts)).Methods("GET")
+	router.HandleFunc("/post/{post_id}/translate", controller.WithTimeout(controller.AITimeout, geminiController.HandleTranslatePost)).Methods("GET")
+	router.HandleFunc("/post/{post_id}/summary", controller.WithTimeout(controller.AITimeout, geminiController.HandleSummarizeThread)).Methods("GET")
+	router.HandleFunc("/post/{post_id}/image_analysis", controller.WithTimeout(controller.DefaultTimeout, imageController.HandleGetPostImageAnalysis)).Methods("GET")
 
 	// いいね関連エンドポイント
-	router.HandleFunc("/like/{post_id}", likeController.HandleAddLike).Methods("POST")
-	router.HandleFunc("/like/{post_id}/remove", likeController.HandleRemoveLike).Methods("DELETE")
-	router.HandleFunc("/like/{post_id}/users", likeController.HandleGetUsersByPostID).Methods("GET")
+	router.HandleFunc("/like/{post_id}", controller.WithTimeout(controller.DefaultTimeout, likeController.HandleAddLike)).Methods("POST")
+	router.HandleFunc("/like/{post_id}/remove", controller.WithTimeout(controller.DefaultTimeout, likeController.HandleRemoveLike)).Methods("DELETE")
+	router.HandleFunc("/like/{post_id}/users", controller.WithTimeout(controller.DefaultTimeout, likeController.HandleGetUsersByPostID)).Methods("GET")
 
 	// フォロー関連エンドポイント
-	router.HandleFunc("/follow/{user_id}", followController.HandleAddFollow).Methods("POST")
-	router.HandleFunc("/follow/{user_id}/remove", followController.HandleRemoveFollow).Methods("DELETE")
-	router.HandleFunc("/follow/{user_id}/followers", followController.HandleGetFollowers).Methods("GET")
-	router.HandleFunc("/follow/{user_id}/following", followController.HandleGetFollowing).Methods("GET")
+	router.HandleFunc("/follow/{user_id}", controller.WithTimeout(controller.DefaultTimeout, followController.HandleAddFollow)).Methods("POST")
+	router.HandleFunc("/follow/{user_id}/remove", controller.WithTimeout(controller.DefaultTimeout, followController.HandleRemoveFollow)).Methods("DELETE")
+	router.HandleFunc("/follow/{user_id}/followers", controller.WithTimeout(controller.DefaultTimeout, followController.HandleGetFollowers)).Methods("GET")
+	router.HandleFunc("/follow/{user_id}/following", controller.WithTimeout(controller.DefaultTimeout, followController.HandleGetFollowing)).Methods("GET")
 	// +フォロー関係取得エンドポイント
-	router.HandleFunc("/follow/graph", followController.HandleGetFollowGraph).Methods("GET")
+	router.HandleFunc("/follow/graph", controller.WithTimeout(controller.DefaultTimeout, followController.HandleGetFollowGraph)).Methods("GET")
+
+	// ブロック関連エンドポイント
+	router.HandleFunc("/block/{user_id}", controller.WithTimeout(controller.DefaultTimeout, blockController.HandleAddBlock)).Methods("POST")
+	router.HandleFunc("/block/{user_id}/remove", controller.WithTimeout(controller.DefaultTimeout, blockController.HandleRemoveBlock)).Methods("DELETE")
+	router.HandleFunc("/block/{user_id}/blocking", controller.WithTimeout(controller.DefaultTimeout, blockController.HandleGetBlockedUsers)).Methods("GET")
 
 	// タイムライン関連エンドポイント
-	router.HandleFunc("/timeline/{auth_id}", timelineController.HandleGetUserTimeline).Methods("GET")
-	router.HandleFunc("/timeline/posts_by/{user_id}", timelineController.HandleGetUserPosts).Methods("GET")
-	router.HandleFunc("/timeline/liked_by/{user_id}", timelineController.HandleGetLikedPosts).Methods("GET")
+	router.HandleFunc("/timeline/{auth_id}", controller.WithTimeout(controller.DefaultTimeout, timelineController.HandleGetUserTimeline)).Methods("GET")
+	router.HandleFunc("/timeline/posts_by/{user_id}", controller.WithTimeout(controller.DefaultTimeout, timelineController.HandleGetUserPosts)).Methods("GET")
+	router.HandleFunc("/timeline/liked_by/{user_id}", controller.WithTimeout(controller.DefaultTimeout, timelineController.HandleGetLikedPosts)).Methods("GET")
+	router.HandleFunc("/timeline/{auth_id}/digest", controller.WithTimeout(controller.AITimeout, geminiController.HandleDigestTimeline)).Methods("GET")
 
 	// 検索関連エンドポイント
-	router.HandleFunc("/find/user/{key}", findController.HandleFindUsers).Methods("GET")
-	router.HandleFunc("/find/post/{key}", findController.HandleFindPosts).Methods("GET")
+	router.HandleFunc("/find/user/{key}", controller.WithTimeout(controller.SearchTimeout, findController.HandleFindUsers)).Methods("GET")
+	router.HandleFunc("/find/suggest", controller.WithTimeout(controller.SearchTimeout, findController.HandleSuggest)).Methods("GET")
+	router.HandleFunc("/find/post/semantic", controller.WithTimeout(controller.AITimeout, findController.HandleSemanticSearchPosts)).Methods("GET") // /find/post/{key} より先に登録する
+	router.HandleFunc("/find/post/{key}", controller.WithTimeout(controller.SearchTimeout, findController.HandleFindPosts)).Methods("GET")
+	router.HandleFunc("/find/saved/{auth_id}", controller.WithTimeout(controller.DefaultTimeout, findController.HandleSaveSearch)).Methods("POST")
+	router.HandleFunc("/find/saved/{auth_id}", controller.WithTimeout(controller.DefaultTimeout, findController.HandleListSavedSearches)).Methods("GET")
+	router.HandleFunc("/find/saved/{auth_id}/{search_id}/new", controller.WithTimeout(controller.SearchTimeout, findController.HandleGetSavedSearchTimeline)).Methods("GET")
+	router.HandleFunc("/find/saved/{auth_id}/{search_id}/delete", controller.WithTimeout(controller.DefaultTimeout, findController.HandleDeleteSavedSearch)).Methods("DELETE")
+	router.HandleFunc("/find/history/{auth_id}", controller.WithTimeout(controller.DefaultTimeout, findController.HandleListSearchHistory)).Methods("GET")
+	router.HandleFunc("/find/history/{auth_id}/remove", controller.WithTimeout(controller.DefaultTimeout, findController.HandleDeleteSearchHistory)).Methods("DELETE")
+	router.HandleFunc("/find/history/{auth_id}/clear", controller.WithTimeout(controller.DefaultTimeout, findController.HandleClearSearchHistory)).Methods("DELETE")
 
 	// Geimini関連エンドポイント
-	router.HandleFunc("/gemini/generate_name/{auth_id}", geminiController.HandleGenerateName).Methods("POST")
-	router.HandleFunc("/gemini/generate_bio/{auth_id}", geminiController.HandleGenerateBio).Methods("POST")
-	router.HandleFunc("/gemini/generate_tweet_continuation/{auth_id}", geminiController.HandleGenerateTweetContinuation).Methods("POST")
-	router.HandleFunc("/gemini/check_isbad/{post_id}", geminiController.HandleCheckIsBad).Methods("GET")
-	router.HandleFunc("/gemini/update_isbad/{post_id}/{bool}", geminiController.HandleUpdateIsBad).Methods("PUT")
-	router.HandleFunc("/gemini/recommend/{auth_id}", geminiController.HandleRecommendUsers).Methods("POST")
+	router.HandleFunc("/gemini/generate_name/{auth_id}", controller.WithTimeout(controller.AITimeout, geminiController.HandleGenerateName)).Methods("POST")
+	router.HandleFunc("/gemini/generate_bio/{auth_id}", controller.WithTimeout(controller.AITimeout, geminiController.HandleGenerateBio)).Methods("POST")
+	router.HandleFunc("/gemini/generate_tweet_continuation/{auth_id}", controller.WithTimeout(controller.AITimeout, geminiController.HandleGenerateTweetContinuation)).Methods("POST")
+	router.HandleFunc("/gemini/generate_name/{auth_id}/stream", controller.WithTimeout(controller.StreamTimeout, geminiController.HandleStreamName)).Methods("POST")
+	router.HandleFunc("/gemini/generate_bio/{auth_id}/stream", controller.WithTimeout(controller.StreamTimeout, geminiController.HandleStreamBio)).Methods("POST")
+	router.HandleFunc("/gemini/generate_tweet_continuation/{auth_id}/stream", controller.WithTimeout(controller.StreamTimeout, geminiController.HandleStreamTweetContinuation)).Methods("POST")
+	router.HandleFunc("/gemini/generate_name/{auth_id}/candidates", controller.WithTimeout(controller.AITimeout, geminiController.HandleGenerateNameCandidates)).Methods("POST")
+	router.HandleFunc("/gemini/generate_bio/{auth_id}/candidates", controller.WithTimeout(controller.AITimeout, geminiController.HandleGenerateBioCandidates)).Methods("POST")
+	router.HandleFunc("/gemini/refine_name/{auth_id}", controller.WithTimeout(controller.AITimeout, geminiController.HandleRefineName)).Methods("POST")
+	router.HandleFunc("/gemini/refine_bio/{auth_id}", controller.WithTimeout(controller.AITimeout, geminiController.HandleRefineBio)).Methods("POST")
+	router.HandleFunc("/gemini/suggest_replies/{post_id}/{auth_id}", controller.WithTimeout(controller.AITimeout, geminiController.HandleSuggestReplies)).Methods("POST")
+	router.HandleFunc("/gemini/check_isbad/{post_id}", controller.WithTimeout(controller.AITimeout, geminiController.HandleCheckIsBad)).Methods("GET")
+	router.HandleFunc("/gemini/update_isbad/{post_id}/{bool}", controller.WithTimeout(controller.DefaultTimeout, geminiController.HandleUpdateIsBad)).Methods("PUT")
+	router.HandleFunc("/gemini/recommend/{auth_id}", controller.WithTimeout(controller.AITimeout, geminiController.HandleRecommendUsers)).Methods("POST")
+	router.HandleFunc("/gemini/usage/{auth_id}", controller.WithTimeout(controller.DefaultTimeout, geminiController.HandleGetUsage)).Methods("GET")
+
+	// おすすめユーザー関連エンドポイント
+	router.HandleFunc("/recommend/users/{auth_id}", controller.WithTimeout(controller.DefaultTimeout, recommendController.HandleRecommendUsers)).Methods("GET")
+
+	// 管理者用エンドポイント
+	router.HandleFunc("/admin/restrict/{kind}/{user_id}", controller.WithTimeout(controller.DefaultTimeout, adminController.HandleRestrictUser)).Methods("POST")
+	router.HandleFunc("/admin/restrict/{kind}/{user_id}/lift", controller.WithTimeout(controller.DefaultTimeout, adminController.HandleLiftRestriction)).Methods("DELETE")
+	router.HandleFunc("/admin/restrictions/{user_id}", controller.WithTimeout(controller.DefaultTimeout, adminController.HandleGetRestrictions)).Methods("GET")
+	router.HandleFunc("/admin/ai_quota/{user_id}", controller.WithTimeout(controller.DefaultTimeout, adminController.HandleSetQuotaOverride)).Methods("PUT")
+	router.HandleFunc("/admin/ai_quota/{user_id}", controller.WithTimeout(controller.DefaultTimeout, adminController.HandleDeleteQuotaOverride)).Methods("DELETE")
+	router.HandleFunc("/admin/ai_cache/stats", controller.WithTimeout(controller.DefaultTimeout, adminController.HandleGetCacheStats)).Methods("GET")
 
 	// OPTIONSリクエストに対応
 	router.Methods("OPTIONS").HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -99,9 +214,10 @@ func main() {
 
 	// CORS設定
 	corsOptions := handlers.CORS(
-		handlers.AllowedOrigins([]string{"*"}),                                       // 許可するURL
-		handlers.AllowedHeaders([]string{"Content-Type", "Authorization"}),           // 許可するヘッダー
-		handlers.AllowedMethods([]string{"GET", "DELETE", "POST", "PUT", "OPTIONS"}), // 許可するHTTPメソッド
+		handlers.AllowedOrigins(cfg.Server.AllowedOrigins),                                                              // 許可するURL
+		handlers.AllowedHeaders([]string{"Content-Type", "Authorization"}),                                              // 許可するヘッダー
+		handlers.AllowedMethods([]string{"GET", "DELETE", "POST", "PUT", "OPTIONS"}),                                    // 許可するHTTPメソッド
+		handlers.ExposedHeaders([]string{controller.PromptTemplateHeader, controller.CacheStatusHeader, "Retry-After"}), // クライアントから読めるヘッダー
 	)
 
 	// CORSエラーロギングミドルウェア
@@ -115,21 +231,40 @@ func main() {
 		})
 	}
 
-	// シグナル処理
+	server := &http.Server{
+		Addr:              ":" + cfg.Server.Port,
+		Handler:           loggingHandler(corsOptions(router)),
+		ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout,
+		ReadTimeout:       cfg.Server.ReadTimeout,
+		WriteTimeout:      cfg.Server.WriteTimeout,
+		IdleTimeout:       cfg.Server.IdleTimeout,
+	}
+
+	// シグナル処理 (処理中のリクエストを待ってから終了する)
 	sig := make(chan os.Signal, 1)
 	signal.Notify(sig, syscall.SIGTERM, syscall.SIGINT)
+	shutdownDone := make(chan struct{})
 	go func() {
+		defer close(shutdownDone)
 		<-sig
-		dao.CloseDB()
-		os.Exit(0)
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+		defer cancel()
+		if err := server.Shutdown(ctx); err != nil {
+			log.Printf("[main.go] サーバー終了時に処理中のリクエストを待ちきれませんでした: %v", err)
+		}
 	}()
 
 	// サーバー起動
-	log.Println("[main.go] サーバー起動中...")
-	wrappedRouter := loggingHandler(corsOptions(router))
-	if err := http.ListenAndServe(":8080", wrappedRouter); err != nil {
-		log.Fatal("[main.go] サーバー起動失敗")
+	log.Printf("[main.go] サーバー起動中... (port: %s)", cfg.Server.Port)
+	if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		log.Fatalf("[main.go] サーバー起動失敗: %v", err)
 	}
+	<-shutdownDone
+	// DB を閉じる前に、DB を使うワーカーを止める
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+	semanticSearchUseCase.Close(ctx)
+	dao.CloseDB()
 }
 
 // responseRecorder レスポンスのステータスコードを記録するためのラッパー
@@ -143,3 +278,10 @@ func (rec *responseRecorder) WriteHeader(code int) {
 	rec.statusCode = code
 	rec.ResponseWriter.WriteHeader(code)
 }
+
+// Flush ストリーミングレスポンスのために下位の ResponseWriter をフラッシュ
+func (rec *responseRecorder) Flush() {
+	if flusher, ok := rec.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
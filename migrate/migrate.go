@@ -0,0 +1,374 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"github.com/go-sql-driver/mysql"
+	"io"
+	"io/fs"
+	"log"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	mysqlNoSuchTable = 1146 // テーブルが存在しない
+
+	lockName    = "twitter_schema_migrations" // 同時に実行されないようにする MySQL のアドバイザリロックの名前
+	lockTimeout = 30                          // ロックを待つ最大秒数
+)
+
+//go:embed migrations
+var migrationFS embed.FS
+
+var (
+	// ファイル名の形式: migrations/<バージョン>_<名前>.up.sql (適用) と migrations/<バージョン>_<名前>.down.sql (取り消し)
+	fileNamePattern = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+	// ErrLocked 他のインスタンスがマイグレーションを実行中
+	ErrLocked = errors.New("他のインスタンスがマイグレーションを実行中です")
+	// ErrIrreversible 取り消せないマイグレーション (down の SQL がコメントのみ)
+	ErrIrreversible = errors.New("取り消せないマイグレーションです")
+)
+
+// Migration 1つのバージョンのスキーマ変更
+type Migration struct {
+	Version int
+	Name    string
+	up      string
+	down    string
+}
+
+// Irreversible down の SQL に文が無く (コメントのみ)、取り消せないか
+func (m Migration) Irreversible() bool {
+	return len(splitStatements(m.down)) == 0
+}
+
+// Status バージョンごとの適用状況
+type Status struct {
+	Version   int
+	Name      string
+	AppliedAt *time.Time // 未適用なら nil
+}
+
+// Load 埋め込まれたマイグレーションをバージョン順に読み込む
+// バージョンの重複や、取り消しの SQL が無いバージョンはエラーにする
+func Load() ([]Migration, error) {
+	byVersion := make(map[int]*Migration)
+	err := fs.WalkDir(migrationFS, "migrations", func(filePath string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		matches := fileNamePattern.FindStringSubmatch(d.Name())
+		if matches == nil {
+			return fmt.Errorf("マイグレーションのファイル名が不正です: %s", filePath)
+		}
+		version, _ := strconv.Atoi(matches[1])
+		content, err := migrationFS.ReadFile(filePath)
+		if err != nil {
+			return err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: matches[2]}
+			byVersion[version] = m
+		} else if m.Name != matches[2] {
+			return fmt.Errorf("バージョン %d のマイグレーションが重複しています: %s", version, filePath)
+		}
+		if matches[3] == "up" {
+			m.up = string(content)
+		} else {
+			m.down = string(content)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.up == "" || m.down == "" {
+			return nil, fmt.Errorf("バージョン %d (%s) の up と down の SQL が揃っていません", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Migrator スキーマのバージョンを schema_migrations テーブルで管理し、マイグレーションを適用・取り消す
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+}
+
+func NewMigrator(db *sql.DB, migrations []Migration) *Migrator {
+	return &Migrator{db: db, migrations: migrations}
+}
+
+// Up 未適用のマイグレーションを古い順に最大 steps 件適用する (steps が 0 以下なら全て)
+func (m *Migrator) Up(ctx context.Context, steps int) ([]Migration, error) {
+	var done []Migration
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, migration := range m.migrations {
+			if steps > 0 && len(done) >= steps {
+				break
+			}
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+			if err := execScript(ctx, conn, migration.up); err != nil {
+				return fmt.Errorf("バージョン %d (%s) の適用失敗: %w", migration.Version, migration.Name, err)
+			}
+			if _, err := conn.ExecContext(ctx, "INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)", migration.Version, migration.Name, time.Now()); err != nil {
+				return fmt.Errorf("バージョン %d (%s) の記録失敗: %w", migration.Version, migration.Name, err)
+			}
+			log.Printf("[migrate.go] マイグレーション適用: %04d_%s", migration.Version, migration.Name)
+			done = append(done, migration)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Down 適用済みのマイグレーションを新しい順に最大 steps 件取り消す (steps が 0 以下なら1件)
+// 取り消せないマイグレーションが含まれる場合は何もせず ErrIrreversible を返す
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	if steps <= 0 {
+		steps = 1
+	}
+	var done []Migration
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+		var targets []Migration
+		for i := len(m.migrations) - 1; i >= 0 && len(targets) < steps; i-- {
+			if _, ok := applied[m.migrations[i].Version]; ok {
+				targets = append(targets, m.migrations[i])
+			}
+		}
+		// 取り消せないバージョンが含まれていれば、どのバージョンも取り消さない
+		for _, migration := range targets {
+			if migration.Irreversible() {
+				return fmt.Errorf("バージョン %d (%s) は取り消せません。%04d_%s.down.sql の説明に従って手動で戻してください: %w", migration.Version, migration.Name, migration.Version, migration.Name, ErrIrreversible)
+			}
+		}
+		for _, migration := range targets {
+			if err := execScript(ctx, conn, migration.down); err != nil {
+				return fmt.Errorf("バージョン %d (%s) の取り消し失敗: %w", migration.Version, migration.Name, err)
+			}
+			if _, err := conn.ExecContext(ctx, "DELETE FROM schema_migrations WHERE version = ?", migration.Version); err != nil {
+				return fmt.Errorf("バージョン %d (%s) の記録の削除失敗: %w", migration.Version, migration.Name, err)
+			}
+			log.Printf("[migrate.go] マイグレーション取り消し: %04d_%s", migration.Version, migration.Name)
+			done = append(done, migration)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Status 全てのマイグレーションの適用状況をバージョン順に返す
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	applied, err := appliedVersions(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	statuses := make([]Status, len(m.migrations))
+	for i, migration := range m.migrations {
+		statuses[i] = Status{Version: migration.Version, Name: migration.Name}
+		if appliedAt, ok := applied[migration.Version]; ok {
+			statuses[i].AppliedAt = &appliedAt
+		}
+	}
+	return statuses, nil
+}
+
+// Pending 未適用のマイグレーションの件数を返す (起動時の確認用)
+func Pending(ctx context.Context, db *sql.DB) (int, error) {
+	migrations, err := Load()
+	if err != nil {
+		return 0, err
+	}
+	statuses, err := NewMigrator(db, migrations).Status(ctx)
+	if err != nil {
+		return 0, err
+	}
+	pending := 0
+	for _, status := range statuses {
+		if status.AppliedAt == nil {
+			pending++
+		}
+	}
+	return pending, nil
+}
+
+// withLock ヘルパー関数: アドバイザリロックを取得した接続で fn を実行する
+// ロックは接続ごとに持つため、取得から解放まで同じ接続を使う
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	var locked sql.NullInt64
+	if err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, ?)", lockName, lockTimeout).Scan(&locked); err != nil {
+		return fmt.Errorf("ロックの取得失敗: %w", err)
+	}
+	if !locked.Valid || locked.Int64 != 1 {
+		return ErrLocked
+	}
+	defer func() {
+		// ctx がキャンセルされていてもロックは解放する
+		if _, err := conn.ExecContext(context.WithoutCancel(ctx), "SELECT RELEASE_LOCK(?)", lockName); err != nil {
+			log.Printf("[migrate.go] ロックの解放失敗: %v", err)
+		}
+	}()
+
+	if err := ensureVersionTable(ctx, conn); err != nil {
+		return err
+	}
+	return fn(conn)
+}
+
+// ensureVersionTable ヘルパー関数: 適用済みのバージョンを記録するテーブルが無ければ作成する
+func ensureVersionTable(ctx context.Context, conn *sql.Conn) error {
+	_, err := conn.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version    INT          NOT NULL,
+			name       VARCHAR(255) NOT NULL,
+			applied_at DATETIME     NOT NULL,
+			PRIMARY KEY (version)
+		) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4`)
+	if err != nil {
+		return fmt.Errorf("schema_migrations テーブルの作成失敗: %w", err)
+	}
+	return nil
+}
+
+// appliedVersions ヘルパー関数: 適用済みのバージョンと適用日時
+func appliedVersions(ctx context.Context, conn *sql.Conn) (map[int]time.Time, error) {
+	rows, err := conn.QueryContext(ctx, "SELECT version, applied_at FROM schema_migrations")
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlNoSuchTable {
+		return map[int]time.Time{}, nil // まだ一度も適用していない
+	}
+	if err != nil {
+		return nil, fmt.Errorf("適用済みのバージョンの取得失敗: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[int]time.Time)
+	for rows.Next() {
+		var version int
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		applied[version] = appliedAt
+	}
+	return applied, rows.Err()
+}
+
+// execScript ヘルパー関数: SQL を1文ずつ実行する
+// MySQL の DDL はトランザクションで取り消せないため、途中で失敗した場合は成功した文の変更が残る
+func execScript(ctx context.Context, conn *sql.Conn, script string) error {
+	for _, statement := range splitStatements(script) {
+		if _, err := conn.ExecContext(ctx, statement); err != nil {
+			return fmt.Errorf("%w\n%s", err, statement)
+		}
+	}
+	return nil
+}
+
+// splitStatements ヘルパー関数: 行末の ; で SQL を文に分ける (-- で始まる行はコメントとして除く)
+// ドライバで複数の文をまとめて実行する設定 (multiStatements) を使わずに済むようにする
+func splitStatements(script string) []string {
+	var statements []string
+	var current strings.Builder
+	for _, line := range strings.Split(script, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+		current.WriteString(line)
+		current.WriteString("\n")
+		if strings.HasSuffix(trimmed, ";") {
+			statements = append(statements, strings.TrimSuffix(strings.TrimSpace(current.String()), ";"))
+			current.Reset()
+		}
+	}
+	if rest := strings.TrimSpace(current.String()); rest != "" {
+		statements = append(statements, rest)
+	}
+	return statements
+}
+
+// Run migrate サブコマンドを実行する
+// args は "up [件数]"、"down [件数]"、"status" のいずれか
+func Run(ctx context.Context, db *sql.DB, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return errors.New("使い方: migrate up [件数] | down [件数] | status")
+	}
+	steps := 0
+	if len(args) > 1 {
+		n, err := strconv.Atoi(args[1])
+		if err != nil || n <= 0 {
+			return fmt.Errorf("件数は1以上の整数で指定してください: %s", args[1])
+		}
+		steps = n
+	}
+
+	migrations, err := Load()
+	if err != nil {
+		return err
+	}
+	migrator := NewMigrator(db, migrations)
+
+	switch args[0] {
+	case "up":
+		done, err := migrator.Up(ctx, steps)
+		fmt.Fprintf(out, "%d件のマイグレーションを適用しました\n", len(done))
+		return err
+	case "down":
+		done, err := migrator.Down(ctx, steps)
+		fmt.Fprintf(out, "%d件のマイグレーションを取り消しました\n", len(done))
+		return err
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, status := range statuses {
+			state := "未適用"
+			if status.AppliedAt != nil {
+				state = "適用済み " + status.AppliedAt.Format(time.DateTime)
+			}
+			fmt.Fprintf(out, "%s\t%s\n", fmt.Sprintf("%04d_%s", status.Version, status.Name), state)
+		}
+		return nil
+	default:
+		return fmt.Errorf("不明なサブコマンドです: %s (up, down, status のいずれか)", args[0])
+	}
+}
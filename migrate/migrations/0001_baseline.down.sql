@@ -0,0 +1,4 @@
+-- 0001_baseline は取り消せない (migrate down はエラーになる)
+-- up は CREATE TABLE IF NOT EXISTS で既存の users・posts・likes・followers をそのまま引き継ぐため、
+-- ここでテーブルを削除すると 0001 より前からあるデータも全て消えてしまう
+-- スキーマを作り直す場合は、バックアップを取ったうえでテーブルと schema_migrations を手動で削除すること
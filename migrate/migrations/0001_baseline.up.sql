@@ -0,0 +1,163 @@
+-- 既存の全テーブル (既に作成済みのテーブルはそのまま残す)
+-- 文字数の上限は usecase/validation.go の検証と合わせる
+
+CREATE TABLE IF NOT EXISTS users (
+    user_id         VARCHAR(255)  NOT NULL,
+    name            VARCHAR(50)   NOT NULL,
+    bio             VARCHAR(160)  NULL,
+    profile_img_url VARCHAR(2048) NULL,
+    header_img_url  VARCHAR(2048) NULL,
+    location        VARCHAR(100)  NULL,
+    birthday        DATETIME      NULL,
+    PRIMARY KEY (user_id),
+    FULLTEXT INDEX ft_users_name_bio (name, bio) WITH PARSER ngram
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
+
+CREATE TABLE IF NOT EXISTS posts (
+    post_id        CHAR(26)      NOT NULL,
+    user_id        VARCHAR(255)  NOT NULL,
+    content        VARCHAR(280)  NOT NULL,
+    img_url        VARCHAR(2048) NULL,
+    created_at     DATETIME      NOT NULL,
+    edited_at      DATETIME      NULL,
+    deleted_at     DATETIME      NULL,
+    parent_post_id CHAR(26)      NULL,
+    is_bad         BOOLEAN       NOT NULL DEFAULT FALSE,
+    PRIMARY KEY (post_id),
+    INDEX idx_posts_user_created (user_id, created_at),
+    INDEX idx_posts_parent (parent_post_id),
+    INDEX idx_posts_created (created_at),
+    FULLTEXT INDEX ft_posts_content (content) WITH PARSER ngram,
+    CONSTRAINT fk_posts_user FOREIGN KEY (user_id) REFERENCES users (user_id) ON DELETE CASCADE,
+    CONSTRAINT fk_posts_parent FOREIGN KEY (parent_post_id) REFERENCES posts (post_id)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
+
+CREATE TABLE IF NOT EXISTS likes (
+    user_id    VARCHAR(255) NOT NULL,
+    post_id    CHAR(26)     NOT NULL,
+    created_at DATETIME     NOT NULL,
+    PRIMARY KEY (user_id, post_id),
+    INDEX idx_likes_post (post_id),
+    CONSTRAINT fk_likes_user FOREIGN KEY (user_id) REFERENCES users (user_id) ON DELETE CASCADE,
+    CONSTRAINT fk_likes_post FOREIGN KEY (post_id) REFERENCES posts (post_id) ON DELETE CASCADE
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
+
+CREATE TABLE IF NOT EXISTS followers (
+    user_id           VARCHAR(255) NOT NULL,
+    following_user_id VARCHAR(255) NOT NULL,
+    created_at        DATETIME     NOT NULL,
+    PRIMARY KEY (user_id, following_user_id),
+    INDEX idx_followers_following (following_user_id),
+    CONSTRAINT fk_followers_user FOREIGN KEY (user_id) REFERENCES users (user_id) ON DELETE CASCADE,
+    CONSTRAINT fk_followers_following FOREIGN KEY (following_user_id) REFERENCES users (user_id) ON DELETE CASCADE
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
+
+CREATE TABLE IF NOT EXISTS user_restrictions (
+    restriction_id CHAR(26)     NOT NULL,
+    user_id        VARCHAR(255) NOT NULL,
+    kind           VARCHAR(20)  NOT NULL,
+    reason         VARCHAR(500) NOT NULL,
+    created_at     DATETIME     NOT NULL,
+    expires_at     DATETIME     NULL,
+    lifted_at      DATETIME     NULL,
+    PRIMARY KEY (restriction_id),
+    INDEX idx_user_restrictions_user_kind (user_id, kind),
+    CONSTRAINT fk_user_restrictions_user FOREIGN KEY (user_id) REFERENCES users (user_id) ON DELETE CASCADE
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
+
+CREATE TABLE IF NOT EXISTS user_embeddings (
+    user_id     VARCHAR(255) NOT NULL,
+    embedding   MEDIUMTEXT   NOT NULL,
+    source_hash CHAR(64)     NOT NULL,
+    updated_at  DATETIME     NOT NULL,
+    PRIMARY KEY (user_id),
+    CONSTRAINT fk_user_embeddings_user FOREIGN KEY (user_id) REFERENCES users (user_id) ON DELETE CASCADE
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
+
+CREATE TABLE IF NOT EXISTS post_embeddings (
+    post_id    CHAR(26)   NOT NULL,
+    embedding  MEDIUMTEXT NOT NULL,
+    updated_at DATETIME   NOT NULL,
+    PRIMARY KEY (post_id),
+    CONSTRAINT fk_post_embeddings_post FOREIGN KEY (post_id) REFERENCES posts (post_id) ON DELETE CASCADE
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
+
+CREATE TABLE IF NOT EXISTS generation_logs (
+    generation_id    CHAR(26)     NOT NULL,
+    target_id        VARCHAR(255) NOT NULL,
+    template_id      VARCHAR(64)  NOT NULL,
+    template_version INT          NOT NULL,
+    locale           VARCHAR(8)   NOT NULL,
+    created_at       DATETIME     NOT NULL,
+    PRIMARY KEY (generation_id),
+    INDEX idx_generation_logs_target (target_id)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
+
+CREATE TABLE IF NOT EXISTS ai_usage (
+    usage_id        CHAR(26)     NOT NULL,
+    user_id         VARCHAR(255) NULL,
+    operation       VARCHAR(64)  NOT NULL,
+    prompt_tokens   INT          NOT NULL DEFAULT 0,
+    response_tokens INT          NOT NULL DEFAULT 0,
+    total_tokens    INT          NOT NULL DEFAULT 0,
+    created_at      DATETIME     NOT NULL,
+    PRIMARY KEY (usage_id),
+    INDEX idx_ai_usage_user_created (user_id, created_at),
+    INDEX idx_ai_usage_created (created_at),
+    CONSTRAINT fk_ai_usage_user FOREIGN KEY (user_id) REFERENCES users (user_id) ON DELETE CASCADE
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
+
+CREATE TABLE IF NOT EXISTS ai_quota_overrides (
+    user_id          VARCHAR(255) NOT NULL,
+    requests_per_day INT          NOT NULL DEFAULT 0,
+    tokens_per_day   INT          NOT NULL DEFAULT 0,
+    reason           VARCHAR(500) NOT NULL,
+    updated_at       DATETIME     NOT NULL,
+    PRIMARY KEY (user_id),
+    CONSTRAINT fk_ai_quota_overrides_user FOREIGN KEY (user_id) REFERENCES users (user_id) ON DELETE CASCADE
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
+
+CREATE TABLE IF NOT EXISTS ai_cache (
+    cache_key   CHAR(64)     NOT NULL,
+    target_id   VARCHAR(255) NOT NULL,
+    template_id VARCHAR(64)  NOT NULL,
+    response    TEXT         NOT NULL,
+    created_at  DATETIME     NOT NULL,
+    expires_at  DATETIME     NOT NULL,
+    PRIMARY KEY (cache_key),
+    INDEX idx_ai_cache_target (target_id)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
+
+CREATE TABLE IF NOT EXISTS image_analyses (
+    kind        VARCHAR(16)   NOT NULL,
+    target_id   VARCHAR(255)  NOT NULL,
+    img_url     TEXT          NOT NULL,
+    status      VARCHAR(16)   NOT NULL,
+    alt_text    TEXT          NULL,
+    is_unsafe   BOOLEAN       NOT NULL DEFAULT FALSE,
+    categories  VARCHAR(255)  NOT NULL DEFAULT '',
+    error       TEXT          NULL,
+    updated_at  DATETIME      NOT NULL,
+    analyzed_at DATETIME      NULL,
+    PRIMARY KEY (kind, target_id)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
+
+CREATE TABLE IF NOT EXISTS saved_searches (
+    search_id    CHAR(26)     NOT NULL,
+    user_id      VARCHAR(255) NOT NULL,
+    query        VARCHAR(500) NOT NULL,
+    created_at   DATETIME     NOT NULL,
+    last_seen_at DATETIME     NULL,
+    PRIMARY KEY (search_id),
+    UNIQUE INDEX uq_saved_searches_user_query (user_id, query),
+    CONSTRAINT fk_saved_searches_user FOREIGN KEY (user_id) REFERENCES users (user_id) ON DELETE CASCADE
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
+
+CREATE TABLE IF NOT EXISTS search_history (
+    user_id     VARCHAR(255) NOT NULL,
+    query       VARCHAR(500) NOT NULL,
+    searched_at DATETIME     NOT NULL,
+    PRIMARY KEY (user_id, query),
+    INDEX idx_search_history_user_searched (user_id, searched_at),
+    CONSTRAINT fk_search_history_user FOREIGN KEY (user_id) REFERENCES users (user_id) ON DELETE CASCADE
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
@@ -0,0 +1,4 @@
+-- 取り消しても何もしない
+-- 追加した制約とインデックスは 0001_baseline で作った DB にも元からあるため、ここでは削除しない
+-- (削除すると重複したいいね・フォローを再び許してしまう)
+DO 0;
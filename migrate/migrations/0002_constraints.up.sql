@@ -0,0 +1,334 @@
+-- 0001_baseline より前から存在する DB の users・posts・likes・followers を、baseline と同じスキーマに揃える
+-- (ストレージエンジンと文字コード、カラムの型、主キー、インデックス、外部キー) とともに、
+-- baseline と同じ一意制約と全文検索インデックスを追加する
+-- 既に揃っているものは変更しない (新しく作った DB では何もしない)
+-- MySQL の ALTER TABLE には IF NOT EXISTS が無いため、information_schema を見て実行する文を決める
+-- baseline の上限より長い値や NULL の created_at がある場合はカラムの変更が失敗するので、手動で直してから再実行する
+
+-- テーブル: 外部キーのため InnoDB・utf8mb4 にする
+SET @ddl = IF(
+    (SELECT COUNT(*) FROM information_schema.tables
+     WHERE table_schema = DATABASE() AND table_name = 'users' AND engine = 'InnoDB' AND table_collation LIKE 'utf8mb4%') = 0,
+    'ALTER TABLE users ENGINE = InnoDB, CONVERT TO CHARACTER SET utf8mb4',
+    'DO 0');
+PREPARE ddl_statement FROM @ddl;
+EXECUTE ddl_statement;
+DEALLOCATE PREPARE ddl_statement;
+
+SET @ddl = IF(
+    (SELECT COUNT(*) FROM information_schema.tables
+     WHERE table_schema = DATABASE() AND table_name = 'posts' AND engine = 'InnoDB' AND table_collation LIKE 'utf8mb4%') = 0,
+    'ALTER TABLE posts ENGINE = InnoDB, CONVERT TO CHARACTER SET utf8mb4',
+    'DO 0');
+PREPARE ddl_statement FROM @ddl;
+EXECUTE ddl_statement;
+DEALLOCATE PREPARE ddl_statement;
+
+SET @ddl = IF(
+    (SELECT COUNT(*) FROM information_schema.tables
+     WHERE table_schema = DATABASE() AND table_name = 'likes' AND engine = 'InnoDB' AND table_collation LIKE 'utf8mb4%') = 0,
+    'ALTER TABLE likes ENGINE = InnoDB, CONVERT TO CHARACTER SET utf8mb4',
+    'DO 0');
+PREPARE ddl_statement FROM @ddl;
+EXECUTE ddl_statement;
+DEALLOCATE PREPARE ddl_statement;
+
+SET @ddl = IF(
+    (SELECT COUNT(*) FROM information_schema.tables
+     WHERE table_schema = DATABASE() AND table_name = 'followers' AND engine = 'InnoDB' AND table_collation LIKE 'utf8mb4%') = 0,
+    'ALTER TABLE followers ENGINE = InnoDB, CONVERT TO CHARACTER SET utf8mb4',
+    'DO 0');
+PREPARE ddl_statement FROM @ddl;
+EXECUTE ddl_statement;
+DEALLOCATE PREPARE ddl_statement;
+
+-- カラム: 無いカラムを追加し、型と NULL 可否を baseline に揃える
+-- 既存の外部キーが付いたカラムも変更できるよう、この間だけ外部キーの検査を止める
+SET FOREIGN_KEY_CHECKS = 0;
+
+SET @ddl = IF(
+    (SELECT COUNT(*) FROM information_schema.columns
+     WHERE table_schema = DATABASE() AND table_name = 'posts' AND column_name = 'edited_at') = 0,
+    'ALTER TABLE posts ADD COLUMN edited_at DATETIME NULL AFTER created_at',
+    'DO 0');
+PREPARE ddl_statement FROM @ddl;
+EXECUTE ddl_statement;
+DEALLOCATE PREPARE ddl_statement;
+
+UPDATE posts SET is_bad = FALSE WHERE is_bad IS NULL;
+
+SET @ddl = IF(
+    (SELECT COUNT(*) FROM information_schema.columns
+     WHERE table_schema = DATABASE() AND table_name = 'users'
+     AND (column_name, column_type, is_nullable, COALESCE(column_default, '')) IN (
+         ('user_id', 'varchar(255)', 'NO', ''),
+         ('name', 'varchar(50)', 'NO', ''),
+         ('bio', 'varchar(160)', 'YES', ''),
+         ('profile_img_url', 'varchar(2048)', 'YES', ''),
+         ('header_img_url', 'varchar(2048)', 'YES', ''),
+         ('location', 'varchar(100)', 'YES', ''),
+         ('birthday', 'datetime', 'YES', ''))) < 7,
+    'ALTER TABLE users MODIFY COLUMN user_id VARCHAR(255) NOT NULL, MODIFY COLUMN name VARCHAR(50) NOT NULL, MODIFY COLUMN bio VARCHAR(160) NULL, MODIFY COLUMN profile_img_url VARCHAR(2048) NULL, MODIFY COLUMN header_img_url VARCHAR(2048) NULL, MODIFY COLUMN location VARCHAR(100) NULL, MODIFY COLUMN birthday DATETIME NULL',
+    'DO 0');
+PREPARE ddl_statement FROM @ddl;
+EXECUTE ddl_statement;
+DEALLOCATE PREPARE ddl_statement;
+
+SET @ddl = IF(
+    (SELECT COUNT(*) FROM information_schema.columns
+     WHERE table_schema = DATABASE() AND table_name = 'posts'
+     AND (column_name, column_type, is_nullable, COALESCE(column_default, '')) IN (
+         ('post_id', 'char(26)', 'NO', ''),
+         ('user_id', 'varchar(255)', 'NO', ''),
+         ('content', 'varchar(280)', 'NO', ''),
+         ('img_url', 'varchar(2048)', 'YES', ''),
+         ('created_at', 'datetime', 'NO', ''),
+         ('edited_at', 'datetime', 'YES', ''),
+         ('deleted_at', 'datetime', 'YES', ''),
+         ('parent_post_id', 'char(26)', 'YES', ''),
+         ('is_bad', 'tinyint(1)', 'NO', '0'))) < 9,
+    'ALTER TABLE posts MODIFY COLUMN post_id CHAR(26) NOT NULL, MODIFY COLUMN user_id VARCHAR(255) NOT NULL, MODIFY COLUMN content VARCHAR(280) NOT NULL, MODIFY COLUMN img_url VARCHAR(2048) NULL, MODIFY COLUMN created_at DATETIME NOT NULL, MODIFY COLUMN edited_at DATETIME NULL, MODIFY COLUMN deleted_at DATETIME NULL, MODIFY COLUMN parent_post_id CHAR(26) NULL, MODIFY COLUMN is_bad BOOLEAN NOT NULL DEFAULT FALSE',
+    'DO 0');
+PREPARE ddl_statement FROM @ddl;
+EXECUTE ddl_statement;
+DEALLOCATE PREPARE ddl_statement;
+
+SET @ddl = IF(
+    (SELECT COUNT(*) FROM information_schema.columns
+     WHERE table_schema = DATABASE() AND table_name = 'likes'
+     AND (column_name, column_type, is_nullable, COALESCE(column_default, '')) IN (
+         ('user_id', 'varchar(255)', 'NO', ''),
+         ('post_id', 'char(26)', 'NO', ''),
+         ('created_at', 'datetime', 'NO', ''))) < 3,
+    'ALTER TABLE likes MODIFY COLUMN user_id VARCHAR(255) NOT NULL, MODIFY COLUMN post_id CHAR(26) NOT NULL, MODIFY COLUMN created_at DATETIME NOT NULL',
+    'DO 0');
+PREPARE ddl_statement FROM @ddl;
+EXECUTE ddl_statement;
+DEALLOCATE PREPARE ddl_statement;
+
+SET @ddl = IF(
+    (SELECT COUNT(*) FROM information_schema.columns
+     WHERE table_schema = DATABASE() AND table_name = 'followers'
+     AND (column_name, column_type, is_nullable, COALESCE(column_default, '')) IN (
+         ('user_id', 'varchar(255)', 'NO', ''),
+         ('following_user_id', 'varchar(255)', 'NO', ''),
+         ('created_at', 'datetime', 'NO', ''))) < 3,
+    'ALTER TABLE followers MODIFY COLUMN user_id VARCHAR(255) NOT NULL, MODIFY COLUMN following_user_id VARCHAR(255) NOT NULL, MODIFY COLUMN created_at DATETIME NOT NULL',
+    'DO 0');
+PREPARE ddl_statement FROM @ddl;
+EXECUTE ddl_statement;
+DEALLOCATE PREPARE ddl_statement;
+
+SET FOREIGN_KEY_CHECKS = 1;
+
+-- users・posts: 主キーが無ければ追加する
+SET @ddl = IF(
+    (SELECT COUNT(*) FROM information_schema.table_constraints
+     WHERE table_schema = DATABASE() AND table_name = 'users' AND constraint_type = 'PRIMARY KEY') = 0,
+    'ALTER TABLE users ADD PRIMARY KEY (user_id)',
+    'DO 0');
+PREPARE ddl_statement FROM @ddl;
+EXECUTE ddl_statement;
+DEALLOCATE PREPARE ddl_statement;
+
+SET @ddl = IF(
+    (SELECT COUNT(*) FROM information_schema.table_constraints
+     WHERE table_schema = DATABASE() AND table_name = 'posts' AND constraint_type = 'PRIMARY KEY') = 0,
+    'ALTER TABLE posts ADD PRIMARY KEY (post_id)',
+    'DO 0');
+PREPARE ddl_statement FROM @ddl;
+EXECUTE ddl_statement;
+DEALLOCATE PREPARE ddl_statement;
+
+-- likes: 重複したいいねを最も古い1件にまとめてから主キーを追加する
+CREATE TEMPORARY TABLE likes_duplicates AS
+    SELECT user_id, post_id, MIN(created_at) AS created_at
+    FROM likes
+    GROUP BY user_id, post_id
+    HAVING COUNT(*) > 1;
+START TRANSACTION;
+DELETE l FROM likes l JOIN likes_duplicates d ON d.user_id = l.user_id AND d.post_id = l.post_id;
+INSERT INTO likes (user_id, post_id, created_at) SELECT user_id, post_id, created_at FROM likes_duplicates;
+COMMIT;
+DROP TEMPORARY TABLE likes_duplicates;
+
+SET @ddl = IF(
+    (SELECT COUNT(*) FROM information_schema.table_constraints
+     WHERE table_schema = DATABASE() AND table_name = 'likes' AND constraint_type = 'PRIMARY KEY') = 0,
+    'ALTER TABLE likes ADD PRIMARY KEY (user_id, post_id)',
+    'DO 0');
+PREPARE ddl_statement FROM @ddl;
+EXECUTE ddl_statement;
+DEALLOCATE PREPARE ddl_statement;
+
+-- followers: 重複したフォローを最も古い1件にまとめてから主キーを追加する
+CREATE TEMPORARY TABLE followers_duplicates AS
+    SELECT user_id, following_user_id, MIN(created_at) AS created_at
+    FROM followers
+    GROUP BY user_id, following_user_id
+    HAVING COUNT(*) > 1;
+START TRANSACTION;
+DELETE f FROM followers f JOIN followers_duplicates d ON d.user_id = f.user_id AND d.following_user_id = f.following_user_id;
+INSERT INTO followers (user_id, following_user_id, created_at) SELECT user_id, following_user_id, created_at FROM followers_duplicates;
+COMMIT;
+DROP TEMPORARY TABLE followers_duplicates;
+
+SET @ddl = IF(
+    (SELECT COUNT(*) FROM information_schema.table_constraints
+     WHERE table_schema = DATABASE() AND table_name = 'followers' AND constraint_type = 'PRIMARY KEY') = 0,
+    'ALTER TABLE followers ADD PRIMARY KEY (user_id, following_user_id)',
+    'DO 0');
+PREPARE ddl_statement FROM @ddl;
+EXECUTE ddl_statement;
+DEALLOCATE PREPARE ddl_statement;
+
+-- saved_searches: 同じクエリの保存を最も古い1件にまとめてから一意制約を追加する
+DELETE s FROM saved_searches s
+    JOIN saved_searches kept ON kept.user_id = s.user_id AND kept.query = s.query AND kept.search_id < s.search_id;
+
+SET @ddl = IF(
+    (SELECT COUNT(*) FROM information_schema.statistics
+     WHERE table_schema = DATABASE() AND table_name = 'saved_searches' AND index_name = 'uq_saved_searches_user_query') = 0,
+    'ALTER TABLE saved_searches ADD UNIQUE INDEX uq_saved_searches_user_query (user_id, query)',
+    'DO 0');
+PREPARE ddl_statement FROM @ddl;
+EXECUTE ddl_statement;
+DEALLOCATE PREPARE ddl_statement;
+
+-- 一覧の取得に使うインデックス (外部キーより先に作り、外部キー用の別のインデックスが作られないようにする)
+SET @ddl = IF(
+    (SELECT COUNT(*) FROM information_schema.statistics
+     WHERE table_schema = DATABASE() AND table_name = 'posts' AND index_name = 'idx_posts_user_created') = 0,
+    'ALTER TABLE posts ADD INDEX idx_posts_user_created (user_id, created_at)',
+    'DO 0');
+PREPARE ddl_statement FROM @ddl;
+EXECUTE ddl_statement;
+DEALLOCATE PREPARE ddl_statement;
+
+SET @ddl = IF(
+    (SELECT COUNT(*) FROM information_schema.statistics
+     WHERE table_schema = DATABASE() AND table_name = 'posts' AND index_name = 'idx_posts_parent') = 0,
+    'ALTER TABLE posts ADD INDEX idx_posts_parent (parent_post_id)',
+    'DO 0');
+PREPARE ddl_statement FROM @ddl;
+EXECUTE ddl_statement;
+DEALLOCATE PREPARE ddl_statement;
+
+SET @ddl = IF(
+    (SELECT COUNT(*) FROM information_schema.statistics
+     WHERE table_schema = DATABASE() AND table_name = 'posts' AND index_name = 'idx_posts_created') = 0,
+    'ALTER TABLE posts ADD INDEX idx_posts_created (created_at)',
+    'DO 0');
+PREPARE ddl_statement FROM @ddl;
+EXECUTE ddl_statement;
+DEALLOCATE PREPARE ddl_statement;
+
+SET @ddl = IF(
+    (SELECT COUNT(*) FROM information_schema.statistics
+     WHERE table_schema = DATABASE() AND table_name = 'likes' AND index_name = 'idx_likes_post') = 0,
+    'ALTER TABLE likes ADD INDEX idx_likes_post (post_id)',
+    'DO 0');
+PREPARE ddl_statement FROM @ddl;
+EXECUTE ddl_statement;
+DEALLOCATE PREPARE ddl_statement;
+
+SET @ddl = IF(
+    (SELECT COUNT(*) FROM information_schema.statistics
+     WHERE table_schema = DATABASE() AND table_name = 'followers' AND index_name = 'idx_followers_following') = 0,
+    'ALTER TABLE followers ADD INDEX idx_followers_following (following_user_id)',
+    'DO 0');
+PREPARE ddl_statement FROM @ddl;
+EXECUTE ddl_statement;
+DEALLOCATE PREPARE ddl_statement;
+
+-- 外部キーを追加する前に、存在しないユーザー・投稿を参照している行を片付ける
+-- 親の投稿が無いリプライは親を外し、ユーザーが存在しない投稿・いいね・フォローは削除する
+UPDATE posts c
+    LEFT JOIN posts p ON p.post_id = c.parent_post_id
+    LEFT JOIN users u ON u.user_id = p.user_id
+    SET c.parent_post_id = NULL
+    WHERE c.parent_post_id IS NOT NULL AND u.user_id IS NULL;
+DELETE l FROM likes l
+    LEFT JOIN users u ON u.user_id = l.user_id
+    LEFT JOIN posts p ON p.post_id = l.post_id
+    LEFT JOIN users a ON a.user_id = p.user_id
+    WHERE u.user_id IS NULL OR a.user_id IS NULL;
+DELETE p FROM posts p LEFT JOIN users u ON u.user_id = p.user_id WHERE u.user_id IS NULL;
+DELETE f FROM followers f
+    LEFT JOIN users u ON u.user_id = f.user_id
+    LEFT JOIN users g ON g.user_id = f.following_user_id
+    WHERE u.user_id IS NULL OR g.user_id IS NULL;
+
+-- 外部キー: 同じカラムに既に外部キーがあれば追加しない
+SET @ddl = IF(
+    (SELECT COUNT(*) FROM information_schema.key_column_usage
+     WHERE table_schema = DATABASE() AND table_name = 'posts' AND column_name = 'user_id' AND referenced_table_name = 'users') = 0,
+    'ALTER TABLE posts ADD CONSTRAINT fk_posts_user FOREIGN KEY (user_id) REFERENCES users (user_id) ON DELETE CASCADE',
+    'DO 0');
+PREPARE ddl_statement FROM @ddl;
+EXECUTE ddl_statement;
+DEALLOCATE PREPARE ddl_statement;
+
+SET @ddl = IF(
+    (SELECT COUNT(*) FROM information_schema.key_column_usage
+     WHERE table_schema = DATABASE() AND table_name = 'posts' AND column_name = 'parent_post_id' AND referenced_table_name = 'posts') = 0,
+    'ALTER TABLE posts ADD CONSTRAINT fk_posts_parent FOREIGN KEY (parent_post_id) REFERENCES posts (post_id)',
+    'DO 0');
+PREPARE ddl_statement FROM @ddl;
+EXECUTE ddl_statement;
+DEALLOCATE PREPARE ddl_statement;
+
+SET @ddl = IF(
+    (SELECT COUNT(*) FROM information_schema.key_column_usage
+     WHERE table_schema = DATABASE() AND table_name = 'likes' AND column_name = 'user_id' AND referenced_table_name = 'users') = 0,
+    'ALTER TABLE likes ADD CONSTRAINT fk_likes_user FOREIGN KEY (user_id) REFERENCES users (user_id) ON DELETE CASCADE',
+    'DO 0');
+PREPARE ddl_statement FROM @ddl;
+EXECUTE ddl_statement;
+DEALLOCATE PREPARE ddl_statement;
+
+SET @ddl = IF(
+    (SELECT COUNT(*) FROM information_schema.key_column_usage
+     WHERE table_schema = DATABASE() AND table_name = 'likes' AND column_name = 'post_id' AND referenced_table_name = 'posts') = 0,
+    'ALTER TABLE likes ADD CONSTRAINT fk_likes_post FOREIGN KEY (post_id) REFERENCES posts (post_id) ON DELETE CASCADE',
+    'DO 0');
+PREPARE ddl_statement FROM @ddl;
+EXECUTE ddl_statement;
+DEALLOCATE PREPARE ddl_statement;
+
+SET @ddl = IF(
+    (SELECT COUNT(*) FROM information_schema.key_column_usage
+     WHERE table_schema = DATABASE() AND table_name = 'followers' AND column_name = 'user_id' AND referenced_table_name = 'users') = 0,
+    'ALTER TABLE followers ADD CONSTRAINT fk_followers_user FOREIGN KEY (user_id) REFERENCES users (user_id) ON DELETE CASCADE',
+    'DO 0');
+PREPARE ddl_statement FROM @ddl;
+EXECUTE ddl_statement;
+DEALLOCATE PREPARE ddl_statement;
+
+SET @ddl = IF(
+    (SELECT COUNT(*) FROM information_schema.key_column_usage
+     WHERE table_schema = DATABASE() AND table_name = 'followers' AND column_name = 'following_user_id' AND referenced_table_name = 'users') = 0,
+    'ALTER TABLE followers ADD CONSTRAINT fk_followers_following FOREIGN KEY (following_user_id) REFERENCES users (user_id) ON DELETE CASCADE',
+    'DO 0');
+PREPARE ddl_statement FROM @ddl;
+EXECUTE ddl_statement;
+DEALLOCATE PREPARE ddl_statement;
+
+-- ユーザー検索・投稿検索の全文検索インデックス (ngram)
+SET @ddl = IF(
+    (SELECT COUNT(*) FROM information_schema.statistics
+     WHERE table_schema = DATABASE() AND table_name = 'users' AND index_name = 'ft_users_name_bio') = 0,
+    'ALTER TABLE users ADD FULLTEXT INDEX ft_users_name_bio (name, bio) WITH PARSER ngram',
+    'DO 0');
+PREPARE ddl_statement FROM @ddl;
+EXECUTE ddl_statement;
+DEALLOCATE PREPARE ddl_statement;
+
+SET @ddl = IF(
+    (SELECT COUNT(*) FROM information_schema.statistics
+     WHERE table_schema = DATABASE() AND table_name = 'posts' AND index_name = 'ft_posts_content') = 0,
+    'ALTER TABLE posts ADD FULLTEXT INDEX ft_posts_content (content) WITH PARSER ngram',
+    'DO 0');
+PREPARE ddl_statement FROM @ddl;
+EXECUTE ddl_statement;
+DEALLOCATE PREPARE ddl_statement;
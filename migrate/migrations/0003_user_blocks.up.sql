@@ -0,0 +1,11 @@
+-- ユーザーのブロック (おすすめから双方を除くのに使う)
+
+CREATE TABLE IF NOT EXISTS user_blocks (
+    user_id         VARCHAR(255) NOT NULL,
+    blocked_user_id VARCHAR(255) NOT NULL,
+    created_at      DATETIME     NOT NULL,
+    PRIMARY KEY (user_id, blocked_user_id),
+    INDEX idx_user_blocks_blocked (blocked_user_id),
+    CONSTRAINT fk_user_blocks_user FOREIGN KEY (user_id) REFERENCES users (user_id) ON DELETE CASCADE,
+    CONSTRAINT fk_user_blocks_blocked FOREIGN KEY (blocked_user_id) REFERENCES users (user_id) ON DELETE CASCADE
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
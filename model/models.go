@@ -39,3 +39,262 @@ type Follow struct {
 	UserID          string `json:"user_id"`
 	FollowingUserID string `json:"following_user_id"`
 }
+
+// Block ブロックモデル
+type Block struct {
+	UserID        string `json:"user_id"`
+	BlockedUserID string `json:"blocked_user_id"`
+}
+
+// 制限の種類
+const (
+	RestrictionSuspend   = "suspend"    // アカウント凍結
+	RestrictionShadowBan = "shadow_ban" // シャドウバン
+)
+
+// Restriction ユーザー制限 (凍結・シャドウバン) モデル
+type Restriction struct {
+	RestrictionID string     `json:"restriction_id"`
+	UserID        string     `json:"user_id"`
+	Kind          string     `json:"kind"`
+	Reason        string     `json:"reason"`
+	CreatedAt     time.Time  `json:"created_at"`
+	ExpiresAt     *time.Time `json:"expires_at,omitempty"`
+	LiftedAt      *time.Time `json:"lifted_at,omitempty"`
+}
+
+// UserEmbedding ユーザーの埋め込みベクトルモデル
+type UserEmbedding struct {
+	UserID     string    `json:"user_id"`
+	Vector     []float32 `json:"vector"`
+	SourceHash string    `json:"source_hash"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// PostEmbedding 投稿の埋め込みベクトルモデル
+type PostEmbedding struct {
+	PostID    string    `json:"post_id"`
+	Vector    []float32 `json:"vector"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// UserRecommendation おすすめユーザーモデル
+type UserRecommendation struct {
+	User          User    `json:"user"`
+	Score         float64 `json:"score"`
+	Similarity    float64 `json:"similarity,omitempty"`
+	MutualFollows int     `json:"mutual_follows"`
+	Reason        string  `json:"reason"`
+}
+
+// RecommendationPage ページ分割されたおすすめユーザー一覧モデル
+type RecommendationPage struct {
+	Recommendations []UserRecommendation `json:"recommendations"`
+	NextOffset      *int                 `json:"next_offset,omitempty"`
+}
+
+// GenerationLog AI生成に使ったプロンプトテンプレートの記録モデル
+type GenerationLog struct {
+	GenerationID    string    `json:"generation_id"`
+	TargetID        string    `json:"target_id"`
+	TemplateID      string    `json:"template_id"`
+	TemplateVersion int       `json:"template_version"`
+	Locale          string    `json:"locale"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// GenerationUsage AI生成のトークン使用量モデル
+type GenerationUsage struct {
+	PromptTokens   int `json:"prompt_tokens"`
+	ResponseTokens int `json:"response_tokens"`
+	TotalTokens    int `json:"total_tokens"`
+}
+
+// AIUsage AI呼び出し1回ごとの使用量の記録モデル
+type AIUsage struct {
+	UsageID        string    `json:"usage_id"`
+	UserID         *string   `json:"user_id,omitempty"` // 投稿検査などユーザーに紐づかない呼び出しは nil
+	Operation      string    `json:"operation"`
+	PromptTokens   int       `json:"prompt_tokens"`
+	ResponseTokens int       `json:"response_tokens"`
+	TotalTokens    int       `json:"total_tokens"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// UsageTotals 期間内のAI使用量の合計モデル
+type UsageTotals struct {
+	Requests       int `json:"requests"`
+	PromptTokens   int `json:"prompt_tokens"`
+	ResponseTokens int `json:"response_tokens"`
+	TotalTokens    int `json:"total_tokens"`
+}
+
+// QuotaLimits AI使用量の上限モデル (0 は無制限)
+type QuotaLimits struct {
+	RequestsPerMinute int `json:"requests_per_minute"`
+	RequestsPerDay    int `json:"requests_per_day"`
+	TokensPerDay      int `json:"tokens_per_day"`
+}
+
+// QuotaOverride 管理者が設定したユーザーごとのAI使用量の上限モデル
+type QuotaOverride struct {
+	UserID         string    `json:"user_id"`
+	RequestsPerDay int       `json:"requests_per_day"`
+	TokensPerDay   int       `json:"tokens_per_day"`
+	Reason         string    `json:"reason"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// UsageSummary ユーザーの当日のAI使用量と上限モデル
+type UsageSummary struct {
+	UserID           string      `json:"user_id"`
+	Date             string      `json:"date"` // UTC の日付 (YYYY-MM-DD)
+	Usage            UsageTotals `json:"usage"`
+	Limits           QuotaLimits `json:"limits"`
+	Overridden       bool        `json:"overridden"`
+	EstimatedCostUSD float64     `json:"estimated_cost_usd"`
+	ResetsAt         time.Time   `json:"resets_at"`
+}
+
+// CachedGeneration 永続化したAI生成結果のキャッシュモデル
+type CachedGeneration struct {
+	CacheKey   string    `json:"cache_key"`
+	TargetID   string    `json:"target_id"`
+	TemplateID string    `json:"template_id"`
+	Response   string    `json:"response"`
+	CreatedAt  time.Time `json:"created_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// PostTranslation 投稿の翻訳モデル
+type PostTranslation struct {
+	PostID         string `json:"post_id"`
+	SourceLanguage string `json:"source_language"`
+	TargetLanguage string `json:"target_language"`
+	Content        string `json:"content"`
+	Translated     bool   `json:"translated"` // 元の言語と同じで翻訳しなかった場合は false
+}
+
+// Summary AIによる投稿の要約
+type Summary struct {
+	Summary         string   `json:"summary"`
+	CitedPostIDs    []string `json:"cited_post_ids"`   // 要約の根拠として引用された投稿ID
+	PostCount       int      `json:"post_count"`       // 対象の投稿数
+	SummarizedCount int      `json:"summarized_count"` // トークン数の上限に収まり要約に使った投稿数
+}
+
+// ThreadSummary 返信でつながった会話の要約
+type ThreadSummary struct {
+	PostID     string `json:"post_id"`
+	RootPostID string `json:"root_post_id"`
+	Summary
+}
+
+// TimelineDigest タイムラインで見逃した投稿の要約
+type TimelineDigest struct {
+	UserID string    `json:"user_id"`
+	Since  time.Time `json:"since"`
+	Summary
+}
+
+// 画像解析の対象の種類
+const (
+	ImageKindPost    = "post"    // 投稿に添付された画像 (posts.img_url)
+	ImageKindProfile = "profile" // プロフィール画像 (users.profile_img_url)
+)
+
+// 画像解析の状態
+const (
+	ImageAnalysisPending = "pending"
+	ImageAnalysisDone    = "done"
+	ImageAnalysisFailed  = "failed"
+)
+
+// ImageAnalysis AIによる画像の代替テキストの提案と安全性の判定
+type ImageAnalysis struct {
+	Kind       string     `json:"kind"`
+	TargetID   string     `json:"target_id"` // 投稿IDまたはユーザーID
+	ImgURL     string     `json:"img_url"`
+	Status     string     `json:"status"`
+	AltText    *string    `json:"alt_text,omitempty"`
+	IsUnsafe   bool       `json:"is_unsafe"`
+	Categories []string   `json:"categories"` // 不適切と判定した理由の分類 (violence, sexual など)
+	Error      *string    `json:"error,omitempty"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	AnalyzedAt *time.Time `json:"analyzed_at,omitempty"`
+}
+
+// PostSearchResult 意味検索の結果の投稿と順位付けのスコア
+type PostSearchResult struct {
+	Post
+	Score        float64 `json:"score"`
+	Similarity   float64 `json:"similarity"`    // クエリとのコサイン類似度
+	KeywordScore float64 `json:"keyword_score"` // クエリの語を含む割合 (キーワード検索を組み合わせた場合)
+}
+
+// Highlight スニペット内の検索語に一致した箇所 (文字単位の位置、End は含まない)
+type Highlight struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// PostHit 全文検索の結果の投稿
+type PostHit struct {
+	Post
+	Score      float64     `json:"score"` // 関連度に新しさによる倍率を掛けたもの
+	Snippet    string      `json:"snippet"`
+	Highlights []Highlight `json:"highlights"`
+}
+
+// UserHit 全文検索の結果のユーザー
+type UserHit struct {
+	User
+	Score      float64     `json:"score"`
+	Snippet    string      `json:"snippet"` // 名前または自己紹介の一致箇所の前後
+	Highlights []Highlight `json:"highlights"`
+}
+
+// UserSuggestion 入力補完の候補のユーザーと順位付けに使った値
+type UserSuggestion struct {
+	UserID              string  `json:"user_id"`
+	Name                string  `json:"name"`
+	ProfileImgURL       *string `json:"profile_img_url,omitempty"`
+	FollowerCount       int     `json:"follower_count"`
+	Following           bool    `json:"following"`             // 閲覧者がフォロー中か
+	FollowedByFollowing int     `json:"followed_by_following"` // 閲覧者がフォロー中のユーザーのうち、このユーザーをフォローしている数
+	Score               float64 `json:"score"`
+}
+
+// HashtagSuggestion 入力補完の候補のハッシュタグ (# を除く)
+type HashtagSuggestion struct {
+	Tag       string `json:"tag"`
+	PostCount int    `json:"post_count"` // 最近の投稿で使われた数
+}
+
+// Suggestions 入力補完の候補
+type Suggestions struct {
+	Users    []UserSuggestion    `json:"users"`
+	Hashtags []HashtagSuggestion `json:"hashtags"`
+}
+
+// SavedSearch ユーザーが保存した投稿検索のクエリ
+type SavedSearch struct {
+	SearchID   string     `json:"search_id"`
+	UserID     string     `json:"user_id"`
+	Query      string     `json:"query"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastSeenAt *time.Time `json:"last_seen_at"` // 新しい結果を最後に見た日時 (まだ見ていなければ null)
+}
+
+// SavedSearchTimeline 保存した検索に前回見たとき以降に一致した投稿
+type SavedSearchTimeline struct {
+	Search SavedSearch `json:"search"`
+	Since  *time.Time  `json:"since"` // この日時より後の投稿 (初めて見る場合は null で、最近の一致した投稿を返す)
+	Posts  []PostHit   `json:"posts"`
+}
+
+// SearchHistoryEntry 検索履歴の1件
+type SearchHistoryEntry struct {
+	Query      string    `json:"query"`
+	SearchedAt time.Time `json:"searched_at"`
+}
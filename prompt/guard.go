@@ -0,0 +1,95 @@
+package prompt
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Limits プロンプトに入れるユーザー入力の上限
+type Limits struct {
+	ContextTokenBudget int // 過去ツイートなどの文脈に使う推定トークン数の上限
+	MaxInstructionLen  int // instruction の最大文字数
+	MaxTempTextLen     int // temp_text の最大文字数
+}
+
+// injectionPatterns システムの指示を上書きしようとする典型的な表現
+var injectionPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)(ignore|disregard|forget|override)\s+(all\s+)?(the\s+)?(previous|prior|above|earlier|system)\s+(instructions?|prompts?|rules?)`),
+	regexp.MustCompile(`(?i)(system|developer)\s*prompt`),
+	regexp.MustCompile(`(?i)you\s+are\s+now\s+`),
+	regexp.MustCompile(`(?i)</?\s*(user_content|tweets?|posts?|thread|instruction|temp_text|system)\s*>`),
+	regexp.MustCompile(`(以前|前|上|これまで|先)の(指示|命令|ルール|プロンプト).{0,10}(無視|忘れ|従わな|破棄)`),
+	regexp.MustCompile(`(指示|命令|ルール|プロンプト)を(無視|忘れ|上書き)`),
+	regexp.MustCompile(`システム\s*プロンプト`),
+	regexp.MustCompile(`あなたは(今から|これから)`),
+}
+
+// DetectInjection システムの指示を上書きしようとする入力か判定
+func DetectInjection(text string) bool {
+	for _, pattern := range injectionPatterns {
+		if pattern.MatchString(text) {
+			return true
+		}
+	}
+	return false
+}
+
+// escape テンプレート関数: ユーザー入力が区切りタグを閉じたり開いたりできないよう山括弧を全角にする
+func escape(text string) string {
+	return strings.NewReplacer("<", "＜", ">", "＞").Replace(text)
+}
+
+// EstimateTokens テキストの推定トークン数 (CJK は1文字1トークン、それ以外は4文字1トークン)
+func EstimateTokens(text string) int {
+	cjk, other := 0, 0
+	for _, r := range text {
+		if unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul) {
+			cjk++
+		} else {
+			other++
+		}
+	}
+	return cjk + (other+3)/4
+}
+
+// SelectWithinBudget 新しい順に並んだテキストから、推定トークン数の合計が budget に収まるだけ選ぶ
+// 重複・空白のみ・指示の上書きを試みるテキストは除く
+func SelectWithinBudget(texts []string, budget int) []string {
+	seen := make(map[string]bool)
+	var selected []string
+	used := 0
+	for _, text := range texts {
+		normalized := strings.Join(strings.Fields(text), " ")
+		if normalized == "" || seen[normalized] || DetectInjection(text) {
+			continue
+		}
+		tokens := EstimateTokens(text)
+		if used+tokens > budget {
+			// 長すぎる投稿は飛ばし、より短い投稿で残りを埋める
+			continue
+		}
+		seen[normalized] = true
+		selected = append(selected, text)
+		used += tokens
+	}
+	return selected
+}
+
+// TruncateToBudget 推定トークン数が budget に収まるよう末尾を切り詰める
+func TruncateToBudget(text string, budget int) string {
+	if EstimateTokens(text) <= budget {
+		return text
+	}
+	runes := []rune(text)
+	for len(runes) > 0 && EstimateTokens(string(runes)) > budget {
+		runes = runes[:len(runes)*9/10]
+	}
+	return string(runes)
+}
+
+// RuneLen 文字数 (バイト数ではない) を返す
+func RuneLen(text string) int {
+	return utf8.RuneCountInString(text)
+}
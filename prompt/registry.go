@@ -0,0 +1,246 @@
+package prompt
+
+import (
+	"embed"
+	"fmt"
+	"hash/fnv"
+	"io/fs"
+	"log"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// テンプレートID
+const (
+	GenerateBio               = "generate_bio"
+	GenerateName              = "generate_name"
+	GenerateTweetContinuation = "generate_tweet_continuation"
+	CheckIsBad                = "check_isbad"
+	RefineName                = "refine_name"
+	RefineBio                 = "refine_bio"
+	TranslatePost             = "translate_post"
+	SummarizeThread           = "summarize_thread"
+	DigestTimeline            = "digest_timeline"
+	AnalyzeImage              = "analyze_image"
+	SuggestReplies            = "suggest_replies"
+)
+
+// DefaultLocale 指定したロケールのテンプレートが無い場合に使うロケール
+const DefaultLocale = "ja"
+
+//go:embed templates
+var templateFS embed.FS
+
+var (
+	// ファイル名の形式: templates/<テンプレートID>/v<バージョン>.<ロケール>.tmpl
+	fileNamePattern = regexp.MustCompile(`^v(\d+)\.([a-z]{2})\.tmpl$`)
+	// 変数宣言の形式: {{- /* vars: A, B */ -}}
+	varsPattern = regexp.MustCompile(`\{\{-?\s*/\*\s*vars:([^*]*)\*/\s*-?\}\}`)
+	// テンプレートのアクション {{ ... }} と、その中の変数参照 .Name
+	actionPattern = regexp.MustCompile(`\{\{[^}]*\}\}`)
+	fieldPattern  = regexp.MustCompile(`\.([A-Z][A-Za-z0-9]*)`)
+)
+
+// Info 生成に使ったテンプレートの識別情報
+type Info struct {
+	TemplateID string `json:"template_id"`
+	Version    int    `json:"version"`
+	Locale     string `json:"locale"`
+}
+
+// String "generate_bio@v2/ja" の形式で返す
+func (i Info) String() string {
+	return fmt.Sprintf("%s@v%d/%s", i.TemplateID, i.Version, i.Locale)
+}
+
+// Rendered 変数を埋め込んだプロンプト
+type Rendered struct {
+	Info
+	Text string
+}
+
+// promptTemplate 1つのテンプレートファイル
+type promptTemplate struct {
+	info Info
+	vars map[string]bool
+	tmpl *template.Template
+}
+
+// Registry バージョン・ロケールごとのプロンプトテンプレート
+type Registry struct {
+	templates map[Info]*promptTemplate
+	// テンプレートIDごとに使用するバージョン (複数ある場合はユーザーごとに振り分けて A/B テスト)
+	activeVersions map[string][]int
+	limits         Limits
+}
+
+// Load 埋め込まれたテンプレートを読み込む
+// 使用するバージョンは versions (例: "generate_bio=2,summarize_thread=1") で指定でき、
+// 指定が無いテンプレートは最新バージョンを使う (存在しないバージョンを指定するとエラー)
+func Load(versions string, limits Limits) (*Registry, error) {
+	registry := &Registry{
+		templates:      make(map[Info]*promptTemplate),
+		activeVersions: make(map[string][]int),
+		limits:         limits,
+	}
+
+	err := fs.WalkDir(templateFS, "templates", func(filePath string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		matches := fileNamePattern.FindStringSubmatch(d.Name())
+		if matches == nil {
+			return fmt.Errorf("テンプレートのファイル名が不正です: %s", filePath)
+		}
+		version, _ := strconv.Atoi(matches[1])
+		info := Info{TemplateID: path.Base(path.Dir(filePath)), Version: version, Locale: matches[2]}
+
+		content, err := templateFS.ReadFile(filePath)
+		if err != nil {
+			return err
+		}
+		t, err := parseTemplate(info, string(content))
+		if err != nil {
+			return fmt.Errorf("テンプレートの解析失敗 (%s): %w", filePath, err)
+		}
+		registry.templates[info] = t
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// 最新バージョンをデフォルトにする
+	for info := range registry.templates {
+		if versions := registry.activeVersions[info.TemplateID]; len(versions) == 0 || versions[0] < info.Version {
+			registry.activeVersions[info.TemplateID] = []int{info.Version}
+		}
+	}
+	if err := registry.applyVersionOverrides(versions); err != nil {
+		return nil, err
+	}
+
+	for id, versions := range registry.activeVersions {
+		log.Printf("[registry.go] プロンプトテンプレート: %s 使用バージョン: %v", id, versions)
+	}
+	return registry, nil
+}
+
+// Limits プロンプトに入れるユーザー入力の上限を返す
+func (r *Registry) Limits() Limits {
+	return r.limits
+}
+
+// parseTemplate ヘルパー関数: テンプレートを解析し、宣言された変数と本文で使われている変数が一致するか検証
+func parseTemplate(info Info, content string) (*promptTemplate, error) {
+	matches := varsPattern.FindStringSubmatch(content)
+	if matches == nil {
+		return nil, fmt.Errorf("変数宣言 {{/* vars: ... */}} がありません")
+	}
+	vars := make(map[string]bool)
+	for _, name := range strings.Split(matches[1], ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			vars[name] = true
+		}
+	}
+
+	tmpl, err := template.New(info.String()).
+		Option("missingkey=error").
+		Funcs(template.FuncMap{"join": strings.Join, "escape": escape}).
+		Parse(content)
+	if err != nil {
+		return nil, err
+	}
+
+	// 本文のアクションで使われている変数が全て宣言されているか確認
+	for _, action := range actionPattern.FindAllString(content, -1) {
+		for _, used := range fieldPattern.FindAllStringSubmatch(action, -1) {
+			if !vars[used[1]] {
+				return nil, fmt.Errorf("宣言されていない変数 %s が使われています", used[1])
+			}
+		}
+	}
+
+	return &promptTemplate{info: info, vars: vars, tmpl: tmpl}, nil
+}
+
+// applyVersionOverrides ヘルパー関数: "id=1|2,id2=3" 形式のバージョン指定を反映
+func (r *Registry) applyVersionOverrides(spec string) error {
+	if spec == "" {
+		return nil
+	}
+	for _, entry := range strings.Split(spec, ",") {
+		id, versionList, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok {
+			return fmt.Errorf("PROMPT_VERSIONS の形式が不正です: %s", entry)
+		}
+		var versions []int
+		for _, v := range strings.Split(versionList, "|") {
+			version, err := strconv.Atoi(strings.TrimSpace(v))
+			if err != nil {
+				return fmt.Errorf("PROMPT_VERSIONS のバージョンが不正です: %s", entry)
+			}
+			if _, ok := r.templates[Info{TemplateID: id, Version: version, Locale: DefaultLocale}]; !ok {
+				return fmt.Errorf("PROMPT_VERSIONS に存在しないテンプレートが指定されています: %s@v%d/%s", id, version, DefaultLocale)
+			}
+			versions = append(versions, version)
+		}
+		sort.Ints(versions)
+		r.activeVersions[id] = versions
+	}
+	return nil
+}
+
+// Render テンプレートに変数を埋め込む
+// bucketKey (ユーザーIDなど) で A/B テストのバージョンを決め、ロケールのテンプレートが無ければ DefaultLocale を使う
+func (r *Registry) Render(templateID, locale, bucketKey string, data map[string]interface{}) (*Rendered, error) {
+	versions := r.activeVersions[templateID]
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("テンプレートが存在しません: %s", templateID)
+	}
+	h := fnv.New32a()
+	h.Write([]byte(templateID + ":" + bucketKey))
+	version := versions[int(h.Sum32()%uint32(len(versions)))]
+
+	t, ok := r.templates[Info{TemplateID: templateID, Version: version, Locale: locale}]
+	if !ok {
+		t, ok = r.templates[Info{TemplateID: templateID, Version: version, Locale: DefaultLocale}]
+		if !ok {
+			return nil, fmt.Errorf("テンプレートが存在しません: %s@v%d", templateID, version)
+		}
+	}
+
+	// 変数の過不足を検証
+	for name := range t.vars {
+		if _, ok := data[name]; !ok {
+			return nil, fmt.Errorf("テンプレート %s の変数 %s が指定されていません", t.info, name)
+		}
+	}
+	for name := range data {
+		if !t.vars[name] {
+			return nil, fmt.Errorf("テンプレート %s に変数 %s は宣言されていません", t.info, name)
+		}
+	}
+
+	var text strings.Builder
+	if err := t.tmpl.Execute(&text, data); err != nil {
+		return nil, fmt.Errorf("テンプレート %s の実行失敗: %w", t.info, err)
+	}
+	return &Rendered{Info: t.info, Text: text.String()}, nil
+}
+
+// NormalizeLocale Accept-Language ヘッダやクエリの値からロケールを決める (対応していなければ DefaultLocale)
+func NormalizeLocale(value string) string {
+	for _, tag := range strings.Split(value, ",") {
+		lang, _, _ := strings.Cut(strings.TrimSpace(tag), ";")
+		lang, _, _ = strings.Cut(strings.ToLower(lang), "-")
+		if lang == "ja" || lang == "en" {
+			return lang
+		}
+	}
+	return DefaultLocale
+}
@@ -0,0 +1,129 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+)
+
+// kanaRomaji ひらがな (拗音を含む) のヘボン式のローマ字
+var kanaRomaji = map[string]string{
+	"あ": "a", "い": "i", "う": "u", "え": "e", "お": "o",
+	"か": "ka", "き": "ki", "く": "ku", "け": "ke", "こ": "ko",
+	"さ": "sa", "し": "shi", "す": "su", "せ": "se", "そ": "so",
+	"た": "ta", "ち": "chi", "つ": "tsu", "て": "te", "と": "to",
+	"な": "na", "に": "ni", "ぬ": "nu", "ね": "ne", "の": "no",
+	"は": "ha", "ひ": "hi", "ふ": "fu", "へ": "he", "ほ": "ho",
+	"ま": "ma", "み": "mi", "む": "mu", "め": "me", "も": "mo",
+	"や": "ya", "ゆ": "yu", "よ": "yo",
+	"ら": "ra", "り": "ri", "る": "ru", "れ": "re", "ろ": "ro",
+	"わ": "wa", "ゐ": "i", "ゑ": "e", "を": "o", "ん": "n",
+	"が": "ga", "ぎ": "gi", "ぐ": "gu", "げ": "ge", "ご": "go",
+	"ざ": "za", "じ": "ji", "ず": "zu", "ぜ": "ze", "ぞ": "zo",
+	"だ": "da", "ぢ": "ji", "づ": "zu", "で": "de", "ど": "do",
+	"ば": "ba", "び": "bi", "ぶ": "bu", "べ": "be", "ぼ": "bo",
+	"ぱ": "pa", "ぴ": "pi", "ぷ": "pu", "ぺ": "pe", "ぽ": "po",
+	"ゔ": "vu",
+	"ぁ": "a", "ぃ": "i", "ぅ": "u", "ぇ": "e", "ぉ": "o",
+	"ゃ": "ya", "ゅ": "yu", "ょ": "yo", "ゎ": "wa",
+	"きゃ": "kya", "きゅ": "kyu", "きょ": "kyo",
+	"しゃ": "sha", "しゅ": "shu", "しぇ": "she", "しょ": "sho",
+	"ちゃ": "cha", "ちゅ": "chu", "ちぇ": "che", "ちょ": "cho",
+	"にゃ": "nya", "にゅ": "nyu", "にょ": "nyo",
+	"ひゃ": "hya", "ひゅ": "hyu", "ひょ": "hyo",
+	"みゃ": "mya", "みゅ": "myu", "みょ": "myo",
+	"りゃ": "rya", "りゅ": "ryu", "りょ": "ryo",
+	"ぎゃ": "gya", "ぎゅ": "gyu", "ぎょ": "gyo",
+	"じゃ": "ja", "じゅ": "ju", "じぇ": "je", "じょ": "jo",
+	"ぢゃ": "ja", "ぢゅ": "ju", "ぢょ": "jo",
+	"びゃ": "bya", "びゅ": "byu", "びょ": "byo",
+	"ぴゃ": "pya", "ぴゅ": "pyu", "ぴょ": "pyo",
+	"ふぁ": "fa", "ふぃ": "fi", "ふぇ": "fe", "ふぉ": "fo",
+	"てぃ": "ti", "でぃ": "di", "うぃ": "wi", "うぇ": "we", "うぉ": "wo",
+	"ゔぁ": "va", "ゔぃ": "vi", "ゔぇ": "ve", "ゔぉ": "vo",
+}
+
+// romajiVariants 訓令式などの綴りをヘボン式にそろえる置換 (入力と索引の両方に適用する)
+var romajiVariants = strings.NewReplacer(
+	"sya", "sha", "syu", "shu", "syo", "sho",
+	"tya", "cha", "tyu", "chu", "tyo", "cho",
+	"zya", "ja", "zyu", "ju", "zyo", "jo",
+	"jya", "ja", "jyu", "ju", "jyo", "jo",
+	"si", "shi", "ti", "chi", "tu", "tsu", "hu", "fu", "zi", "ji", "di", "ji", "du", "zu",
+	"nn", "n", "n'", "n",
+)
+
+// Readings 前方一致の検索に使う、テキストの読みの一覧を返す
+// 1つ目は大文字小文字・全角半角・カタカナとひらがなの違いをそろえ空白を除いた表記で、
+// 2つ目はかなをローマ字に変換した表記 (表記と同じなら省く)
+// 漢字の読みは分からないため、漢字はそのまま残す
+func Readings(text string) []string {
+	folded := fold(text)
+	if folded == "" {
+		return nil
+	}
+	romaji := romajiVariants.Replace(toRomaji(folded))
+	if romaji == folded {
+		return []string{folded}
+	}
+	return []string{folded, romaji}
+}
+
+// fold ヘルパー関数: 小文字にし、全角英数字を半角に、カタカナをひらがなにして、空白を除く
+func fold(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		switch {
+		case unicode.IsSpace(r):
+			continue
+		case r >= '！' && r <= '～':
+			r -= '！' - '!'
+		case r >= 'ァ' && r <= 'ヶ':
+			r -= 'ァ' - 'ぁ'
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
+
+// toRomaji ヘルパー関数: ひらがなをローマ字に変換する (促音は次の子音を重ね、長音記号は除く)
+func toRomaji(text string) string {
+	runes := []rune(text)
+	var b strings.Builder
+	doubled := false
+	for i := 0; i < len(runes); {
+		if runes[i] == 'っ' {
+			doubled = true
+			i++
+			continue
+		}
+		if runes[i] == 'ー' {
+			i++
+			continue
+		}
+
+		romaji, width := "", 1
+		if i+1 < len(runes) {
+			if r, ok := kanaRomaji[string(runes[i:i+2])]; ok {
+				romaji, width = r, 2
+			}
+		}
+		if romaji == "" {
+			if r, ok := kanaRomaji[string(runes[i])]; ok {
+				romaji = r
+			} else {
+				romaji = string(runes[i])
+			}
+		}
+		if doubled && romaji[0] >= 'a' && romaji[0] <= 'z' && !strings.ContainsRune("aiueon", rune(romaji[0])) {
+			if strings.HasPrefix(romaji, "ch") {
+				b.WriteByte('t') // っち は tchi と書く
+			} else {
+				b.WriteByte(romaji[0])
+			}
+		}
+		doubled = false
+		b.WriteString(romaji)
+		i += width
+	}
+	return b.String()
+}
@@ -0,0 +1,141 @@
+package search
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// prefixEntry 前方一致の索引の1件
+type prefixEntry struct {
+	key string
+	id  string
+}
+
+// PrefixIndex IDごとの読みを辞書順に保持し、前方一致で探す索引
+// 複数の goroutine から同時に使える
+type PrefixIndex struct {
+	mu      sync.RWMutex
+	entries []prefixEntry       // key, id の順に並べたもの
+	keys    map[string][]string // ID -> 登録した読み
+}
+
+// NewPrefixIndex 空の索引を作成
+func NewPrefixIndex() *PrefixIndex {
+	return &PrefixIndex{keys: make(map[string][]string)}
+}
+
+// Set IDの読みを登録する (同じIDがあれば置き換える)
+func (ix *PrefixIndex) Set(id string, keys []string) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	ix.remove(id)
+
+	seen := make(map[string]bool)
+	for _, key := range keys {
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		entry := prefixEntry{key: key, id: id}
+		i := sort.Search(len(ix.entries), func(i int) bool { return !entryLess(ix.entries[i], entry) })
+		ix.entries = append(ix.entries, prefixEntry{})
+		copy(ix.entries[i+1:], ix.entries[i:])
+		ix.entries[i] = entry
+		ix.keys[id] = append(ix.keys[id], key)
+	}
+}
+
+// Load 全ての読みをまとめて登録し直す (起動時など、件数が多いときに使う)
+func (ix *PrefixIndex) Load(keys map[string][]string) {
+	entries := make([]prefixEntry, 0, len(keys))
+	byID := make(map[string][]string, len(keys))
+	for id, idKeys := range keys {
+		seen := make(map[string]bool)
+		for _, key := range idKeys {
+			if key == "" || seen[key] {
+				continue
+			}
+			seen[key] = true
+			entries = append(entries, prefixEntry{key: key, id: id})
+			byID[id] = append(byID[id], key)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entryLess(entries[i], entries[j]) })
+
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	ix.entries = entries
+	ix.keys = byID
+}
+
+// Remove IDの読みを取り除く
+func (ix *PrefixIndex) Remove(id string) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	ix.remove(id)
+}
+
+// lookupScanFactor 1つの前方一致で調べる件数の上限 (limit の倍数、短い1文字の入力で全件を調べないようにする)
+const lookupScanFactor = 20
+
+// Lookup いずれかの読みが prefixes のいずれかで始まるIDを、読みの短い順に最大 limit 件返す
+// 一致が多い場合は、各前方一致について辞書順で先頭の limit*lookupScanFactor 件から選ぶ
+func (ix *PrefixIndex) Lookup(prefixes []string, limit int) []string {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	var matches []prefixEntry
+	seen := make(map[string]bool)
+	for _, prefix := range prefixes {
+		if prefix == "" {
+			continue
+		}
+		i := sort.Search(len(ix.entries), func(i int) bool { return ix.entries[i].key >= prefix })
+		for end := i + limit*lookupScanFactor; i < min(end, len(ix.entries)) && strings.HasPrefix(ix.entries[i].key, prefix); i++ {
+			if seen[ix.entries[i].id] {
+				continue
+			}
+			seen[ix.entries[i].id] = true
+			matches = append(matches, ix.entries[i])
+		}
+	}
+
+	// 短い読みほど入力に近い
+	sort.SliceStable(matches, func(i, j int) bool { return len(matches[i].key) < len(matches[j].key) })
+	ids := make([]string, 0, min(len(matches), limit))
+	for _, match := range matches {
+		if len(ids) == limit {
+			break
+		}
+		ids = append(ids, match.id)
+	}
+	return ids
+}
+
+// Len 登録しているIDの数
+func (ix *PrefixIndex) Len() int {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	return len(ix.keys)
+}
+
+// remove ヘルパー関数: IDの読みを取り除く (呼び出し側でロックする)
+func (ix *PrefixIndex) remove(id string) {
+	for _, key := range ix.keys[id] {
+		entry := prefixEntry{key: key, id: id}
+		i := sort.Search(len(ix.entries), func(i int) bool { return !entryLess(ix.entries[i], entry) })
+		if i < len(ix.entries) && ix.entries[i] == entry {
+			ix.entries = append(ix.entries[:i], ix.entries[i+1:]...)
+		}
+	}
+	delete(ix.keys, id)
+}
+
+// entryLess ヘルパー関数: 読み、IDの順に比較する
+func entryLess(a, b prefixEntry) bool {
+	if a.key != b.key {
+		return a.key < b.key
+	}
+	return a.id < b.id
+}
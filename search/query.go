@@ -0,0 +1,270 @@
+package search
+
+import (
+	"strconv"
+	"strings"
+	"time"
+	"twitter/apperr"
+	"unicode"
+	"unicode/utf8"
+)
+
+// クエリ言語の上限
+const (
+	maxQueryLen      = 500     // クエリ全体の最大文字数
+	maxQueryTerms    = 16      // 語とフレーズの最大数 (OR の候補と除外する語を含む)
+	maxUserIDLen     = 255     // from:, to: に指定できるユーザーIDの最大文字数
+	maxMinLikes      = 1000000 // min_likes: の最大値
+	queryDateLayout  = "2006-01-02"
+	orOperator       = "OR"
+	negationOperator = '-'
+)
+
+// ErrInvalidQuery 検索クエリの形式が不正
+var ErrInvalidQuery = apperr.Validation("invalid_query", "検索クエリが不正です")
+
+// Term 検索する語 (Phrase なら "" で囲まれた語句で、空白を含めてそのまま探す)
+type Term struct {
+	Text   string
+	Phrase bool
+}
+
+// Query 解析した検索クエリ
+// Clauses は全て満たす必要がある条件で、各条件はいずれかの語を含めば満たす (OR でつないだ語)
+type Query struct {
+	Clauses  [][]Term
+	Excluded []Term     // -語 で指定した、含んではいけない語
+	From     []string   // from: 投稿者のユーザーID (いずれか)
+	To       []string   // to: 返信先の投稿者のユーザーID (いずれか)
+	HasImage *bool      // has:image (true) / -has:image (false)
+	IsReply  *bool      // is:reply (true) / -is:reply (false)
+	Since    *time.Time // since: この日以降 (その日を含む)
+	Until    *time.Time // until: この日の翌日より前 (その日を含む)
+	MinLikes int        // min_likes: いいね数の下限
+}
+
+// AllOf 全ての語を含む投稿を探すクエリ (語が無ければ nil)
+func AllOf(terms []string) *Query {
+	if len(terms) == 0 {
+		return nil
+	}
+	query := &Query{}
+	for _, term := range terms {
+		query.Clauses = append(query.Clauses, []Term{{Text: term}})
+	}
+	return query
+}
+
+// PositiveTerms 含むべき語の一覧 (スニペットの一致箇所に使う)
+func (q *Query) PositiveTerms() []string {
+	var terms []string
+	for _, clause := range q.Clauses {
+		for _, term := range clause {
+			terms = append(terms, term.Text)
+		}
+	}
+	return terms
+}
+
+// token クエリを区切った1つの要素
+type token struct {
+	text     string
+	phrase   bool
+	negated  bool
+	isOr     bool
+	operator string // from, to, has, is, since, until, min_likes (語なら空)
+	value    string
+}
+
+// ParseQuery 検索クエリを解析する
+// 対応する構文: 語、"フレーズ"、語 OR 語、-除外、from:ユーザーID、to:ユーザーID、has:image、is:reply、
+// since:YYYY-MM-DD、until:YYYY-MM-DD、min_likes:数 (-has:image、-is:reply で否定できる)
+// 形式が不正なら ErrInvalidQuery を返す
+func ParseQuery(input string) (*Query, error) {
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return nil, ErrInvalidQuery.Detailf("検索条件が空です")
+	}
+	if utf8.RuneCountInString(input) > maxQueryLen {
+		return nil, ErrInvalidQuery.Detailf("%d文字以内で指定してください", maxQueryLen)
+	}
+
+	tokens, err := tokenize(input)
+	if err != nil {
+		return nil, err
+	}
+
+	query := &Query{}
+	termCount := 0
+	for i := 0; i < len(tokens); i++ {
+		tok := tokens[i]
+		switch {
+		case tok.isOr:
+			// OR は直前の語と直後の語をつなぐ
+			if i == 0 || i == len(tokens)-1 || !isPositiveTerm(tokens[i-1]) || !isPositiveTerm(tokens[i+1]) {
+				return nil, ErrInvalidQuery.Detailf("OR は除外しない2つの語の間に書いてください")
+			}
+			next := tokens[i+1]
+			last := len(query.Clauses) - 1
+			query.Clauses[last] = append(query.Clauses[last], Term{Text: next.text, Phrase: next.phrase})
+			termCount++
+			i++
+		case tok.operator != "":
+			if err := query.applyFilter(tok); err != nil {
+				return nil, err
+			}
+		case tok.negated:
+			query.Excluded = append(query.Excluded, Term{Text: tok.text, Phrase: tok.phrase})
+			termCount++
+		default:
+			query.Clauses = append(query.Clauses, []Term{{Text: tok.text, Phrase: tok.phrase}})
+			termCount++
+		}
+		if termCount > maxQueryTerms {
+			return nil, ErrInvalidQuery.Detailf("語は%d個までです", maxQueryTerms)
+		}
+	}
+
+	if len(query.Clauses) == 0 && !query.hasFilter() {
+		return nil, ErrInvalidQuery.Detailf("除外する語だけでは検索できません")
+	}
+	if query.Since != nil && query.Until != nil && !query.Since.Before(*query.Until) {
+		return nil, ErrInvalidQuery.Detailf("since: は until: 以前の日付にしてください")
+	}
+	return query, nil
+}
+
+// tokenize ヘルパー関数: クエリを空白と "" で区切る
+func tokenize(input string) ([]token, error) {
+	runes := []rune(input)
+	var tokens []token
+	for i := 0; i < len(runes); {
+		if unicode.IsSpace(runes[i]) {
+			i++
+			continue
+		}
+
+		tok := token{}
+		if runes[i] == negationOperator {
+			tok.negated = true
+			i++
+			if i == len(runes) || unicode.IsSpace(runes[i]) {
+				return nil, ErrInvalidQuery.Detailf("- の直後に除外する語を書いてください")
+			}
+		}
+
+		if runes[i] == '"' {
+			end := i + 1
+			for end < len(runes) && runes[end] != '"' {
+				end++
+			}
+			if end == len(runes) {
+				return nil, ErrInvalidQuery.Detailf("引用符 \" が閉じられていません")
+			}
+			tok.text = strings.Join(strings.Fields(string(runes[i+1:end])), " ")
+			tok.phrase = true
+			if tok.text == "" {
+				return nil, ErrInvalidQuery.Detailf("空のフレーズ \"\" は使えません")
+			}
+			tokens = append(tokens, tok)
+			i = end + 1
+			continue
+		}
+
+		end := i
+		for end < len(runes) && !unicode.IsSpace(runes[end]) {
+			if runes[end] == '"' {
+				return nil, ErrInvalidQuery.Detailf("引用符 \" は語の先頭に書いてください")
+			}
+			end++
+		}
+		word := string(runes[i:end])
+		i = end
+
+		if word == orOperator && !tok.negated {
+			tokens = append(tokens, token{isOr: true})
+			continue
+		}
+		if name, value, ok := strings.Cut(word, ":"); ok && isFilterName(strings.ToLower(name)) {
+			tok.operator = strings.ToLower(name)
+			tok.value = value
+			if value == "" {
+				return nil, ErrInvalidQuery.Detailf("%s: の値がありません", tok.operator)
+			}
+		}
+		tok.text = word
+		tokens = append(tokens, tok)
+	}
+	return tokens, nil
+}
+
+// applyFilter ヘルパー関数: from: などの絞り込みをクエリに反映する
+func (q *Query) applyFilter(tok token) error {
+	negatable := tok.operator == "has" || tok.operator == "is"
+	if tok.negated && !negatable {
+		return ErrInvalidQuery.Detailf("%s: は - で否定できません", tok.operator)
+	}
+
+	switch tok.operator {
+	case "from", "to":
+		userID := strings.TrimPrefix(tok.value, "@")
+		if userID == "" || utf8.RuneCountInString(userID) > maxUserIDLen {
+			return ErrInvalidQuery.Detailf("%s: のユーザーIDが不正です", tok.operator)
+		}
+		if tok.operator == "from" {
+			q.From = append(q.From, userID)
+		} else {
+			q.To = append(q.To, userID)
+		}
+	case "has":
+		if strings.ToLower(tok.value) != "image" {
+			return ErrInvalidQuery.Detailf("has: には image のみ指定できます")
+		}
+		value := !tok.negated
+		q.HasImage = &value
+	case "is":
+		if strings.ToLower(tok.value) != "reply" {
+			return ErrInvalidQuery.Detailf("is: には reply のみ指定できます")
+		}
+		value := !tok.negated
+		q.IsReply = &value
+	case "since", "until":
+		date, err := time.ParseInLocation(queryDateLayout, tok.value, time.Local)
+		if err != nil {
+			return ErrInvalidQuery.Detailf("%s: の日付は YYYY-MM-DD 形式で指定してください", tok.operator)
+		}
+		if tok.operator == "since" {
+			q.Since = &date
+		} else {
+			next := date.AddDate(0, 0, 1)
+			q.Until = &next
+		}
+	case "min_likes":
+		count, err := strconv.Atoi(tok.value)
+		if err != nil || count < 0 || count > maxMinLikes {
+			return ErrInvalidQuery.Detailf("min_likes: には0以上%d以下の整数を指定してください", maxMinLikes)
+		}
+		q.MinLikes = count
+	}
+	return nil
+}
+
+// hasFilter ヘルパー関数: 絞り込みが1つでも指定されているか
+func (q *Query) hasFilter() bool {
+	return len(q.From) > 0 || len(q.To) > 0 || q.HasImage != nil || q.IsReply != nil ||
+		q.Since != nil || q.Until != nil || q.MinLikes > 0
+}
+
+// isFilterName ヘルパー関数: 絞り込みの名前か判定する (それ以外の "a:b" は語として扱う)
+func isFilterName(name string) bool {
+	switch name {
+	case "from", "to", "has", "is", "since", "until", "min_likes":
+		return true
+	}
+	return false
+}
+
+// isPositiveTerm ヘルパー関数: OR でつなげる語 (除外でも絞り込みでもない語・フレーズ) か判定する
+func isPositiveTerm(tok token) bool {
+	return !tok.isOr && !tok.negated && tok.operator == ""
+}
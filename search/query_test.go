@@ -0,0 +1,219 @@
+package search
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+	"twitter/apperr"
+)
+
+func boolPtr(v bool) *bool {
+	return &v
+}
+
+func datePtr(year int, month time.Month, day int) *time.Time {
+	date := time.Date(year, month, day, 0, 0, 0, 0, time.Local)
+	return &date
+}
+
+func TestParseQueryValid(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  *Query
+	}{
+		{
+			name:  "単語",
+			input: "go",
+			want:  &Query{Clauses: [][]Term{{{Text: "go"}}}},
+		},
+		{
+			name:  "複数の単語は全て含む",
+			input: "  go   mysql ",
+			want:  &Query{Clauses: [][]Term{{{Text: "go"}}, {{Text: "mysql"}}}},
+		},
+		{
+			name:  "OR でつないだ語",
+			input: "go OR rust",
+			want:  &Query{Clauses: [][]Term{{{Text: "go"}, {Text: "rust"}}}},
+		},
+		{
+			name:  "OR を連続してつなぐ",
+			input: "go OR rust OR zig mysql",
+			want: &Query{Clauses: [][]Term{
+				{{Text: "go"}, {Text: "rust"}, {Text: "zig"}},
+				{{Text: "mysql"}},
+			}},
+		},
+		{
+			name:  "小文字の or は語",
+			input: "go or rust",
+			want:  &Query{Clauses: [][]Term{{{Text: "go"}}, {{Text: "or"}}, {{Text: "rust"}}}},
+		},
+		{
+			name:  "フレーズ",
+			input: `"hello   world" go`,
+			want: &Query{Clauses: [][]Term{
+				{{Text: "hello world", Phrase: true}},
+				{{Text: "go"}},
+			}},
+		},
+		{
+			name:  "フレーズを OR でつなぐ",
+			input: `"hello world" OR hi`,
+			want:  &Query{Clauses: [][]Term{{{Text: "hello world", Phrase: true}, {Text: "hi"}}}},
+		},
+		{
+			name:  "除外する語とフレーズ",
+			input: `go -java -"spring boot"`,
+			want: &Query{
+				Clauses:  [][]Term{{{Text: "go"}}},
+				Excluded: []Term{{Text: "java"}, {Text: "spring boot", Phrase: true}},
+			},
+		},
+		{
+			name:  "-OR は除外する語",
+			input: "go -OR",
+			want: &Query{
+				Clauses:  [][]Term{{{Text: "go"}}},
+				Excluded: []Term{{Text: "OR"}},
+			},
+		},
+		{
+			name:  "from: と to: は @ を取り除く",
+			input: "from:@alice from:bob to:carol",
+			want:  &Query{From: []string{"alice", "bob"}, To: []string{"carol"}},
+		},
+		{
+			name:  "has:image",
+			input: "go has:image",
+			want:  &Query{Clauses: [][]Term{{{Text: "go"}}}, HasImage: boolPtr(true)},
+		},
+		{
+			name:  "-has:image",
+			input: "go -has:image",
+			want:  &Query{Clauses: [][]Term{{{Text: "go"}}}, HasImage: boolPtr(false)},
+		},
+		{
+			name:  "-is:reply",
+			input: "-is:reply",
+			want:  &Query{IsReply: boolPtr(false)},
+		},
+		{
+			name:  "絞り込みの名前と値は大文字小文字を区別しない",
+			input: "FROM:alice Has:IMAGE IS:Reply",
+			want:  &Query{From: []string{"alice"}, HasImage: boolPtr(true), IsReply: boolPtr(true)},
+		},
+		{
+			name:  "since: と until: (until: はその日を含む)",
+			input: "since:2024-01-01 until:2024-01-31",
+			want:  &Query{Since: datePtr(2024, time.January, 1), Until: datePtr(2024, time.February, 1)},
+		},
+		{
+			name:  "since: と until: が同じ日",
+			input: "since:2024-03-01 until:2024-03-01",
+			want:  &Query{Since: datePtr(2024, time.March, 1), Until: datePtr(2024, time.March, 2)},
+		},
+		{
+			name:  "min_likes:",
+			input: "go min_likes:10",
+			want:  &Query{Clauses: [][]Term{{{Text: "go"}}}, MinLikes: 10},
+		},
+		{
+			name:  "min_likes: の上限",
+			input: "min_likes:1000000",
+			want:  &Query{MinLikes: maxMinLikes},
+		},
+		{
+			name:  "絞り込み以外の a:b は語",
+			input: "http://example.com lang:ja",
+			want:  &Query{Clauses: [][]Term{{{Text: "http://example.com"}}, {{Text: "lang:ja"}}}},
+		},
+		{
+			name:  "語の数の上限",
+			input: strings.TrimSpace(strings.Repeat("a ", maxQueryTerms)),
+			want: func() *Query {
+				query := &Query{}
+				for i := 0; i < maxQueryTerms; i++ {
+					query.Clauses = append(query.Clauses, []Term{{Text: "a"}})
+				}
+				return query
+			}(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseQuery(tt.input)
+			if err != nil {
+				t.Fatalf("ParseQuery(%q) error = %v", tt.input, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseQuery(%q) = %+v, want %+v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseQueryInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{name: "空", input: ""},
+		{name: "空白だけ", input: "   "},
+		{name: "文字数の上限を超える", input: strings.Repeat("a", maxQueryLen+1)},
+		{name: "語の数の上限を超える", input: strings.TrimSpace(strings.Repeat("a ", maxQueryTerms+1))},
+		{name: "OR の候補を含めて上限を超える", input: "a b c d e f g h i j k l m n o OR p OR q"},
+		{name: "除外する語を含めて上限を超える", input: "a b c d e f g h i j k l m n o p -q"},
+		{name: "先頭の OR", input: "OR go"},
+		{name: "末尾の OR", input: "go OR"},
+		{name: "OR だけ", input: "OR"},
+		{name: "OR の連続", input: "go OR OR rust"},
+		{name: "除外する語の前の OR", input: "go OR -rust"},
+		{name: "除外する語の後の OR", input: "-go OR rust"},
+		{name: "絞り込みの前の OR", input: "go OR from:alice"},
+		{name: "絞り込みの後の OR", input: "has:image OR go"},
+		{name: "- だけ", input: "go -"},
+		{name: "- の後が空白", input: "- go"},
+		{name: "閉じていない引用符", input: `"hello world`},
+		{name: "空のフレーズ", input: `go ""`},
+		{name: "空白だけのフレーズ", input: `"   "`},
+		{name: "語の途中の引用符", input: `he"llo"`},
+		{name: "値の無い from:", input: "from:"},
+		{name: "値の無い min_likes:", input: "go min_likes:"},
+		{name: "@ だけの from:", input: "from:@"},
+		{name: "長すぎるユーザーID", input: "to:" + strings.Repeat("a", maxUserIDLen+1)},
+		{name: "否定した from:", input: "go -from:alice"},
+		{name: "否定した since:", input: "go -since:2024-01-01"},
+		{name: "否定した min_likes:", input: "go -min_likes:5"},
+		{name: "image 以外の has:", input: "has:video"},
+		{name: "reply 以外の is:", input: "is:quote"},
+		{name: "日付の形式が不正", input: "since:2024/01/01"},
+		{name: "存在しない日付", input: "until:2024-02-30"},
+		{name: "負の min_likes:", input: "min_likes:-1"},
+		{name: "上限を超える min_likes:", input: "min_likes:1000001"},
+		{name: "数値でない min_likes:", input: "min_likes:many"},
+		{name: "除外する語だけ", input: "-go -rust"},
+		{name: "since: が until: より後", input: "since:2024-01-02 until:2024-01-01"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseQuery(tt.input)
+			if err == nil {
+				t.Fatalf("ParseQuery(%q) = %+v, want error", tt.input, got)
+			}
+			if !errors.Is(err, ErrInvalidQuery) {
+				t.Fatalf("ParseQuery(%q) error = %v, want ErrInvalidQuery", tt.input, err)
+			}
+			var appErr *apperr.Error
+			if !errors.As(err, &appErr) || appErr.Kind.Status() != http.StatusBadRequest {
+				t.Errorf("ParseQuery(%q) error = %v, want status %d", tt.input, err, http.StatusBadRequest)
+			}
+		})
+	}
+}
@@ -0,0 +1,170 @@
+package search
+
+import (
+	"math"
+	"regexp"
+	"strings"
+	"time"
+	"twitter/model"
+	"unicode/utf8"
+)
+
+// 全文検索に使う値
+const (
+	MinTermLen         = 2              // 全文検索インデックス (ngram) で探せる語の最小文字数
+	maxTerms           = 8              // クエリから使う語の最大数
+	recencyHalfLife    = 72 * time.Hour // 新しさによる加点が半分になるまでの時間
+	recencyWeight      = 1.0            // 投稿した直後の加点の大きさ (関連度に掛ける倍率は最大 1+recencyWeight)
+	snippetContextLen  = 30             // スニペットで一致箇所の前後に含める文字数
+	maxSnippetFullText = 100            // この文字数以下ならスニペットに全文を使う
+)
+
+// Terms 検索クエリを空白で区切った語の一覧を返す
+// 全文検索の演算子として扱われる記号は取り除き、重複する語と空の語は除く
+func Terms(query string) []string {
+	seen := make(map[string]bool)
+	var terms []string
+	for _, field := range strings.Fields(query) {
+		term := strings.Map(func(r rune) rune {
+			if strings.ContainsRune(`"+-<>()~*@'`, r) {
+				return ' '
+			}
+			return r
+		}, field)
+		for _, part := range strings.Fields(term) {
+			key := strings.ToLower(part)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			terms = append(terms, part)
+			if len(terms) == maxTerms {
+				return terms
+			}
+		}
+	}
+	return terms
+}
+
+// Indexable 全ての語が全文検索インデックスで探せる長さか判定する (短い語を含むなら LIKE で探す)
+func Indexable(terms []string) bool {
+	for _, term := range terms {
+		if utf8.RuneCountInString(term) < MinTermLen {
+			return false
+		}
+	}
+	return len(terms) > 0
+}
+
+// RecencyBoost 投稿日時による関連度の倍率 (直後は 1+recencyWeight で、半減期ごとに加点が半分になる)
+func RecencyBoost(createdAt, now time.Time) float64 {
+	age := now.Sub(createdAt)
+	if age < 0 {
+		age = 0
+	}
+	return 1 + recencyWeight*math.Pow(0.5, float64(age)/float64(recencyHalfLife))
+}
+
+// Snippet 最初の一致箇所の前後を切り出したスニペットと、その中の一致箇所を返す (大文字小文字は区別しない)
+// 短いテキストは全文を使い、一致しなければ先頭を切り出す
+func Snippet(text string, terms []string) (string, []model.Highlight) {
+	runes := []rune(text)
+	lower := []rune(strings.ToLower(text))
+	if len(lower) != len(runes) {
+		// 小文字にすると文字数が変わる文字を含む場合は位置がずれるため、そのまま比較する
+		lower = runes
+	}
+
+	matches := findMatches(lower, terms)
+	start, end := 0, len(runes)
+	if len(runes) > maxSnippetFullText {
+		first := 0
+		if len(matches) > 0 {
+			first = matches[0].Start
+		}
+		start = max(first-snippetContextLen, 0)
+		end = min(start+2*snippetContextLen+maxTermRunes(terms), len(runes))
+	}
+
+	snippet := string(runes[start:end])
+	if start > 0 {
+		snippet = "…" + snippet
+	}
+	if end < len(runes) {
+		snippet += "…"
+	}
+
+	highlights := []model.Highlight{}
+	offset := 0
+	if start > 0 {
+		offset = 1 // 先頭の "…" の分
+	}
+	for _, match := range matches {
+		if match.Start < start || match.End > end {
+			continue
+		}
+		highlights = append(highlights, model.Highlight{Start: match.Start - start + offset, End: match.End - start + offset})
+	}
+	return snippet, highlights
+}
+
+// findMatches ヘルパー関数: 全ての語の一致箇所を重ならないように先頭から順に返す
+func findMatches(text []rune, terms []string) []model.Highlight {
+	var matches []model.Highlight
+	for i := 0; i < len(text); {
+		matched := 0
+		for _, term := range terms {
+			termRunes := []rune(strings.ToLower(term))
+			if len(termRunes) > matched && hasPrefixAt(text, termRunes, i) {
+				matched = len(termRunes)
+			}
+		}
+		if matched == 0 {
+			i++
+			continue
+		}
+		matches = append(matches, model.Highlight{Start: i, End: i + matched})
+		i += matched
+	}
+	return matches
+}
+
+// hasPrefixAt ヘルパー関数: text の位置 i から prefix が始まるか判定する
+func hasPrefixAt(text, prefix []rune, i int) bool {
+	if i+len(prefix) > len(text) {
+		return false
+	}
+	for j, r := range prefix {
+		if text[i+j] != r {
+			return false
+		}
+	}
+	return true
+}
+
+// maxTermRunes ヘルパー関数: 最も長い語の文字数
+func maxTermRunes(terms []string) int {
+	longest := 0
+	for _, term := range terms {
+		longest = max(longest, utf8.RuneCountInString(term))
+	}
+	return longest
+}
+
+// hashtagPattern ハッシュタグ (# または全角の ＃ に続く文字・数字・_ の並び)
+var hashtagPattern = regexp.MustCompile(`[#＃]([\p{L}\p{M}\p{N}_]+)`)
+
+// Hashtags テキストに含まれるハッシュタグを # を除いて重複なく返す (大文字小文字は区別しない)
+func Hashtags(text string) []string {
+	seen := make(map[string]bool)
+	var tags []string
+	for _, match := range hashtagPattern.FindAllStringSubmatch(text, -1) {
+		key := strings.ToLower(match[1])
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		tags = append(tags, match[1])
+	}
+	return tags
+}
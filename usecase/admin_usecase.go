@@ -0,0 +1,104 @@
+package usecase
+
+import (
+	"context"
+	"github.com/oklog/ulid"
+	"math/rand"
+	"time"
+	"twitter/dao"
+	"twitter/model"
+	"twitter/validate"
+)
+
+// AdminUseCase 管理者用のUseCase
+type AdminUseCase struct {
+	UnitOfWork      *dao.UnitOfWork
+	RestrictionDAO  *dao.RestrictionDAO
+	UsageDAO        *dao.UsageDAO
+	GenerationCache *GenerationCache
+}
+
+func NewAdminUseCase(unitOfWork *dao.UnitOfWork, restrictionDAO *dao.RestrictionDAO, usageDAO *dao.UsageDAO, generationCache *GenerationCache) *AdminUseCase {
+	return &AdminUseCase{UnitOfWork: unitOfWork, RestrictionDAO: restrictionDAO, UsageDAO: usageDAO, GenerationCache: generationCache}
+}
+
+// RestrictUser ユーザーを凍結またはシャドウバンする (durationHours が 0 なら無期限)
+func (uc *AdminUseCase) RestrictUser(ctx context.Context, userID, kind, reason string, durationHours int) (*model.Restriction, error) {
+	if err := validateRestriction(userID, kind, reason, durationHours); err != nil {
+		return nil, err
+	}
+
+	entropy := rand.New(rand.NewSource(time.Now().UnixNano()))
+	now := time.Now()
+	restriction := model.Restriction{
+		RestrictionID: ulid.MustNew(ulid.Timestamp(now), entropy).String(),
+		UserID:        userID,
+		Kind:          kind,
+		Reason:        reason,
+		CreatedAt:     now,
+	}
+	if durationHours > 0 {
+		expiresAt := now.Add(time.Duration(durationHours) * time.Hour)
+		restriction.ExpiresAt = &expiresAt
+	}
+
+	// 既存の制限の解除と新しい制限の追加を同じトランザクションで行い、途中で失敗すれば全て元に戻す
+	err := uc.UnitOfWork.Do(ctx, func(ctx context.Context) error {
+		return uc.RestrictionDAO.AddRestriction(ctx, restriction)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &restriction, nil
+}
+
+// LiftRestriction ユーザーの凍結またはシャドウバンを解除する
+func (uc *AdminUseCase) LiftRestriction(ctx context.Context, userID, kind string) error {
+	if err := validate.Check(
+		validate.Required("user_id", userID),
+		validate.OneOf("kind", kind, model.RestrictionSuspend, model.RestrictionShadowBan),
+	); err != nil {
+		return err
+	}
+	return uc.RestrictionDAO.LiftRestriction(ctx, userID, kind)
+}
+
+// GetRestrictions ユーザーの制限履歴を取得する
+func (uc *AdminUseCase) GetRestrictions(ctx context.Context, userID string) ([]model.Restriction, error) {
+	if err := validate.Check(validate.Required("user_id", userID)); err != nil {
+		return nil, err
+	}
+	return uc.RestrictionDAO.GetRestrictions(ctx, userID)
+}
+
+// SetQuotaOverride ユーザーのAI使用量の1日の上限を上書きする (0 は無制限)
+func (uc *AdminUseCase) SetQuotaOverride(ctx context.Context, userID string, requestsPerDay, tokensPerDay int, reason string) (*model.QuotaOverride, error) {
+	if err := validateQuotaOverride(userID, requestsPerDay, tokensPerDay, reason); err != nil {
+		return nil, err
+	}
+
+	override := model.QuotaOverride{
+		UserID:         userID,
+		RequestsPerDay: requestsPerDay,
+		TokensPerDay:   tokensPerDay,
+		Reason:         reason,
+		UpdatedAt:      time.Now(),
+	}
+	if err := uc.UsageDAO.SetQuotaOverride(ctx, override); err != nil {
+		return nil, err
+	}
+	return &override, nil
+}
+
+// DeleteQuotaOverride ユーザーのAI使用量の上限の上書きを削除してデフォルトに戻す
+func (uc *AdminUseCase) DeleteQuotaOverride(ctx context.Context, userID string) error {
+	if err := validate.Check(validate.Required("user_id", userID)); err != nil {
+		return err
+	}
+	return uc.UsageDAO.DeleteQuotaOverride(ctx, userID)
+}
+
+// GetCacheStats AI生成結果のキャッシュのヒット・ミスなどの統計を取得する
+func (uc *AdminUseCase) GetCacheStats() CacheStats {
+	return uc.GenerationCache.Stats()
+}
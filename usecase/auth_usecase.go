@@ -1,41 +1,51 @@
 package usecase
 
 import (
-	"errors"
+	"context"
 	"twitter/dao"
 	"twitter/model"
+	"twitter/validate"
 )
 
 type AuthUseCase struct { // 修正: 名前をAuthUseCaseに変更
-	AuthDAO *dao.AuthDAO
+	AuthDAO        *dao.AuthDAO
+	RestrictionDAO *dao.RestrictionDAO
+	ImageUseCase   *ImageUseCase
+	SuggestUseCase *SuggestUseCase
 }
 
-func NewAuthUseCase(AuthDAO *dao.AuthDAO) *AuthUseCase { // 修正: コンストラクタも変更
-	return &AuthUseCase{AuthDAO: AuthDAO}
+func NewAuthUseCase(AuthDAO *dao.AuthDAO, RestrictionDAO *dao.RestrictionDAO, imageUseCase *ImageUseCase, suggestUseCase *SuggestUseCase) *AuthUseCase { // 修正: コンストラクタも変更
+	return &AuthUseCase{AuthDAO: AuthDAO, RestrictionDAO: RestrictionDAO, ImageUseCase: imageUseCase, SuggestUseCase: suggestUseCase}
 }
 
-func (uc *AuthUseCase) RegisterUser(userID, name, bio, profileImgURL string) (string, error) {
-	if userID == "" {
-		return "", errors.New("user_id が無効: 必須項目")
-	}
-	if name == "" || len(name) > 50 {
-		return "", errors.New("名前が無効: 必須項目で50文字以内である必要がある")
-	}
-
+func (uc *AuthUseCase) RegisterUser(ctx context.Context, userID, name, bio, profileImgURL string) (string, error) {
 	user := model.User{
 		UserID:        userID,
 		Name:          name,
 		Bio:           stringToPointer(bio),
 		ProfileImgURL: stringToPointer(profileImgURL),
 	}
+	if err := validateUser(user); err != nil {
+		return "", err
+	}
 
-	if err := uc.AuthDAO.RegisterUser(user); err != nil {
+	if err := uc.AuthDAO.RegisterUser(ctx, user); err != nil {
 		return "", err
 	}
+	uc.ImageUseCase.Enqueue(ctx, model.ImageKindProfile, user.UserID, user.ProfileImgURL)
+	uc.SuggestUseCase.RefreshUser(user.UserID, user.Name)
 
 	return user.UserID, nil
 }
 
+// CheckLogin ログイン可否を確認し、凍結中なら有効な凍結情報を返す
+func (uc *AuthUseCase) CheckLogin(ctx context.Context, userID string) (*model.Restriction, error) {
+	if err := validate.Check(validate.Required("user_id", userID)); err != nil {
+		return nil, err
+	}
+	return uc.RestrictionDAO.GetActiveRestriction(ctx, userID, model.RestrictionSuspend)
+}
+
 // ヘルパー関数: 文字列をポインタに変換
 func stringToPointer(s string) *string {
 	return &s
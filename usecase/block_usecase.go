@@ -0,0 +1,43 @@
+package usecase
+
+import (
+	"context"
+	"twitter/dao"
+	"twitter/model"
+)
+
+type BlockUseCase struct {
+	UnitOfWork *dao.UnitOfWork
+	BlockDAO   *dao.BlockDAO
+	FollowDAO  *dao.FollowDAO
+}
+
+func NewBlockUseCase(unitOfWork *dao.UnitOfWork, blockDAO *dao.BlockDAO, followDAO *dao.FollowDAO) *BlockUseCase {
+	return &BlockUseCase{UnitOfWork: unitOfWork, BlockDAO: blockDAO, FollowDAO: followDAO}
+}
+
+// AddBlock 指定ユーザーをブロックし、2人の間のフォローを両方向とも解除する
+func (uc *BlockUseCase) AddBlock(ctx context.Context, userID, blockedUserID string) error {
+	if err := validateBlock(userID, blockedUserID); err != nil {
+		return err
+	}
+	return uc.UnitOfWork.Do(ctx, func(ctx context.Context) error {
+		if err := uc.BlockDAO.AddBlock(ctx, userID, blockedUserID); err != nil {
+			return err
+		}
+		return uc.FollowDAO.RemoveFollowsBetween(ctx, userID, blockedUserID)
+	})
+}
+
+// RemoveBlock 指定ユーザーのブロックを解除 (解除したフォローは元に戻さない)
+func (uc *BlockUseCase) RemoveBlock(ctx context.Context, userID, blockedUserID string) error {
+	if err := validateBlock(userID, blockedUserID); err != nil {
+		return err
+	}
+	return uc.BlockDAO.RemoveBlock(ctx, userID, blockedUserID)
+}
+
+// GetBlockedUsers 指定ユーザーがブロックしているユーザー一覧を取得
+func (uc *BlockUseCase) GetBlockedUsers(ctx context.Context, userID string) ([]model.User, error) {
+	return uc.BlockDAO.GetBlockedUsers(ctx, userID)
+}
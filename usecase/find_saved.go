@@ -0,0 +1,140 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"github.com/oklog/ulid"
+	"log"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+	"twitter/apperr"
+	"twitter/model"
+	"twitter/search"
+	"twitter/validate"
+)
+
+// 保存した検索と検索履歴に使う値
+const (
+	maxSavedSearches         = 50 // ユーザーごとに保存できる検索の最大数
+	searchHistoryKeep        = 50 // ユーザーごとに残す検索履歴の最大数
+	defaultHistoryLimit      = 20
+	savedSearchTimelineLimit = maxFindResults // 保存した検索の新しい投稿を返す最大数
+)
+
+// ErrSavedSearchLimit 保存できる検索の数の上限に達している
+var ErrSavedSearchLimit = apperr.Conflict("saved_search_limit", fmt.Sprintf("保存できる検索は%d件までです", maxSavedSearches))
+
+// SaveSearch 投稿検索のクエリを保存する
+// クエリの形式が不正なら search.ErrInvalidQuery、同じクエリを保存済みなら dao.ErrSavedSearchExists を返す
+func (uc *FindUseCase) SaveSearch(ctx context.Context, userID, query string) (*model.SavedSearch, error) {
+	if err := validate.Check(validate.Required("user_id", userID)); err != nil {
+		return nil, err
+	}
+	query = strings.TrimSpace(query)
+	if _, err := search.ParseQuery(query); err != nil {
+		return nil, err
+	}
+
+	count, err := uc.SavedSearchDAO.CountSavedSearches(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if count >= maxSavedSearches {
+		return nil, ErrSavedSearchLimit
+	}
+
+	entropy := rand.New(rand.NewSource(time.Now().UnixNano()))
+	now := time.Now()
+	saved := model.SavedSearch{
+		SearchID:  ulid.MustNew(ulid.Timestamp(now), entropy).String(),
+		UserID:    userID,
+		Query:     query,
+		CreatedAt: now,
+	}
+	if err := uc.SavedSearchDAO.CreateSavedSearch(ctx, saved); err != nil {
+		return nil, err
+	}
+	return &saved, nil
+}
+
+// ListSavedSearches ユーザーが保存した検索を新しい順に取得
+func (uc *FindUseCase) ListSavedSearches(ctx context.Context, userID string) ([]model.SavedSearch, error) {
+	return uc.SavedSearchDAO.ListSavedSearches(ctx, userID)
+}
+
+// DeleteSavedSearch ユーザーが保存した検索を削除 (存在しない場合は dao.ErrSavedSearchNotFound)
+func (uc *FindUseCase) DeleteSavedSearch(ctx context.Context, userID, searchID string) error {
+	return uc.SavedSearchDAO.DeleteSavedSearch(ctx, userID, searchID)
+}
+
+// GetSavedSearchTimeline 保存した検索に、前回見たとき以降に一致した投稿を新しい順に返し、見た日時を更新する
+// 初めて見る場合は、最近の一致した投稿を返す
+// 新しい投稿が上限を超える場合は古いものから返し、残りは次回に返す
+func (uc *FindUseCase) GetSavedSearchTimeline(ctx context.Context, userID, searchID string) (*model.SavedSearchTimeline, error) {
+	saved, err := uc.SavedSearchDAO.GetSavedSearch(ctx, userID, searchID)
+	if err != nil {
+		return nil, err
+	}
+	query, err := search.ParseQuery(saved.Query)
+	if err != nil {
+		// 保存時に検証しているため、構文を変えた場合のみ起こる
+		return nil, fmt.Errorf("保存した検索のクエリを解析できません: %w", err)
+	}
+
+	// 検索中に投稿されたものを次回に取りこぼさないよう、検索を始める前の日時を見た日時にする
+	seenAt := time.Now()
+	posts, err := uc.FindDAO.FindNewPostsByQuery(ctx, query, userID, saved.LastSeenAt, savedSearchTimelineLimit)
+	if err != nil {
+		return nil, err
+	}
+	if saved.LastSeenAt != nil && len(posts) == savedSearchTimelineLimit {
+		// 返せなかった新しい投稿は、返した中で最も新しい投稿の後から次回に返す
+		seenAt = posts[len(posts)-1].CreatedAt
+	}
+
+	terms := query.PositiveTerms()
+	for i := range posts {
+		posts[i].Snippet, posts[i].Highlights = search.Snippet(posts[i].Content, terms)
+	}
+	sort.SliceStable(posts, func(i, j int) bool {
+		return posts[i].CreatedAt.After(posts[j].CreatedAt)
+	})
+	if posts == nil {
+		posts = []model.PostHit{}
+	}
+
+	if err := uc.SavedSearchDAO.MarkSavedSearchSeen(ctx, saved.SearchID, seenAt); err != nil {
+		return nil, err
+	}
+	return &model.SavedSearchTimeline{Search: *saved, Since: saved.LastSeenAt, Posts: posts}, nil
+}
+
+// ListSearchHistory ユーザーの検索履歴を新しい順に最大 limit 件取得
+func (uc *FindUseCase) ListSearchHistory(ctx context.Context, userID string, limit int) ([]model.SearchHistoryEntry, error) {
+	if limit <= 0 {
+		limit = defaultHistoryLimit
+	}
+	return uc.SavedSearchDAO.ListSearchHistory(ctx, userID, min(limit, searchHistoryKeep))
+}
+
+// DeleteSearchHistory 検索履歴から指定したクエリを削除 (履歴に無ければ dao.ErrSearchHistoryNotFound)
+func (uc *FindUseCase) DeleteSearchHistory(ctx context.Context, userID, query string) error {
+	return uc.SavedSearchDAO.DeleteSearchHistory(ctx, userID, strings.TrimSpace(query))
+}
+
+// ClearSearchHistory ユーザーの検索履歴を全て削除
+func (uc *FindUseCase) ClearSearchHistory(ctx context.Context, userID string) error {
+	return uc.SavedSearchDAO.ClearSearchHistory(ctx, userID)
+}
+
+// recordSearch ヘルパー関数: 検索履歴に追加する (失敗しても検索は続ける)
+func (uc *FindUseCase) recordSearch(ctx context.Context, userID, query string) {
+	if userID == "" {
+		return
+	}
+	if err := uc.SavedSearchDAO.RecordSearch(ctx, userID, strings.TrimSpace(query), time.Now(), searchHistoryKeep); err != nil {
+		log.Printf("[find_saved.go] 検索履歴の記録失敗 (user_id: %s): %v", userID, err)
+	}
+}
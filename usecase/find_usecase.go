@@ -1,32 +1,83 @@
 package usecase
 
 import (
-	"errors"
+	"context"
+	"sort"
+	"time"
 	"twitter/dao"
 	"twitter/model"
+	"twitter/search"
+	"twitter/validate"
+)
+
+// 全文検索に使う値
+const (
+	maxFindResults      = 100 // 返す検索結果の最大数
+	findCandidateFactor = 3   // 新しさで並べ替えるために、返す件数の何倍の候補を関連度順に取得するか
 )
 
 // FindUseCase 検索用のUseCase
 type FindUseCase struct {
-	FindDAO *dao.FindDAO
+	FindDAO        *dao.FindDAO
+	SavedSearchDAO *dao.SavedSearchDAO
 }
 
-func NewFindUseCase(findDAO *dao.FindDAO) *FindUseCase {
-	return &FindUseCase{FindDAO: findDAO}
+func NewFindUseCase(findDAO *dao.FindDAO, savedSearchDAO *dao.SavedSearchDAO) *FindUseCase {
+	return &FindUseCase{FindDAO: findDAO, SavedSearchDAO: savedSearchDAO}
 }
 
-// FindUsers 指定したキーワードを含むユーザーを検索
-func (uc *FindUseCase) FindUsers(key string) ([]model.User, error) {
-	if key == "" {
-		return nil, errors.New("[find_usecase.go] キーワードが空です")
+// FindUsers 指定したキーワードの全ての語を名前または自己紹介に含むユーザーを関連度の高い順に検索
+// 各ユーザーに一致箇所のスニペットを付ける
+func (uc *FindUseCase) FindUsers(ctx context.Context, key string) ([]model.UserHit, error) {
+	terms := search.Terms(key)
+	if len(terms) == 0 {
+		return nil, validate.Fail("key", "key に検索する語がありません")
+	}
+	users, err := uc.FindDAO.FindUsersByTerms(ctx, terms, maxFindResults)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range users {
+		// 名前に一致すれば名前を、そうでなければ自己紹介をスニペットにする
+		snippet, highlights := search.Snippet(users[i].Name, terms)
+		if len(highlights) == 0 && users[i].Bio != nil {
+			if bioSnippet, bioHighlights := search.Snippet(*users[i].Bio, terms); len(bioHighlights) > 0 {
+				snippet, highlights = bioSnippet, bioHighlights
+			}
+		}
+		users[i].Snippet = snippet
+		users[i].Highlights = highlights
 	}
-	return uc.FindDAO.FindUsersByKey(key)
+	return users, nil
 }
 
-// FindPosts 指定したキーワードを含む投稿を検索
-func (uc *FindUseCase) FindPosts(key string) ([]model.Post, error) {
-	if key == "" {
-		return nil, errors.New("[find_usecase.go] キーワードが空です")
+// FindPosts 検索クエリに一致する投稿を、関連度に新しさによる倍率を掛けたスコアの高い順に検索
+// クエリの構文は search.ParseQuery を参照し、形式が不正なら search.ErrInvalidQuery を返す
+// 各投稿に一致箇所のスニペットを付ける (viewerID は閲覧者のID、未ログインなら空)
+// ログインしていれば検索履歴に追加する
+func (uc *FindUseCase) FindPosts(ctx context.Context, key, viewerID string) ([]model.PostHit, error) {
+	query, err := search.ParseQuery(key)
+	if err != nil {
+		return nil, err
+	}
+	uc.recordSearch(ctx, viewerID, key)
+	terms := query.PositiveTerms()
+	posts, err := uc.FindDAO.FindPostsByQuery(ctx, query, viewerID, maxFindResults*findCandidateFactor)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	for i := range posts {
+		posts[i].Score *= search.RecencyBoost(posts[i].CreatedAt, now)
+		posts[i].Snippet, posts[i].Highlights = search.Snippet(posts[i].Content, terms)
+	}
+	sort.SliceStable(posts, func(i, j int) bool {
+		return posts[i].Score > posts[j].Score
+	})
+	if len(posts) > maxFindResults {
+		posts = posts[:maxFindResults]
 	}
-	return uc.FindDAO.FindPostsByKey(key)
+	return posts, nil
 }
@@ -0,0 +1,85 @@
+package usecase
+
+import (
+	"math"
+	"twitter/model"
+)
+
+// personalized PageRank のパラメータ
+const (
+	pageRankRestart    = 0.15 // 起点ユーザーに戻る確率
+	pageRankIterations = 20   // 反復回数
+)
+
+// followGraph フォロー関係の有向グラフ
+type followGraph struct {
+	following map[string][]string // ユーザー -> フォロー中のユーザー
+	followers map[string][]string // ユーザー -> フォロワー
+}
+
+// newFollowGraph ヘルパー関数: フォロー関係から除外ユーザーを除いたグラフを作成
+func newFollowGraph(follows []model.Follow, excluded map[string]bool) *followGraph {
+	g := &followGraph{following: make(map[string][]string), followers: make(map[string][]string)}
+	for _, f := range follows {
+		if f.UserID == f.FollowingUserID || excluded[f.UserID] || excluded[f.FollowingUserID] {
+			continue
+		}
+		g.following[f.UserID] = append(g.following[f.UserID], f.FollowingUserID)
+		g.followers[f.FollowingUserID] = append(g.followers[f.FollowingUserID], f.UserID)
+	}
+	return g
+}
+
+// degree 無向グラフとしての次数
+func (g *followGraph) degree(userID string) int {
+	return len(g.following[userID]) + len(g.followers[userID])
+}
+
+// friendsOfFriends 起点ユーザーのフォロー中ユーザーがフォローしているユーザーごとに、
+// 経由したユーザー数と Adamic-Adar スコア (経由ユーザーの次数の対数の逆数の和) を計算
+func (g *followGraph) friendsOfFriends(userID string) (map[string]int, map[string]float64) {
+	mutual := make(map[string]int)
+	adamicAdar := make(map[string]float64)
+	for _, friend := range g.following[userID] {
+		weight := 1 / math.Log(1+float64(g.degree(friend)))
+		for _, candidate := range g.following[friend] {
+			if candidate == userID {
+				continue
+			}
+			mutual[candidate]++
+			adamicAdar[candidate] += weight
+		}
+	}
+	return mutual, adamicAdar
+}
+
+// personalizedPageRank 起点ユーザーからフォロー方向に辿る personalized PageRank を計算
+func (g *followGraph) personalizedPageRank(userID string) map[string]float64 {
+	rank := map[string]float64{userID: 1}
+	for i := 0; i < pageRankIterations; i++ {
+		next := map[string]float64{userID: pageRankRestart}
+		for node, score := range rank {
+			out := g.following[node]
+			if len(out) == 0 {
+				// 行き先のないユーザーからは起点ユーザーに戻る
+				next[userID] += (1 - pageRankRestart) * score
+				continue
+			}
+			share := (1 - pageRankRestart) * score / float64(len(out))
+			for _, target := range out {
+				next[target] += share
+			}
+		}
+		rank = next
+	}
+	return rank
+}
+
+// maxValue ヘルパー関数: map の最大値を返す (空なら 0)
+func maxValue(m map[string]float64) float64 {
+	result := 0.0
+	for _, v := range m {
+		result = math.Max(result, v)
+	}
+	return result
+}
@@ -1,46 +1,50 @@
 package usecase
 
 import (
-	"errors"
+	"context"
 	"twitter/dao"
 	"twitter/model"
 )
 
 type FollowUseCase struct {
 	FollowDAO *dao.FollowDAO
+	Guard     *RestrictionGuard
 }
 
-func NewFollowUseCase(FollowDAO *dao.FollowDAO) *FollowUseCase {
-	return &FollowUseCase{FollowDAO: FollowDAO}
+func NewFollowUseCase(FollowDAO *dao.FollowDAO, guard *RestrictionGuard) *FollowUseCase {
+	return &FollowUseCase{FollowDAO: FollowDAO, Guard: guard}
 }
 
 // AddFollow 指定ユーザーをフォロー
-func (uc *FollowUseCase) AddFollow(userID, followingUserID string) error {
-	if userID == "" || followingUserID == "" {
-		return errors.New("[follow_usecase.go] user_id または following_user_id が無効: 必須項目")
+func (uc *FollowUseCase) AddFollow(ctx context.Context, userID, followingUserID string) error {
+	if err := validateFollow(userID, followingUserID); err != nil {
+		return err
 	}
-	return uc.FollowDAO.AddFollow(userID, followingUserID)
+	if err := uc.Guard.CheckNotSuspended(ctx, userID); err != nil {
+		return err
+	}
+	return uc.FollowDAO.AddFollow(ctx, userID, followingUserID)
 }
 
 // RemoveFollow 指定ユーザーのフォローを解除
-func (uc *FollowUseCase) RemoveFollow(userID, followingUserID string) error {
-	if userID == "" || followingUserID == "" {
-		return errors.New("[follow_usecase.go] user_id または following_user_id が無効: 必須項目")
+func (uc *FollowUseCase) RemoveFollow(ctx context.Context, userID, followingUserID string) error {
+	if err := validateFollow(userID, followingUserID); err != nil {
+		return err
 	}
-	return uc.FollowDAO.RemoveFollow(userID, followingUserID)
+	return uc.FollowDAO.RemoveFollow(ctx, userID, followingUserID)
 }
 
 // GetFollowers 指定ユーザーのフォロワー一覧を取得
-func (uc *FollowUseCase) GetFollowers(userID string) ([]model.User, error) {
-	return uc.FollowDAO.GetFollowers(userID)
+func (uc *FollowUseCase) GetFollowers(ctx context.Context, userID string) ([]model.User, error) {
+	return uc.FollowDAO.GetFollowers(ctx, userID)
 }
 
 // GetFollowing 指定ユーザーのフォロー中一覧を取得
-func (uc *FollowUseCase) GetFollowing(userID string) ([]model.User, error) {
-	return uc.FollowDAO.GetFollowing(userID)
+func (uc *FollowUseCase) GetFollowing(ctx context.Context, userID string) ([]model.User, error) {
+	return uc.FollowDAO.GetFollowing(ctx, userID)
 }
 
 // GetFollowGraph フォローグラフを取得
-func (uc *FollowUseCase) GetFollowGraph() ([]model.Follow, error) {
-	return uc.FollowDAO.GetFollowGraph()
+func (uc *FollowUseCase) GetFollowGraph(ctx context.Context) ([]model.Follow, error) {
+	return uc.FollowDAO.GetFollowGraph(ctx)
 }
@@ -0,0 +1,142 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"twitter/apperr"
+	"twitter/prompt"
+	"twitter/validate"
+)
+
+// 名前・自己紹介の候補生成に使う値
+const (
+	maxNameLen            = 15  // プロンプトで指定している名前の最大文字数
+	maxBioLen             = 150 // プロンプトで指定している自己紹介の最大文字数
+	defaultCandidateCount = 3
+	maxCandidateCount     = 8 // Gemini が一度に返せる候補数の上限
+)
+
+// ErrNoValidCandidate 文字数などの条件を満たす候補が1つも生成されなかった
+var ErrNoValidCandidate = apperr.New(apperr.KindUpstream, "no_valid_candidate", "条件を満たす候補を生成できませんでした。もう一度お試しください")
+
+// CandidateGeneration 生成された候補と、生成に使ったプロンプトテンプレート
+type CandidateGeneration struct {
+	Candidates []string    `json:"candidates"`
+	Prompt     prompt.Info `json:"prompt"`
+}
+
+// GenerateNameCandidates 過去ツイートと指示から名前の候補を複数生成
+func (uc *GeminiUseCase) GenerateNameCandidates(ctx context.Context, authID, instruction, locale string, count int) (*CandidateGeneration, error) {
+	data, err := uc.profilePromptData(ctx, authID, instruction)
+	if err != nil {
+		return nil, err
+	}
+	return uc.generateCandidates(ctx, prompt.GenerateName, locale, authID, data, count, maxNameLen, true)
+}
+
+// GenerateBioCandidates 過去ツイートと指示から自己紹介の候補を複数生成
+func (uc *GeminiUseCase) GenerateBioCandidates(ctx context.Context, authID, instruction, locale string, count int) (*CandidateGeneration, error) {
+	data, err := uc.profilePromptData(ctx, authID, instruction)
+	if err != nil {
+		return nil, err
+	}
+	return uc.generateCandidates(ctx, prompt.GenerateBio, locale, authID, data, count, maxBioLen, false)
+}
+
+// RefineName 以前の名前の候補とユーザーの要望から名前を考え直した候補を複数生成
+func (uc *GeminiUseCase) RefineName(ctx context.Context, authID, previous, feedback, locale string, count int) (*CandidateGeneration, error) {
+	data, err := uc.refinePromptData(ctx, authID, previous, feedback, maxNameLen)
+	if err != nil {
+		return nil, err
+	}
+	return uc.generateCandidates(ctx, prompt.RefineName, locale, authID, data, count, maxNameLen, true)
+}
+
+// RefineBio 以前の自己紹介の候補とユーザーの要望から書き直した候補を複数生成
+func (uc *GeminiUseCase) RefineBio(ctx context.Context, authID, previous, feedback, locale string, count int) (*CandidateGeneration, error) {
+	data, err := uc.refinePromptData(ctx, authID, previous, feedback, maxBioLen)
+	if err != nil {
+		return nil, err
+	}
+	return uc.generateCandidates(ctx, prompt.RefineBio, locale, authID, data, count, maxBioLen, false)
+}
+
+// refinePromptData 候補の修正のプロンプトの変数を作成
+func (uc *GeminiUseCase) refinePromptData(ctx context.Context, authID, previous, feedback string, maxLen int) (map[string]interface{}, error) {
+	limits := uc.prompts.Limits()
+	if err := validate.CheckAs(ErrInvalidPromptInput,
+		validate.Length("previous", previous, 1, maxLen),
+		validate.Length("feedback", feedback, 1, limits.MaxInstructionLen),
+	); err != nil {
+		return nil, err
+	}
+	if prompt.DetectInjection(previous) || prompt.DetectInjection(feedback) {
+		return nil, ErrInvalidPromptInput.Detailf("システムの指示を変更しようとする入力は使えません")
+	}
+
+	tweets, err := uc.fetchContextTweets(ctx, authID)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"Tweets":   tweets,
+		"Previous": previous,
+		"Feedback": feedback,
+	}, nil
+}
+
+// generateCandidates テンプレートからプロンプトを作成して複数候補を生成し、条件を満たす候補だけを重複なく返す
+func (uc *GeminiUseCase) generateCandidates(ctx context.Context, templateID, locale, targetID string, data map[string]interface{}, count, maxLen int, singleLine bool) (*CandidateGeneration, error) {
+	if count <= 0 {
+		count = defaultCandidateCount
+	}
+	count = min(count, maxCandidateCount)
+	if err := uc.quota.Check(ctx, targetID); err != nil {
+		return nil, err
+	}
+
+	rendered, err := uc.prompts.Render(templateID, locale, targetID, data)
+	if err != nil {
+		return nil, fmt.Errorf("プロンプトの作成失敗: %w", err)
+	}
+
+	texts, usage, err := uc.geminiDAO.GenerateCandidatesFromPrompt(ctx, rendered.Text, count)
+	if err != nil {
+		return nil, err
+	}
+	uc.quota.Record(ctx, targetID, templateID, usage)
+	uc.recordGeneration(ctx, targetID, rendered.Info)
+
+	candidates := cleanCandidates(texts, maxLen, singleLine)
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("%w (生成数: %d)", ErrNoValidCandidate, len(texts))
+	}
+	return &CandidateGeneration{Candidates: candidates, Prompt: rendered.Info}, nil
+}
+
+// cleanCandidates ヘルパー関数: 前後の空白や括弧を取り除き、空・文字数超過・'#' を含む・重複する候補を除く
+// singleLine なら改行を含む候補も除く
+func cleanCandidates(texts []string, maxLen int, singleLine bool) []string {
+	seen := make(map[string]bool)
+	candidates := []string{}
+	for _, text := range texts {
+		text = strings.TrimSpace(text)
+		text = strings.Trim(text, "\"'「」『』“”")
+		text = strings.TrimSpace(text)
+
+		if text == "" || prompt.RuneLen(text) > maxLen || strings.ContainsAny(text, "#＃") {
+			continue
+		}
+		if singleLine && strings.ContainsAny(text, "\r\n") {
+			continue
+		}
+		key := strings.ToLower(strings.Join(strings.Fields(text), " "))
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		candidates = append(candidates, text)
+	}
+	return candidates
+}
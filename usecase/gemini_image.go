@@ -0,0 +1,83 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"twitter/model"
+	"twitter/prompt"
+)
+
+// imageCategories 画像が不適切と判定された理由として認める分類
+var imageCategories = map[string]bool{
+	"sexual":    true,
+	"violence":  true,
+	"hate":      true,
+	"illegal":   true,
+	"self_harm": true,
+}
+
+// imageSubjects プロンプトのロケールごとの画像の種類の説明
+var imageSubjects = map[string]map[string]string{
+	model.ImageKindPost:    {"ja": "SNSの投稿に添付された画像", "en": "an image attached to a post"},
+	model.ImageKindProfile: {"ja": "ユーザーのプロフィール画像", "en": "a user's profile picture"},
+}
+
+// ImageVerdict 画像の代替テキストと安全性の判定
+type ImageVerdict struct {
+	AltText    string
+	IsUnsafe   bool
+	Categories []string
+}
+
+// AnalyzeImage 画像の代替テキストを生成し、良識に反する内容を含むか判定する
+// 画像の解析はユーザーに紐づけず、全体の上限のみ適用する (targetID は記録に使う投稿IDまたはユーザーID)
+func (uc *GeminiUseCase) AnalyzeImage(ctx context.Context, kind, targetID, mimeType string, image []byte, locale string) (*ImageVerdict, error) {
+	subjects, ok := imageSubjects[kind]
+	if !ok {
+		return nil, fmt.Errorf("画像の種類が不正です: %s", kind)
+	}
+	subject, ok := subjects[locale]
+	if !ok {
+		subject = subjects[prompt.DefaultLocale]
+	}
+
+	rendered, err := uc.prompts.Render(prompt.AnalyzeImage, locale, targetID, map[string]interface{}{"Subject": subject})
+	if err != nil {
+		return nil, fmt.Errorf("プロンプトの作成失敗: %w", err)
+	}
+	if err := uc.quota.Check(ctx, ""); err != nil {
+		return nil, err
+	}
+	text, usage, err := uc.geminiDAO.GenerateJSONFromImage(ctx, rendered.Text, mimeType, image)
+	if err != nil {
+		return nil, err
+	}
+	uc.quota.Record(ctx, "", prompt.AnalyzeImage, usage)
+	uc.recordGeneration(ctx, targetID, rendered.Info)
+
+	return parseImageVerdict(text)
+}
+
+// parseImageVerdict ヘルパー関数: Gemini の JSON の応答を判定結果に変換する (想定外の分類は除く)
+func parseImageVerdict(text string) (*ImageVerdict, error) {
+	var resp struct {
+		AltText    string   `json:"alt_text"`
+		Unsafe     bool     `json:"unsafe"`
+		Categories []string `json:"categories"`
+	}
+	text = strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(text), "```json"), "```"))
+	if err := json.Unmarshal([]byte(text), &resp); err != nil {
+		return nil, fmt.Errorf("%w: 画像の解析結果が JSON ではありません: %v", ErrAIEmptyResponse, err)
+	}
+
+	verdict := &ImageVerdict{AltText: strings.TrimSpace(resp.AltText), IsUnsafe: resp.Unsafe, Categories: []string{}}
+	for _, category := range resp.Categories {
+		category = strings.ToLower(strings.TrimSpace(category))
+		if imageCategories[category] {
+			verdict.Categories = append(verdict.Categories, category)
+		}
+	}
+	return verdict, nil
+}
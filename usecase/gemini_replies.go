@@ -0,0 +1,189 @@
+package usecase
+
+import (
+	"cloud.google.com/go/vertexai/genai"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+	"twitter/model"
+	"twitter/prompt"
+)
+
+// 返信の提案に使う値
+const (
+	maxReplyLen       = 140 // 返信の候補の最大文字数
+	maxReplyAncestors = 50  // 文脈に使う親の投稿をたどる深さの上限
+)
+
+// replyTones 返信の口調と、プロンプトのロケールごとの説明
+var replyTones = map[string]map[string]string{
+	"friendly":   {"ja": "親しみやすくカジュアル", "en": "friendly and casual"},
+	"polite":     {"ja": "丁寧で礼儀正しい", "en": "polite and respectful"},
+	"humorous":   {"ja": "ユーモアのある", "en": "humorous"},
+	"empathetic": {"ja": "相手に共感する", "en": "empathetic"},
+	"curious":    {"ja": "質問で会話を広げる", "en": "asking a question to keep the conversation going"},
+}
+
+// defaultReplyTones 口調を指定しない場合に提案する口調
+var defaultReplyTones = []string{"friendly", "polite", "humorous", "empathetic"}
+
+// ReplySuggestion 返信の候補
+type ReplySuggestion struct {
+	Tone string `json:"tone"`
+	Text string `json:"text"`
+}
+
+// ReplySuggestions 投稿への返信の候補と、生成に使ったテンプレート
+type ReplySuggestions struct {
+	PostID      string            `json:"post_id"`
+	Suggestions []ReplySuggestion `json:"suggestions"`
+	Removed     int               `json:"removed"` // 文字数や検査で除いた候補の数
+	Prompt      prompt.Info       `json:"prompt"`
+	Cached      bool              `json:"-"`
+}
+
+// SuggestReplies 投稿とその親の投稿、返信するユーザーの過去ツイートの書き方から、口調の異なる短い返信の候補を生成する
+// 候補は投稿検査と同じ基準で検査し、良識に反するものは除く
+// tones が空ならデフォルトの口調で提案する
+func (uc *GeminiUseCase) SuggestReplies(ctx context.Context, postID, authID, instruction string, tones []string, locale string) (*ReplySuggestions, error) {
+	if authID == "" {
+		return nil, ErrInvalidPromptInput.Detailf("auth_id は必須項目")
+	}
+	if err := uc.validatePromptInputs(instruction, ""); err != nil {
+		return nil, err
+	}
+	if len(tones) == 0 {
+		tones = defaultReplyTones
+	}
+	var toneLines []string
+	for _, tone := range tones {
+		descriptions, ok := replyTones[tone]
+		if !ok {
+			return nil, ErrInvalidPromptInput.Detailf("口調 %q には対応していません (friendly, polite, humorous, empathetic, curious のいずれか)", tone)
+		}
+		description, ok := descriptions[locale]
+		if !ok {
+			description = descriptions[prompt.DefaultLocale]
+		}
+		toneLines = append(toneLines, tone+": "+description)
+	}
+
+	// 文脈のトークン予算は会話と過去ツイートで半分ずつ使う
+	budget := uc.prompts.Limits().ContextTokenBudget / 2
+	thread, target, err := uc.replyThread(ctx, postID, authID, budget)
+	if err != nil {
+		return nil, err
+	}
+	tweets, err := uc.fetchContextTweets(ctx, authID)
+	if err != nil {
+		return nil, err
+	}
+
+	generation, err := uc.generate(ctx, authID, prompt.SuggestReplies, locale, authID, map[string]interface{}{
+		"Thread":      thread,
+		"Post":        strings.Join(strings.Fields(target.Content), " "),
+		"Tweets":      prompt.SelectWithinBudget(tweets, budget),
+		"Tones":       toneLines,
+		"Instruction": instruction,
+	})
+	if err != nil {
+		return nil, err
+	}
+	text, ok := (*generation.Part).(genai.Text)
+	if !ok {
+		return nil, ErrAIEmptyResponse
+	}
+
+	suggestions, err := parseReplySuggestions(string(text), tones)
+	if err != nil {
+		return nil, err
+	}
+	safe := uc.moderateReplies(ctx, postID, suggestions, locale)
+	if len(safe) == 0 {
+		return nil, fmt.Errorf("%w (生成数: %d)", ErrNoValidCandidate, len(suggestions))
+	}
+	return &ReplySuggestions{
+		PostID:      postID,
+		Suggestions: safe,
+		Removed:     len(tones) - len(safe),
+		Prompt:      generation.Prompt,
+		Cached:      generation.Cached,
+	}, nil
+}
+
+// replyThread ヘルパー関数: 返信先の投稿と、その親の投稿を根に向かってたどった会話の行を古い順に返す
+// 返信先に近い投稿から、推定トークン数の合計が budget に収まるだけ使う
+func (uc *GeminiUseCase) replyThread(ctx context.Context, postID, viewerID string, budget int) ([]string, *model.Post, error) {
+	// 閲覧者から見えない、または不適切と判定された投稿には返信を提案しない (ErrPostNotFound)
+	target, ancestors, err := uc.geminiDAO.FetchPostWithAncestors(ctx, postID, viewerID, maxReplyAncestors)
+	if err != nil {
+		return nil, nil, fmt.Errorf("会話の取得失敗: %w", err)
+	}
+	lines, _ := selectPostsWithinBudget(ancestors, budget)
+	slices.Reverse(lines)
+	return lines, target, nil
+}
+
+// moderateReplies ヘルパー関数: 返信の候補を投稿検査と同じテンプレートで並行して検査し、問題の無いものだけを返す
+// 検査に失敗した候補も除く
+func (uc *GeminiUseCase) moderateReplies(ctx context.Context, postID string, suggestions []ReplySuggestion, locale string) []ReplySuggestion {
+	safe := make([]bool, len(suggestions))
+	var wg sync.WaitGroup
+	for i, suggestion := range suggestions {
+		if prompt.DetectInjection(suggestion.Text) {
+			continue
+		}
+		wg.Add(1)
+		go func(i int, text string) {
+			defer wg.Done()
+			// 投稿検査と同じくユーザーに紐づけず、全体の上限のみ適用する
+			generation, err := uc.generate(ctx, "", prompt.CheckIsBad, locale, postID, map[string]interface{}{"Content": text})
+			if err != nil {
+				log.Printf("[gemini_replies.go] 返信の候補の検査失敗 (post_id: %s): %v", postID, err)
+				return
+			}
+			verdict, ok := (*generation.Part).(genai.Text)
+			answer := strings.ToUpper(string(verdict))
+			safe[i] = ok && strings.Contains(answer, "NO") && !strings.Contains(answer, "YES")
+		}(i, suggestion.Text)
+	}
+	wg.Wait()
+
+	var result []ReplySuggestion
+	for i, suggestion := range suggestions {
+		if safe[i] {
+			result = append(result, suggestion)
+		}
+	}
+	return result
+}
+
+// parseReplySuggestions ヘルパー関数: Gemini の JSON の応答を返信の候補に変換する
+// 指定していない口調・文字数を超える・重複する候補は除く
+func parseReplySuggestions(text string, tones []string) ([]ReplySuggestion, error) {
+	var parsed []ReplySuggestion
+	text = strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(text), "```json"), "```"))
+	if err := json.Unmarshal([]byte(text), &parsed); err != nil {
+		return nil, fmt.Errorf("%w: 返信の候補が JSON ではありません: %v", ErrAIEmptyResponse, err)
+	}
+
+	seen := make(map[string]bool)
+	var suggestions []ReplySuggestion
+	for _, suggestion := range parsed {
+		tone := strings.ToLower(strings.TrimSpace(suggestion.Tone))
+		if !slices.Contains(tones, tone) || seen[tone] {
+			continue
+		}
+		cleaned := cleanCandidates([]string{suggestion.Text}, maxReplyLen, false)
+		if len(cleaned) == 0 {
+			continue
+		}
+		seen[tone] = true
+		suggestions = append(suggestions, ReplySuggestion{Tone: tone, Text: cleaned[0]})
+	}
+	return suggestions, nil
+}
@@ -0,0 +1,141 @@
+package usecase
+
+import (
+	"cloud.google.com/go/vertexai/genai"
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"twitter/model"
+	"twitter/prompt"
+	"twitter/validate"
+)
+
+// 要約に使う値
+const (
+	maxThreadPosts      = 200                // 会話の要約で取得する投稿数の上限
+	maxDigestPosts      = 100                // タイムラインの要約で取得する投稿数の上限
+	defaultDigestPeriod = 24 * time.Hour     // since を指定しない場合に遡る期間
+	maxDigestPeriod     = 7 * 24 * time.Hour // 遡れる期間の上限
+)
+
+// postIDPattern 要約の中で引用された投稿ID (ULID) を探す
+var postIDPattern = regexp.MustCompile(`[0-9A-HJKMNP-TV-Z]{26}`)
+
+// SummarizeThread 投稿が属する会話 (根の投稿とその全ての返信) を要約し、根拠となる投稿IDを返す
+// 投稿はトークン数の上限に収まるだけ根の投稿から古い順に使う
+// viewerID は閲覧者のID (見えない投稿者の投稿を除くのと、使用量の上限の確認に使う)
+func (uc *GeminiUseCase) SummarizeThread(ctx context.Context, postID, viewerID, locale string) (*model.ThreadSummary, *Generation, error) {
+	posts, rootID, err := uc.geminiDAO.FetchConversation(ctx, postID, viewerID, maxThreadPosts)
+	if err != nil {
+		return nil, nil, fmt.Errorf("会話の取得失敗: %w", err)
+	}
+
+	result := &model.ThreadSummary{PostID: postID, RootPostID: rootID}
+	// 会話の要約は根の投稿IDで無効化できるようにする (返信が増えればプロンプトが変わり別のキーになる)
+	generation, err := uc.summarize(ctx, &result.Summary, posts, viewerID, prompt.SummarizeThread, locale, rootID, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	return result, generation, nil
+}
+
+// DigestTimeline フォロー中のユーザーが since 以降に投稿した内容を、反応の多い順にトークン数の上限に収まるだけ要約する
+// since がゼロ値なら24時間前からで、7日より前は7日前に切り詰める
+// 見逃した投稿が無ければ生成せずに空の要約を返す (Generation は nil)
+func (uc *GeminiUseCase) DigestTimeline(ctx context.Context, authID string, since time.Time, locale string) (*model.TimelineDigest, *Generation, error) {
+	if err := validate.Check(validate.Required("auth_id", authID)); err != nil {
+		return nil, nil, err
+	}
+	now := time.Now()
+	if since.IsZero() {
+		since = now.Add(-defaultDigestPeriod)
+	}
+	if since.After(now) {
+		return nil, nil, ErrInvalidPromptInput.Detailf("since に未来の日時は指定できません")
+	}
+	if earliest := now.Add(-maxDigestPeriod); since.Before(earliest) {
+		since = earliest
+	}
+
+	posts, err := uc.geminiDAO.FetchMissedTimelinePosts(ctx, authID, since, maxDigestPosts)
+	if err != nil {
+		return nil, nil, fmt.Errorf("見逃した投稿の取得失敗: %w", err)
+	}
+
+	result := &model.TimelineDigest{UserID: authID, Since: since}
+	generation, err := uc.summarize(ctx, &result.Summary, posts, authID, prompt.DigestTimeline, locale, authID, map[string]interface{}{
+		"Since": since.In(time.UTC).Format("2006-01-02 15:04 UTC"),
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return result, generation, nil
+}
+
+// summarize 投稿をトークン数の上限に収まるだけプロンプトに入れて要約し、summary に結果と引用された投稿IDを書き込む
+// 使える投稿が無ければ生成しない (Generation は nil)
+func (uc *GeminiUseCase) summarize(ctx context.Context, summary *model.Summary, posts []model.Post, userID, templateID, locale, targetID string, data map[string]interface{}) (*Generation, error) {
+	lines, included := selectPostsWithinBudget(posts, uc.prompts.Limits().ContextTokenBudget)
+	summary.PostCount = len(posts)
+	summary.SummarizedCount = len(lines)
+	summary.CitedPostIDs = []string{}
+	if len(lines) == 0 {
+		return nil, nil
+	}
+
+	if data == nil {
+		data = make(map[string]interface{})
+	}
+	data["Posts"] = lines
+	generation, err := uc.generate(ctx, userID, templateID, locale, targetID, data)
+	if err != nil {
+		return nil, err
+	}
+	text, ok := (*generation.Part).(genai.Text)
+	if !ok || strings.TrimSpace(string(text)) == "" {
+		return nil, ErrAIEmptyResponse
+	}
+
+	summary.Summary = strings.TrimSpace(string(text))
+	summary.CitedPostIDs = citedPostIDs(summary.Summary, included)
+	return generation, nil
+}
+
+// selectPostsWithinBudget ヘルパー関数: 投稿を並び順のまま推定トークン数の合計が budget に収まるだけ選び、
+// 「[投稿ID] @ユーザーID: 内容」の形式の行と、選んだ投稿IDの集合を返す
+// 空の投稿・指示の上書きを試みる投稿は除き、長すぎる投稿は飛ばしてより短い投稿で残りを埋める
+func selectPostsWithinBudget(posts []model.Post, budget int) ([]string, map[string]bool) {
+	var lines []string
+	included := make(map[string]bool)
+	used := 0
+	for _, post := range posts {
+		content := strings.Join(strings.Fields(post.Content), " ")
+		if content == "" || prompt.DetectInjection(post.Content) {
+			continue
+		}
+		line := fmt.Sprintf("[%s] @%s: %s", post.PostID, post.UserID, content)
+		tokens := prompt.EstimateTokens(line)
+		if used+tokens > budget {
+			continue
+		}
+		lines = append(lines, line)
+		included[post.PostID] = true
+		used += tokens
+	}
+	return lines, included
+}
+
+// citedPostIDs ヘルパー関数: 要約の中で引用された投稿IDのうち、要約に使った投稿のものだけを出現順に重複なく返す
+func citedPostIDs(text string, included map[string]bool) []string {
+	cited := []string{}
+	seen := make(map[string]bool)
+	for _, id := range postIDPattern.FindAllString(text, -1) {
+		if included[id] && !seen[id] {
+			seen[id] = true
+			cited = append(cited, id)
+		}
+	}
+	return cited
+}
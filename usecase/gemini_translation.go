@@ -0,0 +1,105 @@
+package usecase
+
+import (
+	"cloud.google.com/go/vertexai/genai"
+	"context"
+	"fmt"
+	"strings"
+	"twitter/apperr"
+	"twitter/model"
+	"twitter/prompt"
+	"unicode"
+)
+
+// ErrUnsupportedLanguage 翻訳先の言語に対応していない
+var ErrUnsupportedLanguage = apperr.Validation("unsupported_language", "対応していない言語です")
+
+// languageNames 翻訳に対応している言語と、プロンプトのロケールごとの言語名
+var languageNames = map[string]map[string]string{
+	"ja": {"ja": "日本語", "en": "Japanese"},
+	"en": {"ja": "英語", "en": "English"},
+	"ko": {"ja": "韓国語", "en": "Korean"},
+	"zh": {"ja": "中国語", "en": "Chinese"},
+}
+
+// TranslatePost 投稿を指定した言語に翻訳する (元の言語と同じなら翻訳せずに返す)
+// 翻訳結果は投稿の内容と翻訳先の言語ごとにキャッシュし、投稿が編集されると無効化される
+// viewerID は使用量の上限の確認に使う (未ログインなら空で、全体の上限のみ)
+// 翻訳した場合は生成に使ったテンプレートなどを Generation で返す (翻訳しなかった場合は nil)
+func (uc *GeminiUseCase) TranslatePost(ctx context.Context, postID, targetLanguage, viewerID, locale string) (*model.PostTranslation, *Generation, error) {
+	targetLanguage = strings.ToLower(targetLanguage)
+	if _, ok := languageNames[targetLanguage]; !ok {
+		return nil, nil, ErrUnsupportedLanguage.Detailf("%q (ja, en, ko, zh のいずれかを指定してください)", targetLanguage)
+	}
+
+	content, err := uc.geminiDAO.GetPostContent(ctx, postID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("投稿内容の取得失敗: %w", err)
+	}
+
+	translation := &model.PostTranslation{
+		PostID:         postID,
+		SourceLanguage: detectLanguage(content),
+		TargetLanguage: targetLanguage,
+		Content:        content,
+	}
+	if translation.SourceLanguage == targetLanguage || translation.SourceLanguage == "" {
+		return translation, nil, nil
+	}
+
+	// キャッシュキーはプロンプトの内容 (投稿の内容と翻訳先の言語を含む) から作り、投稿IDで無効化する
+	generation, err := uc.generate(ctx, viewerID, prompt.TranslatePost, locale, postID, map[string]interface{}{
+		"Content":        prompt.TruncateToBudget(content, uc.prompts.Limits().ContextTokenBudget),
+		"SourceLanguage": languageName(translation.SourceLanguage, locale),
+		"TargetLanguage": languageName(targetLanguage, locale),
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	text, ok := (*generation.Part).(genai.Text)
+	if !ok || strings.TrimSpace(string(text)) == "" {
+		return nil, nil, ErrAIEmptyResponse
+	}
+
+	translation.Content = strings.TrimSpace(string(text))
+	translation.Translated = true
+	return translation, generation, nil
+}
+
+// detectLanguage ヘルパー関数: 使われている文字から言語を推定する (かな → ja, ハングル → ko, 漢字のみ → zh, ラテン文字 → en)
+// 文字が無い (絵文字・記号のみ) 場合は空文字列
+func detectLanguage(text string) string {
+	kana, han, hangul, latin := 0, 0, 0, 0
+	for _, r := range text {
+		switch {
+		case unicode.In(r, unicode.Hiragana, unicode.Katakana):
+			kana++
+		case unicode.Is(unicode.Han, r):
+			han++
+		case unicode.Is(unicode.Hangul, r):
+			hangul++
+		case unicode.Is(unicode.Latin, r):
+			latin++
+		}
+	}
+
+	switch {
+	case kana > 0:
+		return "ja"
+	case hangul > 0 && hangul >= latin:
+		return "ko"
+	case han > 0 && han >= latin:
+		return "zh"
+	case latin > 0:
+		return "en"
+	}
+	return ""
+}
+
+// languageName ヘルパー関数: プロンプトのロケールでの言語名を返す
+func languageName(language, locale string) string {
+	if name, ok := languageNames[language][locale]; ok {
+		return name
+	}
+	return languageNames[language][prompt.DefaultLocale]
+}
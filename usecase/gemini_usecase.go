@@ -2,122 +2,456 @@ package usecase
 
 import (
 	"cloud.google.com/go/vertexai/genai"
+	"context"
 	"fmt"
-	"strings"
+	"github.com/oklog/ulid"
+	"log"
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+	"twitter/apperr"
 	"twitter/dao"
+	"twitter/model"
+	"twitter/prompt"
+	"twitter/validate"
 )
 
+// おすすめユーザーの順位付けに使う値
+const (
+	maxRecommendLimit      = 50  // 返すおすすめユーザーの最大数
+	maxLazyEmbeddings      = 20  // 1回の推薦で新たに埋め込みを計算する最大ユーザー数
+	maxRecommendCandidates = 500 // 1回の推薦で順位付けする候補の最大数
+	similarityWeight       = 0.7 // 埋め込みの類似度の重み
+	graphWeight            = 0.3 // フォローグラフのスコアの重み
+	similarReasonThreshold = 0.5 // 「傾向が近い」と説明する類似度の下限
+)
+
+// maxContextPosts 文脈の候補として読み込む最近の投稿数の上限
+const maxContextPosts = 200
+
+// ErrInvalidPromptInput プロンプトに入れるユーザー入力が不正
+var ErrInvalidPromptInput = apperr.Validation("invalid_prompt_input", "プロンプトの入力が不正です")
+
+// Vertex AI 呼び出しの失敗の種類 (コントローラでステータスコードに変換する)
+var (
+	ErrAISafetyBlocked = dao.ErrAISafetyBlocked // 安全性フィルタによるブロック
+	ErrAIEmptyResponse = dao.ErrAIEmptyResponse // 空の応答
+	ErrAIUpstream      = dao.ErrAIUpstream      // 再試行しても失敗した
+	ErrAITimeout       = dao.ErrAITimeout       // 期限切れ
+	ErrAIUnavailable   = dao.ErrAIUnavailable   // 障害中のため呼び出さなかった (CircuitOpenError)
+)
+
+// ErrPostNotFound 投稿が存在しない、または削除されている
+var ErrPostNotFound = dao.ErrPostNotFound
+
+// CircuitOpenError 障害中のため呼び出さずに失敗した (RetryAfter 後に再試行できる)
+type CircuitOpenError = dao.CircuitOpenError
+
 type GeminiUseCase struct {
-	geminiDAO *dao.GeminiDAO
+	geminiDAO    *dao.GeminiDAO
+	embeddingDAO *dao.EmbeddingDAO
+	prompts      *prompt.Registry
+	quota        *QuotaUseCase
+	cache        *GenerationCache
+}
+
+func NewGeminiUseCase(geminiDAO *dao.GeminiDAO, embeddingDAO *dao.EmbeddingDAO, prompts *prompt.Registry, quota *QuotaUseCase, cache *GenerationCache) *GeminiUseCase {
+	return &GeminiUseCase{geminiDAO: geminiDAO, embeddingDAO: embeddingDAO, prompts: prompts, quota: quota, cache: cache}
+}
+
+// Generation 生成結果と、生成に使ったプロンプトテンプレート
+type Generation struct {
+	Part   *genai.Part
+	Prompt prompt.Info
+	Cached bool // キャッシュした結果を返したか
 }
 
-func NewGeminiUseCase(geminiDAO *dao.GeminiDAO) *GeminiUseCase {
-	return &GeminiUseCase{geminiDAO: geminiDAO}
+// StreamResult ストリーミング生成の完了時の情報
+type StreamResult struct {
+	Prompt prompt.Info            `json:"prompt"`
+	Usage  *model.GenerationUsage `json:"usage"`
 }
 
 // GenerateBio 過去ツイートと指示から自己紹介を生成
-func (uc *GeminiUseCase) GenerateBio(authID, instruction string) (*genai.Part, error) {
-	tweets, err := uc.geminiDAO.FetchUserPostContents(authID)
+func (uc *GeminiUseCase) GenerateBio(ctx context.Context, authID, instruction, locale string) (*Generation, error) {
+	data, err := uc.profilePromptData(ctx, authID, instruction)
 	if err != nil {
-		return nil, fmt.Errorf("過去ツイートの取得失敗: %w", err)
+		return nil, err
 	}
+	return uc.generate(ctx, authID, prompt.GenerateBio, locale, authID, data)
+}
 
-	prompt := "以下のツイート内容をもとに、Twitterの自己紹介文を日本語で150字以内で生成してください。'#'はつけないでください。"
-	prompt += "\nツイート内容:\n" + strings.Join(tweets, "\n")
-	if instruction != "" {
-		prompt += fmt.Sprintf(" 追加の指示: %s", instruction)
+// StreamBio 過去ツイートと指示から自己紹介を生成し、生成されたテキストを届いた順に onText に渡す
+func (uc *GeminiUseCase) StreamBio(ctx context.Context, authID, instruction, locale string, onText func(string) error) (*StreamResult, error) {
+	data, err := uc.profilePromptData(ctx, authID, instruction)
+	if err != nil {
+		return nil, err
 	}
-
-	return uc.geminiDAO.GenerateResponseFromPrompt(prompt)
+	return uc.stream(ctx, prompt.GenerateBio, locale, authID, data, onText)
 }
 
 // GenerateName 過去ツイートと指示から名前を生成
-func (uc *GeminiUseCase) GenerateName(authID, instruction string) (*genai.Part, error) {
-	tweets, err := uc.geminiDAO.FetchUserPostContents(authID)
+func (uc *GeminiUseCase) GenerateName(ctx context.Context, authID, instruction, locale string) (*Generation, error) {
+	data, err := uc.profilePromptData(ctx, authID, instruction)
 	if err != nil {
-		return nil, fmt.Errorf("過去ツイートの取得失敗: %w", err)
+		return nil, err
 	}
+	return uc.generate(ctx, authID, prompt.GenerateName, locale, authID, data)
+}
 
-	prompt := "以下のツイート内容をもとに、Twitterの名前を日本語で15字以内で1つだけ生成してください。"
-	prompt += "\nツイート内容:\n" + strings.Join(tweets, "\n")
-	if instruction != "" {
-		prompt += fmt.Sprintf(" 追加の指示: %s", instruction)
+// StreamName 過去ツイートと指示から名前を生成し、生成されたテキストを届いた順に onText に渡す
+func (uc *GeminiUseCase) StreamName(ctx context.Context, authID, instruction, locale string, onText func(string) error) (*StreamResult, error) {
+	data, err := uc.profilePromptData(ctx, authID, instruction)
+	if err != nil {
+		return nil, err
 	}
-
-	return uc.geminiDAO.GenerateResponseFromPrompt(prompt)
+	return uc.stream(ctx, prompt.GenerateName, locale, authID, data, onText)
 }
 
 // GenerateTweetContinuation 過去ツイート、指示、現在の入力からツイートの続きを生成
-func (uc *GeminiUseCase) GenerateTweetContinuation(authID, instruction, tempText string) (*genai.Part, error) {
-	tweets, err := uc.geminiDAO.FetchUserPostContents(authID)
+func (uc *GeminiUseCase) GenerateTweetContinuation(ctx context.Context, authID, instruction, tempText, locale string) (*Generation, error) {
+	data, err := uc.tweetPromptData(ctx, authID, instruction, tempText)
 	if err != nil {
-		return nil, fmt.Errorf("過去ツイートの取得失敗: %w", err)
+		return nil, err
 	}
+	return uc.generate(ctx, authID, prompt.GenerateTweetContinuation, locale, authID, data)
+}
 
-	// プロンプト作成
-	prompt := "以下のツイート内容を基に、Twitterの新しいツイートを合計200字以内で生成してください。'#'はつけないでください。"
-	prompt += "\n過去のツイート内容:\n" + strings.Join(tweets, "\n")
-	prompt += "過去のツイートがタメ口中心ならタメ口中心、敬語中心なら敬語中心にしてください。"
-	if instruction != "" {
-		prompt += fmt.Sprintf(" 追加の指示: %s", instruction)
+// StreamTweetContinuation 過去ツイート、指示、現在の入力からツイートの続きを生成し、生成されたテキストを届いた順に onText に渡す
+func (uc *GeminiUseCase) StreamTweetContinuation(ctx context.Context, authID, instruction, tempText, locale string, onText func(string) error) (*StreamResult, error) {
+	data, err := uc.tweetPromptData(ctx, authID, instruction, tempText)
+	if err != nil {
+		return nil, err
 	}
-	if tempText != "" {
-		prompt += " 現在のツイートの続きを生成してください:"
-		prompt += fmt.Sprintf("\n現在のツイート: %s", tempText)
+	return uc.stream(ctx, prompt.GenerateTweetContinuation, locale, authID, data, onText)
+}
+
+// profilePromptData 名前・自己紹介生成のプロンプトの変数を作成
+func (uc *GeminiUseCase) profilePromptData(ctx context.Context, authID, instruction string) (map[string]interface{}, error) {
+	if err := uc.validatePromptInputs(instruction, ""); err != nil {
+		return nil, err
+	}
+	tweets, err := uc.fetchContextTweets(ctx, authID)
+	if err != nil {
+		return nil, err
 	}
+	return map[string]interface{}{
+		"Tweets":      tweets,
+		"Instruction": instruction,
+	}, nil
+}
 
-	return uc.geminiDAO.GenerateResponseFromPrompt(prompt)
+// tweetPromptData ツイート生成のプロンプトの変数を作成
+func (uc *GeminiUseCase) tweetPromptData(ctx context.Context, authID, instruction, tempText string) (map[string]interface{}, error) {
+	if err := uc.validatePromptInputs(instruction, tempText); err != nil {
+		return nil, err
+	}
+	tweets, err := uc.fetchContextTweets(ctx, authID)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"Tweets":      tweets,
+		"Instruction": instruction,
+		"TempText":    tempText,
+	}, nil
 }
 
 // CheckIfPostIsBad 指定した投稿の内容を検査して Gemini の結果を返す
-func (uc *GeminiUseCase) CheckIfPostIsBad(postID string) (*genai.Part, error) {
+func (uc *GeminiUseCase) CheckIfPostIsBad(ctx context.Context, postID, locale string) (*Generation, error) {
 	// DAO から投稿内容を取得
-	content, err := uc.geminiDAO.GetPostContent(postID)
+	content, err := uc.geminiDAO.GetPostContent(ctx, postID)
 	if err != nil {
 		return nil, fmt.Errorf("投稿内容の取得失敗: %w", err)
 	}
 
-	// プロンプトを作成
-	prompt := fmt.Sprintf("次の投稿が良識に反している場合は 'YES' を、そうでない場合は 'NO' を返してください:\n\n投稿内容: %s", content)
+	// Gemini API を使用して判定 (長すぎる投稿は切り詰める)
+	// 投稿検査はユーザーに紐づけず、全体の上限のみ適用する
+	return uc.generate(ctx, "", prompt.CheckIsBad, locale, postID, map[string]interface{}{
+		"Content": prompt.TruncateToBudget(content, uc.prompts.Limits().ContextTokenBudget),
+	})
+}
+
+// validatePromptInputs instruction と temp_text の長さと、指示の上書きを試みていないかを検証
+func (uc *GeminiUseCase) validatePromptInputs(instruction, tempText string) error {
+	limits := uc.prompts.Limits()
+	if err := validate.CheckAs(ErrInvalidPromptInput,
+		validate.Length("instruction", instruction, 0, limits.MaxInstructionLen),
+		validate.Length("temp_text", tempText, 0, limits.MaxTempTextLen),
+	); err != nil {
+		return err
+	}
+	if prompt.DetectInjection(instruction) || prompt.DetectInjection(tempText) {
+		log.Printf("[gemini_usecase.go] プロンプトインジェクションの疑いがある入力を拒否 (instruction: %q, temp_text: %q)", instruction, tempText)
+		return ErrInvalidPromptInput.Detailf("システムの指示を変更しようとする入力は使えません")
+	}
+	return nil
+}
+
+// fetchContextTweets 最近の投稿から、文脈のトークン予算に収まるだけ過去ツイートを選ぶ
+// 投稿が追加・編集・削除されるまではキャッシュした投稿を使う
+func (uc *GeminiUseCase) fetchContextTweets(ctx context.Context, authID string) ([]string, error) {
+	tweets, ok := uc.cache.GetContextTweets(authID)
+	if !ok {
+		var err error
+		tweets, err = uc.geminiDAO.FetchUserPostContents(ctx, authID, maxContextPosts)
+		if err != nil {
+			return nil, fmt.Errorf("過去ツイートの取得失敗: %w", err)
+		}
+		uc.cache.SetContextTweets(authID, tweets)
+	}
+	return prompt.SelectWithinBudget(tweets, uc.prompts.Limits().ContextTokenBudget), nil
+}
+
+// generate テンプレートからプロンプトを作成して生成し、使用したテンプレートと使用量を記録する
+// userID は使用量の上限の確認に使い (空なら全体の上限のみ)、targetID (ユーザーIDや投稿ID) は A/B テストの振り分けにも使う
+// 同じプロンプトの結果がキャッシュにあれば Gemini を呼ばずに返す (使用量にも数えない)
+func (uc *GeminiUseCase) generate(ctx context.Context, userID, templateID, locale, targetID string, data map[string]interface{}) (*Generation, error) {
+	rendered, err := uc.prompts.Render(templateID, locale, targetID, data)
+	if err != nil {
+		return nil, fmt.Errorf("プロンプトの作成失敗: %w", err)
+	}
+	cacheKey := generationCacheKey(rendered)
+	if text, ok := uc.cache.GetGeneration(ctx, cacheKey); ok {
+		var part genai.Part = genai.Text(text)
+		return &Generation{Part: &part, Prompt: rendered.Info, Cached: true}, nil
+	}
+
+	if err := uc.quota.Check(ctx, userID); err != nil {
+		return nil, err
+	}
+	part, usage, err := uc.geminiDAO.GenerateResponseFromPrompt(ctx, rendered.Text)
+	if err != nil {
+		return nil, err
+	}
+
+	uc.quota.Record(ctx, userID, templateID, usage)
+	uc.recordGeneration(ctx, targetID, rendered.Info)
+	if text, ok := (*part).(genai.Text); ok {
+		uc.cache.SetGeneration(ctx, cacheKey, targetID, templateID, string(text))
+	}
+	return &Generation{Part: part, Prompt: rendered.Info}, nil
+}
+
+// stream テンプレートからプロンプトを作成してストリーミング生成し、使用したテンプレートと使用量を記録する
+func (uc *GeminiUseCase) stream(ctx context.Context, templateID, locale, targetID string, data map[string]interface{}, onText func(string) error) (*StreamResult, error) {
+	if err := uc.quota.Check(ctx, targetID); err != nil {
+		return nil, err
+	}
+	rendered, err := uc.prompts.Render(templateID, locale, targetID, data)
+	if err != nil {
+		return nil, fmt.Errorf("プロンプトの作成失敗: %w", err)
+	}
+
+	usage, err := uc.geminiDAO.StreamResponseFromPrompt(ctx, rendered.Text, onText)
+	if err != nil {
+		// クライアントの切断などで途中で終わっても、Gemini を呼び出していれば1回分とそれまでのトークン数を数える
+		if usage != nil {
+			uc.quota.Record(ctx, targetID, templateID, usage)
+		}
+		return nil, err
+	}
+	uc.quota.Record(ctx, targetID, templateID, usage)
+	uc.recordGeneration(ctx, targetID, rendered.Info)
 
-	// Gemini API を使用して判定
-	return uc.geminiDAO.GenerateResponseFromPrompt(prompt)
+	return &StreamResult{Prompt: rendered.Info, Usage: usage}, nil
+}
+
+// recordGeneration 生成に使ったテンプレートを記録する (失敗しても生成結果は返すためエラーは返さない)
+func (uc *GeminiUseCase) recordGeneration(ctx context.Context, targetID string, info prompt.Info) {
+	entropy := rand.New(rand.NewSource(time.Now().UnixNano()))
+	_ = uc.geminiDAO.RecordGeneration(context.WithoutCancel(ctx), model.GenerationLog{
+		GenerationID:    ulid.MustNew(ulid.Timestamp(time.Now()), entropy).String(),
+		TargetID:        targetID,
+		TemplateID:      info.TemplateID,
+		TemplateVersion: info.Version,
+		Locale:          info.Locale,
+		CreatedAt:       time.Now(),
+	})
 }
 
 // UpdateIsBad 指定した投稿の is_bad カラムを更新
-func (uc *GeminiUseCase) UpdateIsBad(postID string, isBad bool) error {
-	return uc.geminiDAO.UpdateIsBad(postID, isBad)
+func (uc *GeminiUseCase) UpdateIsBad(ctx context.Context, postID string, isBad bool) error {
+	return uc.geminiDAO.UpdateIsBad(ctx, postID, isBad)
 }
 
-// RecommendUsers 指示からおすすめユーザーを生成
-func (uc *GeminiUseCase) RecommendUsers(authID, instruction string) (*genai.Part, error) {
-	// 未フォローのユーザー情報を取得
-	unfollowedUsers, err := uc.geminiDAO.FetchUnfollowedUsers(authID)
+// RecommendUsers 埋め込みの類似度とフォローグラフからおすすめユーザーを順位付けして返す
+func (uc *GeminiUseCase) RecommendUsers(ctx context.Context, authID, instruction string, limit int) ([]model.UserRecommendation, error) {
+	if err := validate.Check(validate.Required("auth_id", authID)); err != nil {
+		return nil, err
+	}
+	limit = min(limit, maxRecommendLimit)
+	if err := uc.quota.Check(ctx, authID); err != nil {
+		return nil, err
+	}
+
+	// 未フォローのユーザー情報と友達の友達を取得
+	unfollowedUsers, err := uc.geminiDAO.FetchUnfollowedUsers(ctx, authID, maxRecommendCandidates)
 	if err != nil {
 		return nil, fmt.Errorf("未フォローのユーザー取得失敗: %w", err)
 	}
+	mutualCounts, err := uc.geminiDAO.FetchMutualFollowCounts(ctx, authID, maxRecommendCandidates)
+	if err != nil {
+		return nil, fmt.Errorf("友達の友達の取得失敗: %w", err)
+	}
+	candidateIDs := make([]string, len(unfollowedUsers))
+	for i, user := range unfollowedUsers {
+		candidateIDs[i] = user.UserID
+	}
+	embeddings, err := uc.embeddingDAO.FetchUserEmbeddings(ctx, candidateIDs)
+	if err != nil {
+		return nil, fmt.Errorf("未フォローのユーザーの埋め込み取得失敗: %w", err)
+	}
 
-	// 未フォローのユーザーをプロンプトに追加
-	userInfo := ""
+	// 埋め込みが無い・古いユーザーを一定数だけ計算し直す
+	// 埋め込みが無いユーザーを古いユーザーより先にし、それぞれ友達の友達を優先する
+	var missing []model.User
 	for _, user := range unfollowedUsers {
-		userInfo += fmt.Sprintf("- ID: %s, 名前: %s, 自己紹介: %s\n", user.UserID, user.Name, nullableToString(user.Bio))
+		if embedding, ok := embeddings[user.UserID]; !ok || isEmbeddingStale(embedding) {
+			missing = append(missing, user)
+		}
+	}
+	sort.SliceStable(missing, func(i, j int) bool {
+		_, iHas := embeddings[missing[i].UserID]
+		_, jHas := embeddings[missing[j].UserID]
+		if iHas != jHas {
+			return !iHas
+		}
+		return mutualCounts[missing[i].UserID] > mutualCounts[missing[j].UserID]
+	})
+	if len(missing) > maxLazyEmbeddings {
+		missing = missing[:maxLazyEmbeddings]
+	}
+
+	self, err := uc.geminiDAO.GetUserProfile(ctx, authID)
+	if err != nil {
+		return nil, fmt.Errorf("ユーザー情報の取得失敗: %w", err)
+	}
+	refreshed, err := uc.refreshEmbeddings(ctx, append([]model.User{*self}, missing...))
+	if err != nil {
+		return nil, err
+	}
+	for _, user := range missing {
+		if embedding, ok := refreshed[user.UserID]; ok {
+			embeddings[user.UserID] = embedding
+		}
 	}
 
-	// プロンプト作成
-	prompt := "ユーザー情報をもとに、フォローすべきおすすめのユーザーを推薦してください。\n"
-	prompt += "ユーザー情報:\n" + userInfo
+	// 指示があれば指示の埋め込みを検索ベクトルに加える
+	query := normalize(refreshed[authID].Vector)
 	if instruction != "" {
-		prompt += fmt.Sprintf(" 追加の指示: %s\n", instruction)
+		vectors, err := uc.embeddingDAO.EmbedTexts(ctx, []string{instruction})
+		if err != nil {
+			return nil, fmt.Errorf("指示の埋め込み生成失敗: %w", err)
+		}
+		query = combineVectors(query, normalize(vectors[0]))
+	}
+	// 埋め込みAPIはトークン使用量を返さないため呼び出し回数のみ記録する
+	uc.quota.Record(ctx, authID, "recommend_users", nil)
+
+	// 類似度とフォローグラフのスコアを合成して順位付け
+	maxMutual := 0
+	for _, count := range mutualCounts {
+		maxMutual = max(maxMutual, count)
+	}
+	var recommendations []model.UserRecommendation
+	for _, user := range unfollowedUsers {
+		mutual := mutualCounts[user.UserID]
+		embedding, hasEmbedding := embeddings[user.UserID]
+		if !hasEmbedding && mutual == 0 {
+			continue
+		}
+
+		similarity := 0.0
+		if hasEmbedding {
+			similarity = cosineSimilarity(query, normalize(embedding.Vector))
+		}
+		graphScore := 0.0
+		if maxMutual > 0 {
+			graphScore = math.Log1p(float64(mutual)) / math.Log1p(float64(maxMutual))
+		}
+
+		recommendations = append(recommendations, model.UserRecommendation{
+			User:          user,
+			Score:         similarityWeight*similarity + graphWeight*graphScore,
+			Similarity:    similarity,
+			MutualFollows: mutual,
+			Reason:        recommendationReason(similarity, mutual),
+		})
+	}
+	sort.SliceStable(recommendations, func(i, j int) bool {
+		return recommendations[i].Score > recommendations[j].Score
+	})
+	if len(recommendations) > limit {
+		recommendations = recommendations[:limit]
 	}
-	prompt += "必ず上記IDのいずれかから選択するようにして、結論となるIDのみを答えてください。\n"
 
-	// Gemini APIで生成
-	responsePart, err := uc.geminiDAO.GenerateResponseFromPrompt(prompt)
+	return recommendations, nil
+}
+
+// refreshEmbeddings 指定ユーザーの埋め込みを、元テキストが変わっていれば計算し直して返す
+func (uc *GeminiUseCase) refreshEmbeddings(ctx context.Context, users []model.User) (map[string]model.UserEmbedding, error) {
+	result := make(map[string]model.UserEmbedding)
+	var targets []model.UserEmbedding
+	var texts []string
+
+	for _, user := range users {
+		posts, err := uc.geminiDAO.FetchUserPostContents(ctx, user.UserID, embeddingPostLimit)
+		if err != nil {
+			return nil, fmt.Errorf("最近の投稿の取得失敗: %w", err)
+		}
+		text := embeddingSourceText(user, posts)
+		hash := hashText(text)
+
+		stored, err := uc.embeddingDAO.GetUserEmbedding(ctx, user.UserID)
+		if err != nil {
+			return nil, fmt.Errorf("埋め込みの取得失敗: %w", err)
+		}
+		if stored != nil && stored.SourceHash == hash {
+			// 元テキストが変わっていなければ計算し直さず、確認した日時だけ更新して次回まで古いと判定しないようにする
+			if isEmbeddingStale(*stored) {
+				stored.UpdatedAt = time.Now()
+				if err := uc.embeddingDAO.TouchUserEmbedding(ctx, user.UserID, stored.UpdatedAt); err != nil {
+					return nil, fmt.Errorf("埋め込みの更新日時の更新失敗: %w", err)
+				}
+			}
+			result[user.UserID] = *stored
+			continue
+		}
+
+		targets = append(targets, model.UserEmbedding{UserID: user.UserID, SourceHash: hash})
+		texts = append(texts, text)
+	}
+	if len(texts) == 0 {
+		return result, nil
+	}
+
+	vectors, err := uc.embeddingDAO.EmbedTexts(ctx, texts)
 	if err != nil {
-		return nil, fmt.Errorf("Geminiによる推薦生成失敗: %w", err)
+		return nil, fmt.Errorf("埋め込みの生成失敗: %w", err)
+	}
+	for i, embedding := range targets {
+		embedding.Vector = vectors[i]
+		embedding.UpdatedAt = time.Now()
+		if err := uc.embeddingDAO.SaveUserEmbedding(ctx, embedding); err != nil {
+			return nil, fmt.Errorf("埋め込みの保存失敗: %w", err)
+		}
+		result[embedding.UserID] = embedding
 	}
+	return result, nil
+}
 
-	return responsePart, nil
+// recommendationReason ヘルパー関数: おすすめ理由の説明文を作成
+func recommendationReason(similarity float64, mutual int) string {
+	switch {
+	case mutual > 0 && similarity >= similarReasonThreshold:
+		return fmt.Sprintf("フォロー中の%d人がフォローしていて、投稿の傾向も近いユーザーです", mutual)
+	case mutual > 0:
+		return fmt.Sprintf("フォロー中の%d人がフォローしています", mutual)
+	default:
+		return "プロフィールや投稿の傾向が近いユーザーです"
+	}
 }
 
 // nullableToString ヘルパー関数: *string を文字列に変換
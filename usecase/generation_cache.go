@@ -0,0 +1,138 @@
+package usecase
+
+import (
+	"context"
+	"log"
+	"sync/atomic"
+	"time"
+	"twitter/cache"
+	"twitter/config"
+	"twitter/dao"
+	"twitter/model"
+	"twitter/prompt"
+)
+
+// AI生成結果のキャッシュに使う値
+const (
+	generationCacheTTL   = 10 * time.Minute   // 名前・自己紹介・ツイート生成の結果を使い回す期間
+	moderationCacheTTL   = 7 * 24 * time.Hour // 投稿検査・翻訳の結果を使い回す期間 (内容が変われば別のキーになる)
+	contextTweetCacheTTL = 10 * time.Minute   // 文脈に使う過去ツイートを使い回す期間
+)
+
+// CacheStats AI生成結果のキャッシュの統計
+type CacheStats struct {
+	Generations      cache.Stats `json:"generations"`
+	ContextTweets    cache.Stats `json:"context_tweets"`
+	Persistent       bool        `json:"persistent"`
+	PersistentHits   int64       `json:"persistent_hits"`
+	PersistentMisses int64       `json:"persistent_misses"`
+}
+
+// GenerationCache プロンプトの内容のハッシュをキーにした生成結果のキャッシュと、ユーザーごとの過去ツイートのキャッシュ
+// 設定 AI_CACHE_PERSIST が true なら生成結果を ai_cache テーブルにも保存する
+type GenerationCache struct {
+	cacheDAO         *dao.CacheDAO // 永続化しない場合は nil
+	generations      *cache.LRU[string]
+	contextTweets    *cache.LRU[[]string]
+	persistentHits   atomic.Int64
+	persistentMisses atomic.Int64
+}
+
+// NewGenerationCache キャッシュの初期化 (容量は設定 AI_CACHE_SIZE の件数)
+func NewGenerationCache(cacheDAO *dao.CacheDAO, settings config.CacheConfig) *GenerationCache {
+	c := &GenerationCache{
+		generations:   cache.NewLRU[string](settings.Size),
+		contextTweets: cache.NewLRU[[]string](settings.Size),
+	}
+	if settings.Persist {
+		c.cacheDAO = cacheDAO
+	}
+	log.Printf("[generation_cache.go] AI生成キャッシュ: 容量 %d件, 永続化: %v", settings.Size, c.cacheDAO != nil)
+	return c
+}
+
+// GetGeneration キャッシュキーに対応する生成結果を返す (メモリに無ければ永続化したキャッシュを探す)
+func (c *GenerationCache) GetGeneration(ctx context.Context, key string) (string, bool) {
+	if text, ok := c.generations.Get(key); ok {
+		return text, true
+	}
+	if c.cacheDAO == nil {
+		return "", false
+	}
+
+	stored, err := c.cacheDAO.GetGeneration(ctx, key)
+	if err != nil || stored == nil {
+		c.persistentMisses.Add(1)
+		return "", false
+	}
+	c.persistentHits.Add(1)
+	c.generations.Set(key, stored.TargetID, stored.Response, time.Until(stored.ExpiresAt))
+	return stored.Response, true
+}
+
+// SetGeneration 生成結果を保存する (targetID はまとめて無効化するための目印)
+func (c *GenerationCache) SetGeneration(ctx context.Context, key, targetID, templateID, text string) {
+	ttl := generationCacheTTL
+	if templateID == prompt.CheckIsBad || templateID == prompt.TranslatePost {
+		ttl = moderationCacheTTL
+	}
+	c.generations.Set(key, targetID, text, ttl)
+
+	if c.cacheDAO != nil {
+		now := time.Now()
+		_ = c.cacheDAO.SaveGeneration(context.WithoutCancel(ctx), model.CachedGeneration{
+			CacheKey:   key,
+			TargetID:   targetID,
+			TemplateID: templateID,
+			Response:   text,
+			CreatedAt:  now,
+			ExpiresAt:  now.Add(ttl),
+		})
+	}
+}
+
+// GetContextTweets ユーザーの過去ツイートを返す
+func (c *GenerationCache) GetContextTweets(userID string) ([]string, bool) {
+	return c.contextTweets.Get(userID)
+}
+
+// SetContextTweets ユーザーの過去ツイートを保存する
+func (c *GenerationCache) SetContextTweets(userID string, tweets []string) {
+	c.contextTweets.Set(userID, userID, tweets, contextTweetCacheTTL)
+}
+
+// InvalidateUser ユーザーの投稿が追加・編集・削除されたときに、過去ツイートとそれを使った生成結果を無効化する
+func (c *GenerationCache) InvalidateUser(ctx context.Context, userID string) {
+	c.contextTweets.Delete(userID)
+	c.invalidateTarget(ctx, userID)
+}
+
+// InvalidatePost 投稿が編集・削除されたときに、その投稿の検査結果を無効化する
+func (c *GenerationCache) InvalidatePost(ctx context.Context, postID string) {
+	c.invalidateTarget(ctx, postID)
+}
+
+// Stats キャッシュの統計を返す
+func (c *GenerationCache) Stats() CacheStats {
+	return CacheStats{
+		Generations:      c.generations.Stats(),
+		ContextTweets:    c.contextTweets.Stats(),
+		Persistent:       c.cacheDAO != nil,
+		PersistentHits:   c.persistentHits.Load(),
+		PersistentMisses: c.persistentMisses.Load(),
+	}
+}
+
+// invalidateTarget ヘルパー関数: 対象の生成結果をメモリと永続化したキャッシュから削除
+func (c *GenerationCache) invalidateTarget(ctx context.Context, targetID string) {
+	c.generations.DeleteTag(targetID)
+	if c.cacheDAO != nil {
+		// 古い生成結果が残らないよう、リクエストがキャンセルされても削除する
+		_ = c.cacheDAO.DeleteByTarget(context.WithoutCancel(ctx), targetID)
+	}
+}
+
+// generationCacheKey ヘルパー関数: テンプレートと埋め込み後のプロンプトの内容からキャッシュキーを作成
+func generationCacheKey(rendered *prompt.Rendered) string {
+	return hashText(rendered.Info.String() + "\n" + rendered.Text)
+}
@@ -0,0 +1,157 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+	"twitter/apperr"
+	"twitter/dao"
+	"twitter/model"
+	"twitter/prompt"
+)
+
+// 画像の解析に使う値
+const (
+	imageQueueSize  = 100              // 解析待ちの画像の最大数 (超えた分は失敗として記録する)
+	imageWorkers    = 2                // 同時に解析する画像の数
+	imageJobTimeout = 60 * time.Second // 1枚の画像の取得と解析の最大時間
+)
+
+// ErrImageAnalysisNotFound 画像の解析結果が存在しない
+var ErrImageAnalysisNotFound = apperr.NotFound("image_analysis_not_found", "画像の解析結果が存在しません")
+
+// imageJob 解析待ちの画像
+type imageJob struct {
+	kind     string
+	targetID string
+	imgURL   string
+}
+
+// ImageUseCase 投稿の画像とプロフィール画像を非同期に解析し、代替テキストの提案と安全性の判定を保存する
+// 投稿の画像が不適切と判定されたら、文章の検査と同じ is_bad を立てる
+type ImageUseCase struct {
+	ImageDAO      *dao.ImageDAO
+	GeminiUseCase *GeminiUseCase
+	jobs          chan imageJob
+}
+
+// NewImageUseCase 初期化と同時に解析のワーカーを起動する
+func NewImageUseCase(imageDAO *dao.ImageDAO, geminiUseCase *GeminiUseCase) *ImageUseCase {
+	uc := &ImageUseCase{
+		ImageDAO:      imageDAO,
+		GeminiUseCase: geminiUseCase,
+		jobs:          make(chan imageJob, imageQueueSize),
+	}
+	for i := 0; i < imageWorkers; i++ {
+		go uc.work()
+	}
+	return uc
+}
+
+// Enqueue 画像の解析を予約する (投稿の作成などを待たせないよう、解析はワーカーが後で行う)
+// 画像が無い、または同じ画像を解析済み・解析待ちなら何もしない
+func (uc *ImageUseCase) Enqueue(ctx context.Context, kind, targetID string, imgURL *string) {
+	if imgURL == nil || *imgURL == "" {
+		return
+	}
+	// 投稿などの保存は済んでいるため、リクエストがキャンセルされても予約は行う
+	ctx = context.WithoutCancel(ctx)
+	existing, err := uc.ImageDAO.GetImageAnalysis(ctx, kind, targetID)
+	if err == nil && existing != nil && existing.ImgURL == *imgURL && existing.Status != model.ImageAnalysisFailed {
+		return
+	}
+
+	job := imageJob{kind: kind, targetID: targetID, imgURL: *imgURL}
+	if err := uc.save(ctx, job, model.ImageAnalysisPending, nil, nil); err != nil {
+		return
+	}
+	select {
+	case uc.jobs <- job:
+	default:
+		log.Printf("[image_usecase.go] 解析待ちの画像が多すぎるため解析しない (kind: %s, target_id: %s)", kind, targetID)
+		_ = uc.save(ctx, job, model.ImageAnalysisFailed, nil, errors.New("解析待ちの画像が多すぎます"))
+	}
+}
+
+// GetAnalysis 画像の解析結果を取得する
+func (uc *ImageUseCase) GetAnalysis(ctx context.Context, kind, targetID string) (*model.ImageAnalysis, error) {
+	analysis, err := uc.ImageDAO.GetImageAnalysis(ctx, kind, targetID)
+	if err != nil {
+		return nil, err
+	}
+	if analysis == nil {
+		return nil, ErrImageAnalysisNotFound
+	}
+	return analysis, nil
+}
+
+// work ヘルパー関数: 解析待ちの画像を順に解析する
+func (uc *ImageUseCase) work() {
+	for job := range uc.jobs {
+		uc.analyze(job)
+	}
+}
+
+// analyze ヘルパー関数: 画像を取得して解析し、結果を保存する
+func (uc *ImageUseCase) analyze(job imageJob) {
+	ctx, cancel := context.WithTimeout(context.Background(), imageJobTimeout)
+	defer cancel()
+
+	mimeType, image, err := uc.ImageDAO.FetchImage(ctx, job.imgURL)
+	if err != nil {
+		log.Printf("[image_usecase.go] 画像の取得失敗 (kind: %s, target_id: %s): %v", job.kind, job.targetID, err)
+		// 時間切れでも失敗として記録し、解析待ちのまま残さない
+		_ = uc.save(context.WithoutCancel(ctx), job, model.ImageAnalysisFailed, nil, err)
+		return
+	}
+	verdict, err := uc.GeminiUseCase.AnalyzeImage(ctx, job.kind, job.targetID, mimeType, image, prompt.DefaultLocale)
+	if err != nil {
+		log.Printf("[image_usecase.go] 画像の解析失敗 (kind: %s, target_id: %s): %v", job.kind, job.targetID, err)
+		_ = uc.save(context.WithoutCancel(ctx), job, model.ImageAnalysisFailed, nil, err)
+		return
+	}
+
+	// 解析中に画像が差し替えられていたら、古い画像の結果は捨てる
+	current, err := uc.ImageDAO.GetImageAnalysis(ctx, job.kind, job.targetID)
+	if err != nil || current == nil || current.ImgURL != job.imgURL {
+		return
+	}
+	if err := uc.save(ctx, job, model.ImageAnalysisDone, verdict, nil); err != nil {
+		return
+	}
+
+	if !verdict.IsUnsafe {
+		return
+	}
+	log.Printf("[image_usecase.go] 不適切な画像を検出 (kind: %s, target_id: %s, categories: %v)", job.kind, job.targetID, verdict.Categories)
+	if job.kind == model.ImageKindPost {
+		if err := uc.GeminiUseCase.UpdateIsBad(ctx, job.targetID, true); err != nil {
+			log.Printf("[image_usecase.go] is_bad 更新失敗 (post_id: %s): %v", job.targetID, err)
+		}
+	}
+}
+
+// save ヘルパー関数: 解析の状態と結果を保存する
+func (uc *ImageUseCase) save(ctx context.Context, job imageJob, status string, verdict *ImageVerdict, cause error) error {
+	now := time.Now()
+	analysis := model.ImageAnalysis{
+		Kind:       job.kind,
+		TargetID:   job.targetID,
+		ImgURL:     job.imgURL,
+		Status:     status,
+		Categories: []string{},
+		UpdatedAt:  now,
+	}
+	if verdict != nil {
+		analysis.AltText = &verdict.AltText
+		analysis.IsUnsafe = verdict.IsUnsafe
+		analysis.Categories = verdict.Categories
+		analysis.AnalyzedAt = &now
+	}
+	if cause != nil {
+		message := cause.Error()
+		analysis.Error = &message
+	}
+	return uc.ImageDAO.SaveImageAnalysis(ctx, analysis)
+}
@@ -1,29 +1,34 @@
 package usecase
 
 import (
+	"context"
 	"twitter/dao"
 	"twitter/model"
 )
 
 type LikeUseCase struct {
 	LikeDAO *dao.LikeDAO
+	Guard   *RestrictionGuard
 }
 
-func NewLikeUseCase(LikeDAO *dao.LikeDAO) *LikeUseCase {
-	return &LikeUseCase{LikeDAO: LikeDAO}
+func NewLikeUseCase(LikeDAO *dao.LikeDAO, guard *RestrictionGuard) *LikeUseCase {
+	return &LikeUseCase{LikeDAO: LikeDAO, Guard: guard}
 }
 
 // AddLike 投稿にいいねを追加
-func (uc *LikeUseCase) AddLike(userID, postID string) error {
-	return uc.LikeDAO.AddLike(userID, postID)
+func (uc *LikeUseCase) AddLike(ctx context.Context, userID, postID string) error {
+	if err := uc.Guard.CheckNotSuspended(ctx, userID); err != nil {
+		return err
+	}
+	return uc.LikeDAO.AddLike(ctx, userID, postID)
 }
 
 // RemoveLike 投稿のいいねを削除
-func (uc *LikeUseCase) RemoveLike(userID, postID string) error {
-	return uc.LikeDAO.RemoveLike(userID, postID)
+func (uc *LikeUseCase) RemoveLike(ctx context.Context, userID, postID string) error {
+	return uc.LikeDAO.RemoveLike(ctx, userID, postID)
 }
 
 // GetUsersByPostID 投稿にいいねしたユーザー一覧を取得
-func (uc *LikeUseCase) GetUsersByPostID(postID string) ([]model.User, error) {
-	return uc.LikeDAO.GetUsersByPostID(postID)
+func (uc *LikeUseCase) GetUsersByPostID(ctx context.Context, postID string) ([]model.User, error) {
+	return uc.LikeDAO.GetUsersByPostID(ctx, postID)
 }
@@ -1,26 +1,38 @@
 package usecase
 
 import (
-	"errors"
+	"context"
 	"github.com/oklog/ulid"
 	"math/rand"
 	"time"
 	"twitter/dao"
 	"twitter/model"
+	"twitter/validate"
 )
 
 type PostUseCase struct {
-	PostDAO *dao.PostDAO
+	UnitOfWork            *dao.UnitOfWork
+	PostDAO               *dao.PostDAO
+	EmbeddingDAO          *dao.EmbeddingDAO
+	ImageDAO              *dao.ImageDAO
+	GenerationCache       *GenerationCache
+	ImageUseCase          *ImageUseCase
+	SemanticSearchUseCase *SemanticSearchUseCase
+	SuggestUseCase        *SuggestUseCase
+	Guard                 *RestrictionGuard
 }
 
-func NewPostUseCase(PostDAO *dao.PostDAO) *PostUseCase {
-	return &PostUseCase{PostDAO: PostDAO}
+func NewPostUseCase(unitOfWork *dao.UnitOfWork, PostDAO *dao.PostDAO, embeddingDAO *dao.EmbeddingDAO, imageDAO *dao.ImageDAO, generationCache *GenerationCache, imageUseCase *ImageUseCase, semanticSearchUseCase *SemanticSearchUseCase, suggestUseCase *SuggestUseCase, guard *RestrictionGuard) *PostUseCase {
+	return &PostUseCase{UnitOfWork: unitOfWork, PostDAO: PostDAO, EmbeddingDAO: embeddingDAO, ImageDAO: imageDAO, GenerationCache: generationCache, ImageUseCase: imageUseCase, SemanticSearchUseCase: semanticSearchUseCase, SuggestUseCase: suggestUseCase, Guard: guard}
 }
 
 // CreatePost 新しい投稿を作成
-func (uc *PostUseCase) CreatePost(post model.Post) (*model.Post, error) {
-	if post.Content == "" {
-		return nil, errors.New("投稿内容が空です")
+func (uc *PostUseCase) CreatePost(ctx context.Context, post model.Post) (*model.Post, error) {
+	if err := validateNewPost(post, false); err != nil {
+		return nil, err
+	}
+	if err := uc.Guard.CheckNotSuspended(ctx, post.UserID); err != nil {
+		return nil, err
 	}
 	entropy := rand.New(rand.NewSource(time.Now().UnixNano()))
 	postID := ulid.MustNew(ulid.Timestamp(time.Now()), entropy).String()
@@ -31,40 +43,98 @@ func (uc *PostUseCase) CreatePost(post model.Post) (*model.Post, error) {
 		post.ParentPostID = nil
 	}
 
-	return uc.PostDAO.CreatePost(post)
+	created, err := uc.PostDAO.CreatePost(ctx, post)
+	if err != nil {
+		return nil, err
+	}
+	uc.GenerationCache.InvalidateUser(ctx, post.UserID)
+	uc.ImageUseCase.Enqueue(ctx, model.ImageKindPost, post.PostID, post.ImgURL)
+	uc.SemanticSearchUseCase.IndexPost(post.PostID, post.Content)
+	uc.SuggestUseCase.AddHashtags(post.Content)
+	return created, nil
 }
 
 // GetPost 投稿の詳細を取得
-func (uc *PostUseCase) GetPost(postID string) (*model.Post, error) {
-	return uc.PostDAO.GetPost(postID)
+func (uc *PostUseCase) GetPost(ctx context.Context, postID string) (*model.Post, error) {
+	return uc.PostDAO.GetPost(ctx, postID)
 }
 
 // UpdatePost 投稿を更新
-func (uc *PostUseCase) UpdatePost(post model.Post) error {
-	return uc.PostDAO.UpdatePost(post)
+func (uc *PostUseCase) UpdatePost(ctx context.Context, post model.Post) error {
+	if err := validatePostUpdate(post); err != nil {
+		return err
+	}
+	if err := uc.Guard.CheckNotSuspended(ctx, post.UserID); err != nil {
+		return err
+	}
+	if err := uc.PostDAO.UpdatePost(ctx, post); err != nil {
+		return err
+	}
+	uc.invalidateGenerations(ctx, post.PostID)
+	uc.ImageUseCase.Enqueue(ctx, model.ImageKindPost, post.PostID, post.ImgURL)
+	uc.SemanticSearchUseCase.IndexPost(post.PostID, post.Content)
+	return nil
 }
 
 // DeletePost 投稿を削除 (論理削除)
-func (uc *PostUseCase) DeletePost(postID string) error {
-	return uc.PostDAO.DeletePost(postID)
+// 投稿の埋め込み・画像の解析結果も同じトランザクションで削除し、途中で失敗すれば全て元に戻す
+// いいねは投稿を復元できるように残す (削除済みの投稿は各クエリの deleted_at 条件で除外される)
+func (uc *PostUseCase) DeletePost(ctx context.Context, postID string) error {
+	err := uc.UnitOfWork.Do(ctx, func(ctx context.Context) error {
+		if err := uc.PostDAO.DeletePost(ctx, postID); err != nil {
+			return err
+		}
+		if err := uc.EmbeddingDAO.DeletePostEmbedding(ctx, postID); err != nil {
+			return err
+		}
+		return uc.ImageDAO.DeleteImageAnalysis(ctx, model.ImageKindPost, postID)
+	})
+	if err != nil {
+		return err
+	}
+	uc.invalidateGenerations(ctx, postID)
+	uc.SemanticSearchUseCase.RemovePost(postID)
+	return nil
 }
 
 // ReplyPost 指定した投稿にリプライを追加
-func (uc *PostUseCase) ReplyPost(post model.Post) (*model.Post, error) {
-	if post.ParentPostID == nil || *post.ParentPostID == "" { // 修正
-		return nil, errors.New("[post_usecase.go] リプライ対象の投稿IDが指定されていません")
+func (uc *PostUseCase) ReplyPost(ctx context.Context, post model.Post) (*model.Post, error) {
+	if err := validateNewPost(post, true); err != nil {
+		return nil, err
+	}
+	if err := uc.Guard.CheckNotSuspended(ctx, post.UserID); err != nil {
+		return nil, err
 	}
 	entropy := rand.New(rand.NewSource(time.Now().UnixNano()))            // 乱数生成器の作成
 	replyID := ulid.MustNew(ulid.Timestamp(time.Now()), entropy).String() // ULIDの生成
 	post.PostID = replyID
 	post.CreatedAt = time.Now()
-	return uc.PostDAO.CreatePost(post)
+
+	created, err := uc.PostDAO.CreatePost(ctx, post)
+	if err != nil {
+		return nil, err
+	}
+	uc.GenerationCache.InvalidateUser(ctx, post.UserID)
+	uc.ImageUseCase.Enqueue(ctx, model.ImageKindPost, post.PostID, post.ImgURL)
+	uc.SemanticSearchUseCase.IndexPost(post.PostID, post.Content)
+	uc.SuggestUseCase.AddHashtags(post.Content)
+	return created, nil
+}
+
+// GetChildrenPosts 子ポストを取得 (viewerID は閲覧者のID、未ログインなら空)
+func (uc *PostUseCase) GetChildrenPosts(ctx context.Context, parentPostID, viewerID string) ([]model.Post, error) {
+	if err := validate.Check(validate.Required("parent_post_id", parentPostID)); err != nil {
+		return nil, err
+	}
+	return uc.PostDAO.GetChildrenPosts(ctx, parentPostID, viewerID)
 }
 
-// GetChildrenPosts 子ポストを取得
-func (uc *PostUseCase) GetChildrenPosts(parentPostID string) ([]model.Post, error) {
-	if parentPostID == "" {
-		return nil, errors.New("[post_usecase.go] parent_post_id が無効: 必須項目")
+// invalidateGenerations ヘルパー関数: 編集・削除された投稿の検査結果と、投稿者の過去ツイートを使った生成結果のキャッシュを無効化
+func (uc *PostUseCase) invalidateGenerations(ctx context.Context, postID string) {
+	uc.GenerationCache.InvalidatePost(ctx, postID)
+	authorID, err := uc.PostDAO.GetPostAuthorID(ctx, postID)
+	if err != nil {
+		return
 	}
-	return uc.PostDAO.GetChildrenPosts(parentPostID)
+	uc.GenerationCache.InvalidateUser(ctx, authorID)
 }
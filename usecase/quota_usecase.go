@@ -0,0 +1,219 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/oklog/ulid"
+	"log"
+	"math/rand"
+	"time"
+	"twitter/apperr"
+	"twitter/config"
+	"twitter/dao"
+	"twitter/model"
+	"twitter/validate"
+)
+
+// 推定コストの計算に使う 100万トークンあたりの料金 (USD, gemini-1.5-flash)
+const (
+	promptCostPerMillionTokens   = 0.075
+	responseCostPerMillionTokens = 0.30
+)
+
+// ErrQuotaExceeded AIの使用量の上限を超えている
+var ErrQuotaExceeded = apperr.RateLimited("quota_exceeded", "AIの使用量の上限を超えました", 0)
+
+// QuotaExceededError 超えた上限と、再試行できるまでの時間
+type QuotaExceededError struct {
+	Scope      string // "user" または "global"
+	Limit      string // 超えた上限の名前
+	RetryAfter time.Duration
+}
+
+func (e *QuotaExceededError) Error() string {
+	return fmt.Sprintf("%s (%s: %s)", ErrQuotaExceeded, e.Scope, e.Limit)
+}
+
+func (e *QuotaExceededError) Unwrap() error {
+	return ErrQuotaExceeded
+}
+
+// QuotaUseCase AI使用量の記録と上限の確認を行うUseCase
+type QuotaUseCase struct {
+	UsageDAO     *dao.UsageDAO
+	UserDAO      *dao.UserDAO
+	Guard        *RestrictionGuard
+	userLimits   model.QuotaLimits
+	globalLimits model.QuotaLimits
+}
+
+// NewQuotaUseCase ユーザーごとと全体の上限を設定して初期化
+func NewQuotaUseCase(usageDAO *dao.UsageDAO, userDAO *dao.UserDAO, guard *RestrictionGuard, quota config.QuotaConfig) *QuotaUseCase {
+	return &QuotaUseCase{
+		UsageDAO: usageDAO,
+		UserDAO:  userDAO,
+		Guard:    guard,
+		userLimits: model.QuotaLimits{
+			RequestsPerMinute: quota.UserRequestsPerMinute,
+			RequestsPerDay:    quota.UserRequestsPerDay,
+			TokensPerDay:      quota.UserTokensPerDay,
+		},
+		globalLimits: model.QuotaLimits{
+			RequestsPerDay: quota.GlobalRequestsPerDay,
+			TokensPerDay:   quota.GlobalTokensPerDay,
+		},
+	}
+}
+
+// Check AIを呼び出す前に上限を確認する (userID が空なら全体の上限のみ確認)
+// ユーザーが存在しなければ dao.ErrUserNotFound、凍結中なら ErrUserSuspended を返す
+func (uc *QuotaUseCase) Check(ctx context.Context, userID string) error {
+	if userID != "" {
+		// 存在しないユーザーの使用量は記録できず、上限を確認しても意味が無いため先に弾く
+		exists, err := uc.UserDAO.UserExists(ctx, userID)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return dao.ErrUserNotFound
+		}
+		if err := uc.Guard.CheckNotSuspended(ctx, userID); err != nil {
+			return err
+		}
+	}
+	now := time.Now().UTC()
+	dayStart, resetsAt := utcDay(now)
+
+	global, err := uc.UsageDAO.GetGlobalUsageSince(ctx, dayStart)
+	if err != nil {
+		return fmt.Errorf("全体の使用量取得失敗: %w", err)
+	}
+	if exceeded(global.Requests, uc.globalLimits.RequestsPerDay) {
+		return &QuotaExceededError{Scope: "global", Limit: "requests_per_day", RetryAfter: resetsAt.Sub(now)}
+	}
+	if exceeded(global.TotalTokens, uc.globalLimits.TokensPerDay) {
+		return &QuotaExceededError{Scope: "global", Limit: "tokens_per_day", RetryAfter: resetsAt.Sub(now)}
+	}
+
+	if userID == "" {
+		return nil
+	}
+	limits, _, err := uc.limitsFor(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	// 直近1分間の呼び出し回数
+	if limits.RequestsPerMinute > 0 {
+		count, oldest, err := uc.UsageDAO.GetUserRequestsSince(ctx, userID, now.Add(-time.Minute))
+		if err != nil {
+			return fmt.Errorf("呼び出し回数の取得失敗: %w", err)
+		}
+		if exceeded(count, limits.RequestsPerMinute) && oldest != nil {
+			// 最も古い呼び出しが1分の枠から外れれば再試行できる
+			retryAfter := max(oldest.Add(time.Minute).Sub(now), time.Second)
+			return &QuotaExceededError{Scope: "user", Limit: "requests_per_minute", RetryAfter: retryAfter}
+		}
+	}
+
+	usage, err := uc.UsageDAO.GetUserUsageSince(ctx, userID, dayStart)
+	if err != nil {
+		return fmt.Errorf("ユーザーの使用量取得失敗: %w", err)
+	}
+	if exceeded(usage.Requests, limits.RequestsPerDay) {
+		return &QuotaExceededError{Scope: "user", Limit: "requests_per_day", RetryAfter: resetsAt.Sub(now)}
+	}
+	if exceeded(usage.TotalTokens, limits.TokensPerDay) {
+		return &QuotaExceededError{Scope: "user", Limit: "tokens_per_day", RetryAfter: resetsAt.Sub(now)}
+	}
+	return nil
+}
+
+// Record AI呼び出し1回分の使用量を記録する (失敗しても生成結果は返すためエラーは返さない)
+func (uc *QuotaUseCase) Record(ctx context.Context, userID, operation string, usage *model.GenerationUsage) {
+	entropy := rand.New(rand.NewSource(time.Now().UnixNano()))
+	record := model.AIUsage{
+		UsageID:   ulid.MustNew(ulid.Timestamp(time.Now()), entropy).String(),
+		Operation: operation,
+		CreatedAt: time.Now(),
+	}
+	if userID != "" {
+		record.UserID = &userID
+	}
+	if usage != nil {
+		record.PromptTokens = usage.PromptTokens
+		record.ResponseTokens = usage.ResponseTokens
+		record.TotalTokens = usage.TotalTokens
+	}
+	// AIを呼び出した後にクライアントが切断しても使用量は必ず記録する
+	ctx = context.WithoutCancel(ctx)
+	err := uc.UsageDAO.RecordUsage(ctx, record)
+	if errors.Is(err, dao.ErrUserNotFound) {
+		// Check の後にユーザーが削除された場合も、全体の使用量には数えるためユーザーなしで記録する
+		log.Printf("[quota_usecase.go] ユーザーが存在しないためユーザーなしで使用量を記録 (user_id: %s, operation: %s)", userID, operation)
+		record.UserID = nil
+		err = uc.UsageDAO.RecordUsage(ctx, record)
+	}
+	if err != nil {
+		log.Printf("[quota_usecase.go] 使用量の記録失敗 (user_id: %s, operation: %s): %v", userID, operation, err)
+	}
+}
+
+// GetUsage ユーザーの当日 (UTC) の使用量、上限、推定コストを返す
+func (uc *QuotaUseCase) GetUsage(ctx context.Context, userID string) (*model.UsageSummary, error) {
+	if err := validate.Check(validate.Required("auth_id", userID)); err != nil {
+		return nil, err
+	}
+	dayStart, resetsAt := utcDay(time.Now().UTC())
+
+	usage, err := uc.UsageDAO.GetUserUsageSince(ctx, userID, dayStart)
+	if err != nil {
+		return nil, fmt.Errorf("ユーザーの使用量取得失敗: %w", err)
+	}
+	limits, overridden, err := uc.limitsFor(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.UsageSummary{
+		UserID:           userID,
+		Date:             dayStart.Format("2006-01-02"),
+		Usage:            *usage,
+		Limits:           limits,
+		Overridden:       overridden,
+		EstimatedCostUSD: estimateCost(usage.PromptTokens, usage.ResponseTokens),
+		ResetsAt:         resetsAt,
+	}, nil
+}
+
+// limitsFor ユーザーに適用する上限を返す (管理者の上書きがあれば1日の上限を置き換える)
+func (uc *QuotaUseCase) limitsFor(ctx context.Context, userID string) (model.QuotaLimits, bool, error) {
+	limits := uc.userLimits
+	override, err := uc.UsageDAO.GetQuotaOverride(ctx, userID)
+	if err != nil {
+		return limits, false, fmt.Errorf("上限の取得失敗: %w", err)
+	}
+	if override == nil {
+		return limits, false, nil
+	}
+	limits.RequestsPerDay = override.RequestsPerDay
+	limits.TokensPerDay = override.TokensPerDay
+	return limits, true, nil
+}
+
+// exceeded ヘルパー関数: 使用量が上限に達しているか (上限0は無制限)
+func exceeded(used, limit int) bool {
+	return limit > 0 && used >= limit
+}
+
+// utcDay ヘルパー関数: UTC の当日の開始日時と翌日の開始日時を返す
+func utcDay(now time.Time) (time.Time, time.Time) {
+	start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	return start, start.Add(24 * time.Hour)
+}
+
+// estimateCost ヘルパー関数: トークン数から推定コスト (USD) を計算
+func estimateCost(promptTokens, responseTokens int) float64 {
+	return float64(promptTokens)/1e6*promptCostPerMillionTokens + float64(responseTokens)/1e6*responseCostPerMillionTokens
+}
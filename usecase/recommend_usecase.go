@@ -0,0 +1,147 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"twitter/dao"
+	"twitter/model"
+	"twitter/validate"
+)
+
+// グラフによるおすすめの重み
+const (
+	adamicAdarWeight = 0.5 // Adamic-Adar スコアの重み
+	pageRankWeight   = 0.5 // personalized PageRank の重み
+)
+
+// maxNeighbourhoodFollows おすすめの計算に使う、2ホップ以内のフォロー関係の最大数
+const maxNeighbourhoodFollows = 5000
+
+// RecommendUseCase フォローグラフのみを使ったおすすめユーザー用のUseCase
+type RecommendUseCase struct {
+	FollowDAO      *dao.FollowDAO
+	UserDAO        *dao.UserDAO
+	RestrictionDAO *dao.RestrictionDAO
+	BlockDAO       *dao.BlockDAO
+}
+
+func NewRecommendUseCase(followDAO *dao.FollowDAO, userDAO *dao.UserDAO, restrictionDAO *dao.RestrictionDAO, blockDAO *dao.BlockDAO) *RecommendUseCase {
+	return &RecommendUseCase{FollowDAO: followDAO, UserDAO: userDAO, RestrictionDAO: restrictionDAO, BlockDAO: blockDAO}
+}
+
+// RecommendUsers 友達の友達の Adamic-Adar スコアと personalized PageRank からおすすめユーザーを返す
+// グラフは閲覧者から2ホップ以内のフォロー関係のみで作る
+// フォロー済み・ブロック関係にある・凍結中やシャドウバン中のユーザーは除く
+func (uc *RecommendUseCase) RecommendUsers(ctx context.Context, authID string, limit, offset int) (*model.RecommendationPage, error) {
+	if err := validate.Check(
+		validate.Required("auth_id", authID),
+		validate.Min("offset", offset, 0),
+	); err != nil {
+		return nil, err
+	}
+
+	follows, err := uc.FollowDAO.GetFollowNeighbourhood(ctx, authID, maxNeighbourhoodFollows)
+	if err != nil {
+		return nil, fmt.Errorf("フォローグラフの取得失敗: %w", err)
+	}
+	excluded, err := uc.RestrictionDAO.GetRestrictedUserIDs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("制限中のユーザー取得失敗: %w", err)
+	}
+	blocked, err := uc.BlockDAO.GetBlockedUserIDs(ctx, authID)
+	if err != nil {
+		return nil, fmt.Errorf("ブロック関係の取得失敗: %w", err)
+	}
+	for userID := range blocked {
+		excluded[userID] = true
+	}
+	// 閲覧者自身のフォローはブロック・制限に関わらず残し、フォロー済みの判定に使う
+	following := make(map[string]bool)
+	for _, follow := range follows {
+		if follow.UserID == authID {
+			following[follow.FollowingUserID] = true
+		}
+	}
+	graph := newFollowGraph(follows, excluded)
+
+	// スコア計算 (フォロー済みのユーザーと自分は除外)
+	mutual, adamicAdar := graph.friendsOfFriends(authID)
+	pageRank := graph.personalizedPageRank(authID)
+	maxAdamicAdar, maxPageRank := maxValue(adamicAdar), maxValue(pageRank)
+
+	var recommendations []model.UserRecommendation
+	for candidate, rank := range pageRank {
+		if candidate == authID || following[candidate] || excluded[candidate] {
+			continue
+		}
+		score := pageRankWeight * rank / maxPageRank
+		if maxAdamicAdar > 0 {
+			score += adamicAdarWeight * adamicAdar[candidate] / maxAdamicAdar
+		}
+		recommendations = append(recommendations, model.UserRecommendation{
+			User:          model.User{UserID: candidate},
+			Score:         score,
+			MutualFollows: mutual[candidate],
+			Reason:        graphRecommendationReason(mutual[candidate]),
+		})
+	}
+
+	// 友達の友達がいないユーザーにはフォロワー数の多いユーザーを返す (続きの有無を判定するため1件多く取得)
+	if len(recommendations) == 0 {
+		recommendations, err = uc.FollowDAO.GetTopFollowedUsers(ctx, authID, offset+limit+1)
+		if err != nil {
+			return nil, fmt.Errorf("フォロワー数の多いユーザーの取得失敗: %w", err)
+		}
+		for i := range recommendations {
+			recommendations[i].Reason = "多くのユーザーにフォローされています"
+		}
+	}
+
+	sort.Slice(recommendations, func(i, j int) bool {
+		if recommendations[i].Score != recommendations[j].Score {
+			return recommendations[i].Score > recommendations[j].Score
+		}
+		return recommendations[i].User.UserID < recommendations[j].User.UserID
+	})
+
+	// ページ分割
+	page := &model.RecommendationPage{Recommendations: []model.UserRecommendation{}}
+	if offset >= len(recommendations) {
+		return page, nil
+	}
+	end := min(offset+limit, len(recommendations))
+	page.Recommendations = recommendations[offset:end]
+	if end < len(recommendations) {
+		page.NextOffset = &end
+	}
+
+	// ユーザー情報を埋める
+	userIDs := make([]string, len(page.Recommendations))
+	for i, recommendation := range page.Recommendations {
+		userIDs[i] = recommendation.User.UserID
+	}
+	users, err := uc.UserDAO.GetUsersByIDs(ctx, userIDs)
+	if err != nil {
+		return nil, fmt.Errorf("ユーザー情報の取得失敗: %w", err)
+	}
+	usersByID := make(map[string]model.User, len(users))
+	for _, user := range users {
+		usersByID[user.UserID] = user
+	}
+	for i := range page.Recommendations {
+		if user, ok := usersByID[page.Recommendations[i].User.UserID]; ok {
+			page.Recommendations[i].User = user
+		}
+	}
+
+	return page, nil
+}
+
+// graphRecommendationReason ヘルパー関数: グラフによるおすすめ理由の説明文を作成
+func graphRecommendationReason(mutual int) string {
+	if mutual > 0 {
+		return fmt.Sprintf("フォロー中の%d人がフォローしています", mutual)
+	}
+	return "フォロー中のユーザーとつながりがあります"
+}
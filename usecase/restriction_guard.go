@@ -0,0 +1,38 @@
+package usecase
+
+import (
+	"context"
+	"time"
+	"twitter/apperr"
+	"twitter/dao"
+	"twitter/model"
+)
+
+// ErrUserSuspended 凍結中のユーザーは操作できない
+var ErrUserSuspended = apperr.Forbidden("user_suspended", "アカウントが凍結されています")
+
+// RestrictionGuard 凍結中のユーザーによる書き込みとAIの利用を拒否する
+// 投稿・リプライ・投稿の編集・いいね・フォロー・プロフィールの更新・AIの呼び出しの前に確認する
+// (いいねやフォローの取り消し、自分のデータの削除は凍結中でもできる)
+type RestrictionGuard struct {
+	RestrictionDAO *dao.RestrictionDAO
+}
+
+func NewRestrictionGuard(restrictionDAO *dao.RestrictionDAO) *RestrictionGuard {
+	return &RestrictionGuard{RestrictionDAO: restrictionDAO}
+}
+
+// CheckNotSuspended ユーザーが凍結中なら ErrUserSuspended を返す
+func (g *RestrictionGuard) CheckNotSuspended(ctx context.Context, userID string) error {
+	restriction, err := g.RestrictionDAO.GetActiveRestriction(ctx, userID, model.RestrictionSuspend)
+	if err != nil {
+		return err
+	}
+	if restriction == nil {
+		return nil
+	}
+	if restriction.ExpiresAt != nil {
+		return ErrUserSuspended.Detailf("%s まで", restriction.ExpiresAt.UTC().Format(time.RFC3339))
+	}
+	return ErrUserSuspended.Detailf("無期限")
+}
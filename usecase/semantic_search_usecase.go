@@ -0,0 +1,286 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+	"twitter/cache"
+	"twitter/dao"
+	"twitter/model"
+	"twitter/prompt"
+	"twitter/search"
+	"twitter/vectorstore"
+)
+
+// 投稿の意味検索に使う値
+const (
+	indexQueueSize          = 1000             // 埋め込み待ちの投稿の最大数 (超えた分は次回起動時にまとめて埋め込む)
+	indexBatchSize          = 20               // 一度に埋め込む投稿の最大数
+	indexJobTimeout         = 60 * time.Second // 1回の埋め込みの最大時間
+	indexBackfillLimit      = 1000             // 起動時に埋め込む、埋め込みの無い投稿の最大数
+	maxSemanticQueryLen     = 200              // 検索クエリの最大文字数
+	defaultSemanticLimit    = 20
+	maxSemanticLimit        = 100
+	semanticCandidateFactor = 5 // 表示件数の何倍の候補から順位付けするか
+	defaultKeywordWeight    = 0.3
+	queryEmbeddingCacheSize = 500
+	queryEmbeddingCacheTTL  = time.Hour
+)
+
+// indexJob 埋め込み待ちの投稿
+type indexJob struct {
+	postID  string
+	content string
+}
+
+// SemanticSearchUseCase 投稿の内容の埋め込みベクトルをベクトルストアに保持し、意味の近い投稿を検索する
+// 埋め込みは投稿の作成・編集時に非同期で計算し、削除時に取り除く
+type SemanticSearchUseCase struct {
+	EmbeddingDAO *dao.EmbeddingDAO
+	FindDAO      *dao.FindDAO
+	quota        *QuotaUseCase
+	store        vectorstore.Store
+	queries      *cache.LRU[[]float32] // 検索クエリの埋め込み
+	jobs         chan indexJob
+	stop         context.CancelFunc // ワーカーを止める
+	done         chan struct{}      // ワーカーが止まると閉じる
+}
+
+// NewSemanticSearchUseCase 初期化と同時に、保存済みの埋め込みの読み込みと埋め込みのワーカーを起動する
+func NewSemanticSearchUseCase(embeddingDAO *dao.EmbeddingDAO, findDAO *dao.FindDAO, quota *QuotaUseCase) *SemanticSearchUseCase {
+	uc := &SemanticSearchUseCase{
+		EmbeddingDAO: embeddingDAO,
+		FindDAO:      findDAO,
+		quota:        quota,
+		store:        vectorstore.NewLocal(),
+		queries:      cache.NewLRU[[]float32](queryEmbeddingCacheSize),
+		jobs:         make(chan indexJob, indexQueueSize),
+		done:         make(chan struct{}),
+	}
+	ctx, stop := context.WithCancel(context.Background())
+	uc.stop = stop
+	go func() {
+		defer close(uc.done)
+		uc.work(ctx)
+	}()
+	return uc
+}
+
+// Close 埋め込みのワーカーを止める (サーバーの終了時に呼ぶ)
+// 処理中のまとまりは最後まで保存し、ctx の期限まで待つ。埋め込み待ちの投稿は次回起動時にまとめて埋め込む
+func (uc *SemanticSearchUseCase) Close(ctx context.Context) {
+	uc.stop()
+	select {
+	case <-uc.done:
+		log.Printf("[semantic_search_usecase.go] 埋め込みのワーカーを停止")
+	case <-ctx.Done():
+		log.Printf("[semantic_search_usecase.go] 埋め込みのワーカーの停止を待ちきれませんでした: %v", ctx.Err())
+	}
+}
+
+// IndexPost 投稿の埋め込みの計算を予約する (投稿の作成・編集を待たせないよう、計算はワーカーが後で行う)
+func (uc *SemanticSearchUseCase) IndexPost(postID, content string) {
+	select {
+	case uc.jobs <- indexJob{postID: postID, content: content}:
+	default:
+		log.Printf("[semantic_search_usecase.go] 埋め込み待ちの投稿が多すぎるため後回しにする (post_id: %s)", postID)
+	}
+}
+
+// RemovePost 削除された投稿の埋め込みをベクトルストアから取り除く (保存済みの埋め込みは投稿と同じトランザクションで削除する)
+func (uc *SemanticSearchUseCase) RemovePost(postID string) {
+	uc.store.Delete(postID)
+}
+
+// SearchPosts クエリと意味の近い投稿を類似度の高い順に最大 limit 件返す (viewerID は閲覧者のID、未ログインなら空)
+// hybrid ならクエリの語を含む投稿も候補に加え、類似度とクエリの語を含む割合を keywordWeight の重みで合成して順位付けする
+func (uc *SemanticSearchUseCase) SearchPosts(ctx context.Context, query, viewerID string, limit int, hybrid bool, keywordWeight float64) ([]model.PostSearchResult, error) {
+	query = strings.TrimSpace(query)
+	if query == "" || prompt.RuneLen(query) > maxSemanticQueryLen {
+		return nil, search.ErrInvalidQuery.Detailf("q は必須項目で%d文字以内である必要がある", maxSemanticQueryLen)
+	}
+	if limit <= 0 {
+		limit = defaultSemanticLimit
+	}
+	limit = min(limit, maxSemanticLimit)
+	if keywordWeight <= 0 || keywordWeight >= 1 {
+		keywordWeight = defaultKeywordWeight
+	}
+
+	vector, err := uc.embedQuery(ctx, query, viewerID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 類似度の高い投稿と、ハイブリッドならクエリを含む投稿を候補にする
+	candidateCount := limit * semanticCandidateFactor
+	similarities := make(map[string]float64)
+	var candidateIDs []string
+	for _, match := range uc.store.Search(vector, candidateCount) {
+		similarities[match.ID] = match.Score
+		candidateIDs = append(candidateIDs, match.ID)
+	}
+	if hybrid {
+		keywordPosts, err := uc.FindDAO.FindPostsByQuery(ctx, search.AllOf(search.Terms(query)), viewerID, candidateCount)
+		if err != nil {
+			return nil, fmt.Errorf("キーワード検索失敗: %w", err)
+		}
+		for _, post := range keywordPosts {
+			if _, ok := similarities[post.PostID]; ok {
+				continue
+			}
+			similarities[post.PostID], _ = uc.store.Similarity(post.PostID, vector)
+			candidateIDs = append(candidateIDs, post.PostID)
+		}
+	}
+
+	// 削除された・閲覧者から見えない投稿は除かれる
+	posts, err := uc.FindDAO.GetPostsByIDs(ctx, candidateIDs, viewerID)
+	if err != nil {
+		return nil, fmt.Errorf("投稿の取得失敗: %w", err)
+	}
+	results := make([]model.PostSearchResult, 0, len(posts))
+	for _, post := range posts {
+		result := model.PostSearchResult{Post: post, Similarity: similarities[post.PostID]}
+		result.Score = result.Similarity
+		if hybrid {
+			result.KeywordScore = keywordScore(query, post.Content)
+			result.Score = (1-keywordWeight)*result.Similarity + keywordWeight*result.KeywordScore
+		}
+		results = append(results, result)
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+// embedQuery ヘルパー関数: 検索クエリの埋め込みを返す (同じクエリはキャッシュを使い、使用量にも数えない)
+func (uc *SemanticSearchUseCase) embedQuery(ctx context.Context, query, viewerID string) ([]float32, error) {
+	if vector, ok := uc.queries.Get(query); ok {
+		return vector, nil
+	}
+	if err := uc.quota.Check(ctx, viewerID); err != nil {
+		return nil, err
+	}
+	vectors, err := uc.EmbeddingDAO.EmbedTextsForTask(ctx, []string{query}, dao.EmbeddingTaskQuery)
+	if err != nil {
+		return nil, fmt.Errorf("検索クエリの埋め込み生成失敗: %w", err)
+	}
+	// 埋め込みAPIはトークン使用量を返さないため呼び出し回数のみ記録する
+	uc.quota.Record(ctx, viewerID, "semantic_search", nil)
+	uc.queries.Set(query, "", vectors[0], queryEmbeddingCacheTTL)
+	return vectors[0], nil
+}
+
+// work ヘルパー関数: 保存済みの埋め込みを読み込み、埋め込みの無い投稿を埋め込んでから、埋め込み待ちの投稿をまとめて処理する
+func (uc *SemanticSearchUseCase) work(ctx context.Context) {
+	err := uc.EmbeddingDAO.FetchPostEmbeddings(ctx, func(embedding model.PostEmbedding) {
+		uc.store.Upsert(embedding.PostID, embedding.Vector)
+	})
+	if err != nil {
+		log.Printf("[semantic_search_usecase.go] 投稿の埋め込みの読み込み失敗: %v", err)
+	}
+	log.Printf("[semantic_search_usecase.go] 投稿の埋め込みを%d件読み込み", uc.store.Len())
+
+	if posts, err := uc.EmbeddingDAO.FetchUnindexedPosts(ctx, indexBackfillLimit); err == nil {
+		for start := 0; start < len(posts) && ctx.Err() == nil; start += indexBatchSize {
+			var batch []indexJob
+			for _, post := range posts[start:min(start+indexBatchSize, len(posts))] {
+				batch = append(batch, indexJob{postID: post.PostID, content: post.Content})
+			}
+			uc.index(ctx, batch)
+		}
+	}
+
+	for {
+		var job indexJob
+		select {
+		case <-ctx.Done():
+			return
+		case job = <-uc.jobs:
+		}
+		batch := []indexJob{job}
+	drain:
+		for len(batch) < indexBatchSize {
+			select {
+			case next := <-uc.jobs:
+				batch = append(batch, next)
+			default:
+				break drain
+			}
+		}
+		uc.index(ctx, batch)
+	}
+}
+
+// index ヘルパー関数: 投稿の埋め込みを計算して保存し、ベクトルストアに反映する
+// 同じ投稿が複数回あれば最後の内容を使い、計算中に削除された投稿は保存しない
+// ワーカーを止めても、始めたまとまりは最後まで処理する
+func (uc *SemanticSearchUseCase) index(ctx context.Context, batch []indexJob) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), indexJobTimeout)
+	defer cancel()
+
+	latest := make(map[string]int)
+	var jobs []indexJob
+	for _, job := range batch {
+		if i, ok := latest[job.postID]; ok {
+			jobs[i] = job
+			continue
+		}
+		latest[job.postID] = len(jobs)
+		jobs = append(jobs, job)
+	}
+
+	// 埋め込みはユーザーに紐づけず、全体の上限のみ適用する
+	if err := uc.quota.Check(ctx, ""); err != nil {
+		log.Printf("[semantic_search_usecase.go] 使用量の上限のため投稿%d件の埋め込みを見送り: %v", len(jobs), err)
+		return
+	}
+	texts := make([]string, len(jobs))
+	for i, job := range jobs {
+		texts[i] = job.content
+	}
+
+	vectors, err := uc.EmbeddingDAO.EmbedTextsForTask(ctx, texts, dao.EmbeddingTaskDocument)
+	if err != nil {
+		log.Printf("[semantic_search_usecase.go] 投稿%d件の埋め込み生成失敗: %v", len(jobs), err)
+		return
+	}
+	uc.quota.Record(ctx, "", "index_posts", nil)
+
+	for i, job := range jobs {
+		saved, err := uc.EmbeddingDAO.SavePostEmbedding(ctx, model.PostEmbedding{PostID: job.postID, Vector: vectors[i], UpdatedAt: time.Now()})
+		if err != nil {
+			log.Printf("[semantic_search_usecase.go] 投稿の埋め込みの保存失敗 (post_id: %s): %v", job.postID, err)
+			continue
+		}
+		if saved {
+			uc.store.Upsert(job.postID, vectors[i])
+		} else {
+			uc.store.Delete(job.postID)
+		}
+	}
+}
+
+// keywordScore ヘルパー関数: クエリの語 (空白区切り) のうち投稿に含まれるものの割合 (大文字小文字は区別しない)
+func keywordScore(query, content string) float64 {
+	terms := strings.Fields(strings.ToLower(query))
+	if len(terms) == 0 {
+		return 0
+	}
+	content = strings.ToLower(content)
+	matched := 0
+	for _, term := range terms {
+		if strings.Contains(content, term) {
+			matched++
+		}
+	}
+	return float64(matched) / float64(len(terms))
+}
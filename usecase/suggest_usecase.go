@@ -0,0 +1,203 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"twitter/dao"
+	"twitter/model"
+	"twitter/prompt"
+	"twitter/search"
+)
+
+// 入力補完に使う値
+const (
+	defaultSuggestLimit    = 10
+	maxSuggestLimit        = 20
+	maxSuggestQueryLen     = 50                  // 入力の最大文字数
+	suggestCandidateFactor = 5                   // 表示件数の何倍のユーザーを前方一致で選んでから順位付けするか
+	hashtagWindow          = 30 * 24 * time.Hour // 起動時にハッシュタグを集計する期間
+	hashtagLoadLimit       = 10000               // 起動時にハッシュタグを集計する投稿の最大数
+	followingBonus         = 3.0                 // 閲覧者がフォロー中のユーザーへの加点
+	followedByFollowingW   = 1.5                 // フォロー中のユーザーからのフォロー数 (対数) の重み
+)
+
+// hashtagCount ハッシュタグの表記と使われた数
+type hashtagCount struct {
+	tag   string // 最初に見つかった表記
+	count int
+}
+
+// SuggestUseCase ユーザーとハッシュタグの入力補完
+// ユーザーIDと名前 (かな・ローマ字の読みを含む)、ハッシュタグの前方一致の索引をメモリに保持し、
+// ユーザー登録・プロフィール更新・投稿の作成時に更新する
+type SuggestUseCase struct {
+	FindDAO  *dao.FindDAO
+	users    *search.PrefixIndex
+	hashtags *search.PrefixIndex
+	mu       sync.RWMutex
+	counts   map[string]*hashtagCount // 小文字にしたハッシュタグ -> 表記と使われた数
+}
+
+// NewSuggestUseCase 初期化と同時に、ユーザーと最近の投稿のハッシュタグの索引をバックグラウンドで作成する
+func NewSuggestUseCase(findDAO *dao.FindDAO) *SuggestUseCase {
+	uc := &SuggestUseCase{
+		FindDAO:  findDAO,
+		users:    search.NewPrefixIndex(),
+		hashtags: search.NewPrefixIndex(),
+		counts:   make(map[string]*hashtagCount),
+	}
+	go uc.load(context.Background())
+	return uc
+}
+
+// RefreshUser ユーザーIDと名前の読みを索引に登録し直す
+func (uc *SuggestUseCase) RefreshUser(userID, name string) {
+	uc.users.Set(userID, userReadings(userID, name))
+}
+
+// AddHashtags 投稿に含まれるハッシュタグを索引に加え、使われた数を数える
+func (uc *SuggestUseCase) AddHashtags(content string) {
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+	for _, tag := range search.Hashtags(content) {
+		key := strings.ToLower(tag)
+		if counted, ok := uc.counts[key]; ok {
+			counted.count++
+			continue
+		}
+		uc.counts[key] = &hashtagCount{tag: tag, count: 1}
+		uc.hashtags.Set(key, search.Readings(tag))
+	}
+}
+
+// Suggest 入力 q に前方一致するユーザーとハッシュタグをそれぞれ最大 limit 件返す (viewerID は閲覧者のID、未ログインなら空)
+// ユーザーはフォロワー数と閲覧者のフォロー関係 (フォロー中か、フォロー中のユーザーからのフォロー数) で、
+// ハッシュタグは最近の投稿で使われた数で順位付けする
+// q が # で始まればハッシュタグのみ、@ で始まればユーザーのみを返す
+func (uc *SuggestUseCase) Suggest(ctx context.Context, q, viewerID string, limit int) (*model.Suggestions, error) {
+	q = strings.TrimSpace(q)
+	if q == "" || prompt.RuneLen(q) > maxSuggestQueryLen {
+		return nil, search.ErrInvalidQuery.Detailf("q は必須項目で%d文字以内である必要がある", maxSuggestQueryLen)
+	}
+	if limit <= 0 {
+		limit = defaultSuggestLimit
+	}
+	limit = min(limit, maxSuggestLimit)
+
+	wantUsers, wantHashtags := true, true
+	switch {
+	case strings.HasPrefix(q, "#") || strings.HasPrefix(q, "＃"):
+		wantUsers = false
+	case strings.HasPrefix(q, "@") || strings.HasPrefix(q, "＠"):
+		wantHashtags = false
+	}
+	readings := search.Readings(strings.TrimLeft(q, "#＃@＠"))
+	if len(readings) == 0 {
+		return nil, search.ErrInvalidQuery.Detailf("q に # や @ 以外の文字が必要です")
+	}
+
+	suggestions := &model.Suggestions{Users: []model.UserSuggestion{}, Hashtags: []model.HashtagSuggestion{}}
+	if wantUsers {
+		users, err := uc.suggestUsers(ctx, readings, viewerID, limit)
+		if err != nil {
+			return nil, err
+		}
+		suggestions.Users = users
+	}
+	if wantHashtags {
+		suggestions.Hashtags = uc.suggestHashtags(readings, limit)
+	}
+	return suggestions, nil
+}
+
+// suggestUsers ヘルパー関数: 読みが前方一致するユーザーを選び、フォロー関係で順位付けする
+func (uc *SuggestUseCase) suggestUsers(ctx context.Context, readings []string, viewerID string, limit int) ([]model.UserSuggestion, error) {
+	candidateIDs := uc.users.Lookup(readings, limit*suggestCandidateFactor)
+	// 前方一致で読みが短い (入力に近い) ほど少し加点する
+	closeness := make(map[string]float64, len(candidateIDs))
+	for i, userID := range candidateIDs {
+		closeness[userID] = 1 / float64(1+i)
+	}
+
+	users, err := uc.FindDAO.FetchUserSuggestions(ctx, candidateIDs, viewerID)
+	if err != nil {
+		return nil, fmt.Errorf("入力補完のユーザー取得失敗: %w", err)
+	}
+	for i := range users {
+		user := &users[i]
+		user.Score = math.Log1p(float64(user.FollowerCount)) +
+			followedByFollowingW*math.Log1p(float64(user.FollowedByFollowing)) +
+			closeness[user.UserID]
+		if user.Following {
+			user.Score += followingBonus
+		}
+	}
+	sort.SliceStable(users, func(i, j int) bool {
+		return users[i].Score > users[j].Score
+	})
+	if len(users) > limit {
+		users = users[:limit]
+	}
+	if users == nil {
+		users = []model.UserSuggestion{}
+	}
+	return users, nil
+}
+
+// suggestHashtags ヘルパー関数: 読みが前方一致するハッシュタグを使われた数の多い順に選ぶ
+func (uc *SuggestUseCase) suggestHashtags(readings []string, limit int) []model.HashtagSuggestion {
+	keys := uc.hashtags.Lookup(readings, limit*suggestCandidateFactor)
+
+	uc.mu.RLock()
+	hashtags := make([]model.HashtagSuggestion, 0, len(keys))
+	for _, key := range keys {
+		if counted, ok := uc.counts[key]; ok {
+			hashtags = append(hashtags, model.HashtagSuggestion{Tag: counted.tag, PostCount: counted.count})
+		}
+	}
+	uc.mu.RUnlock()
+
+	sort.SliceStable(hashtags, func(i, j int) bool {
+		return hashtags[i].PostCount > hashtags[j].PostCount
+	})
+	if len(hashtags) > limit {
+		hashtags = hashtags[:limit]
+	}
+	return hashtags
+}
+
+// load ヘルパー関数: 全ユーザーと最近の投稿のハッシュタグから索引を作成する
+func (uc *SuggestUseCase) load(ctx context.Context) {
+	names, err := uc.FindDAO.FetchUserNames(ctx)
+	if err != nil {
+		log.Printf("[suggest_usecase.go] 入力補完のユーザーの読み込み失敗: %v", err)
+	} else {
+		keys := make(map[string][]string, len(names))
+		for userID, name := range names {
+			keys[userID] = userReadings(userID, name)
+		}
+		uc.users.Load(keys)
+		log.Printf("[suggest_usecase.go] 入力補完のユーザーを%d件読み込み", uc.users.Len())
+	}
+
+	contents, err := uc.FindDAO.FetchRecentPostContents(ctx, time.Now().Add(-hashtagWindow), hashtagLoadLimit)
+	if err != nil {
+		log.Printf("[suggest_usecase.go] 入力補完のハッシュタグの読み込み失敗: %v", err)
+		return
+	}
+	for _, content := range contents {
+		uc.AddHashtags(content)
+	}
+	log.Printf("[suggest_usecase.go] 入力補完のハッシュタグを%d件読み込み", uc.hashtags.Len())
+}
+
+// userReadings ヘルパー関数: ユーザーIDと名前の読みの一覧
+func userReadings(userID, name string) []string {
+	return append(search.Readings(userID), search.Readings(name)...)
+}
@@ -1,9 +1,10 @@
 package usecase
 
 import (
-	"errors"
+	"context"
 	"twitter/dao"
 	"twitter/model"
+	"twitter/validate"
 )
 
 type TimelineUseCase struct {
@@ -15,25 +16,25 @@ func NewTimelineUseCase(TimelineDAO *dao.TimelineDAO) *TimelineUseCase {
 }
 
 // GetUserTimeline ログインユーザーのタイムラインを取得
-func (uc *TimelineUseCase) GetUserTimeline(userID string) ([]model.Post, error) {
-	if userID == "" {
-		return nil, errors.New("[timeline_usecase.go] auth_id が無効: 必須項目")
+func (uc *TimelineUseCase) GetUserTimeline(ctx context.Context, userID string) ([]model.Post, error) {
+	if err := validate.Check(validate.Required("auth_id", userID)); err != nil {
+		return nil, err
 	}
-	return uc.TimelineDAO.FetchUserTimeline(userID)
+	return uc.TimelineDAO.FetchUserTimeline(ctx, userID)
 }
 
-// GetUserPosts 指定ユーザーの投稿一覧を取得
-func (uc *TimelineUseCase) GetUserPosts(userID string) ([]model.Post, error) {
-	if userID == "" {
-		return nil, errors.New("[timeline_usecase.go] auth_id が無効: 必須項目")
+// GetUserPosts 指定ユーザーの投稿一覧を取得 (viewerID は閲覧者のID、未ログインなら空)
+func (uc *TimelineUseCase) GetUserPosts(ctx context.Context, userID, viewerID string) ([]model.Post, error) {
+	if err := validate.Check(validate.Required("auth_id", userID)); err != nil {
+		return nil, err
 	}
-	return uc.TimelineDAO.FetchUserPosts(userID)
+	return uc.TimelineDAO.FetchUserPosts(ctx, userID, viewerID)
 }
 
-// GetLikedPosts 指定ユーザーのいいねした投稿一覧を取得
-func (uc *TimelineUseCase) GetLikedPosts(userID string) ([]model.Post, error) {
-	if userID == "" {
-		return nil, errors.New("[timeline_usecase.go] user_id が無効: 必須項目")
+// GetLikedPosts 指定ユーザーのいいねした投稿一覧を取得 (viewerID は閲覧者のID、未ログインなら空)
+func (uc *TimelineUseCase) GetLikedPosts(ctx context.Context, userID, viewerID string) ([]model.Post, error) {
+	if err := validate.Check(validate.Required("user_id", userID)); err != nil {
+		return nil, err
 	}
-	return uc.TimelineDAO.FetchLikedPosts(userID)
+	return uc.TimelineDAO.FetchLikedPosts(ctx, userID, viewerID)
 }
@@ -0,0 +1,77 @@
+package usecase
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"math"
+	"strings"
+	"time"
+	"twitter/model"
+)
+
+const (
+	embeddingPostLimit  = 20             // 埋め込みに使う最近の投稿数
+	embeddingStaleAfter = 24 * time.Hour // 埋め込みを再計算するまでの期間
+)
+
+// embeddingSourceText ヘルパー関数: ユーザーの埋め込み元テキストを作成
+func embeddingSourceText(user model.User, posts []string) string {
+	text := "名前: " + user.Name + "\n自己紹介: " + nullableToString(user.Bio)
+	if len(posts) > 0 {
+		text += "\n投稿:\n" + strings.Join(posts, "\n")
+	}
+	return text
+}
+
+// hashText ヘルパー関数: テキストの SHA-256 ハッシュを16進文字列で返す
+func hashText(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:])
+}
+
+// isEmbeddingStale ヘルパー関数: 埋め込みベクトルが古くなっているか判定
+func isEmbeddingStale(embedding model.UserEmbedding) bool {
+	return time.Since(embedding.UpdatedAt) > embeddingStaleAfter
+}
+
+// normalize ヘルパー関数: ベクトルを長さ1に正規化 (ゼロベクトルはそのまま)
+func normalize(v []float32) []float64 {
+	var norm float64
+	for _, x := range v {
+		norm += float64(x) * float64(x)
+	}
+	norm = math.Sqrt(norm)
+
+	result := make([]float64, len(v))
+	if norm == 0 {
+		return result
+	}
+	for i, x := range v {
+		result[i] = float64(x) / norm
+	}
+	return result
+}
+
+// combineVectors ヘルパー関数: 正規化済みベクトルを足し合わせて再び正規化
+func combineVectors(a, b []float64) []float64 {
+	if len(a) != len(b) {
+		return a
+	}
+	sum := make([]float32, len(a))
+	for i := range a {
+		sum[i] = float32(a[i] + b[i])
+	}
+	return normalize(sum)
+}
+
+// cosineSimilarity ヘルパー関数: 正規化済みベクトルのコサイン類似度を計算
+func cosineSimilarity(a, b []float64) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var dot float64
+	for i := range a {
+		dot += a[i] * b[i]
+	}
+	return dot
+}
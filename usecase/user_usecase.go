@@ -1,55 +1,61 @@
 package usecase
 
 import (
-	"errors"
+	"context"
 	"twitter/dao"
 	"twitter/model"
+	"twitter/validate"
 )
 
 type UserUseCase struct {
-	UserDAO *dao.UserDAO
+	UserDAO        *dao.UserDAO
+	ImageUseCase   *ImageUseCase
+	SuggestUseCase *SuggestUseCase
+	Guard          *RestrictionGuard
 }
 
-func NewUserUseCase(UserDAO *dao.UserDAO) *UserUseCase {
-	return &UserUseCase{UserDAO: UserDAO}
+func NewUserUseCase(UserDAO *dao.UserDAO, imageUseCase *ImageUseCase, suggestUseCase *SuggestUseCase, guard *RestrictionGuard) *UserUseCase {
+	return &UserUseCase{UserDAO: UserDAO, ImageUseCase: imageUseCase, SuggestUseCase: suggestUseCase, Guard: guard}
 }
 
 // GetUser ユーザー情報を取得する
-func (uc *UserUseCase) GetUser(userID string) (*model.User, error) {
-	if userID == "" {
-		return nil, errors.New("[user_usecase.go] user_id が無効: 必須項目")
+func (uc *UserUseCase) GetUser(ctx context.Context, userID string) (*model.User, error) {
+	if err := validate.Check(validate.Required("user_id", userID)); err != nil {
+		return nil, err
 	}
-	return uc.UserDAO.GetUser(userID)
+	return uc.UserDAO.GetUser(ctx, userID)
 }
 
 // UpdateProfile プロフィールを更新する
-func (uc *UserUseCase) UpdateProfile(user model.User) error {
-	if user.UserID == "" {
-		return errors.New("[user_usecase.go] user_id が無効: 必須項目")
+func (uc *UserUseCase) UpdateProfile(ctx context.Context, user model.User) error {
+	if err := validateUser(user); err != nil {
+		return err
 	}
-	if user.Name == "" || len(user.Name) > 255 {
-		return errors.New("[user_usecase.go] 名前が無効: 必須項目で255文字以内である必要がある")
+	if err := uc.Guard.CheckNotSuspended(ctx, user.UserID); err != nil {
+		return err
 	}
-	if user.Location != nil && len(*user.Location) > 100 {
-		return errors.New("[user_usecase.go] 位置情報が無効: 100文字以内である必要がある")
+	if err := uc.UserDAO.UpdateUser(ctx, user); err != nil {
+		return err
 	}
-	return uc.UserDAO.UpdateUser(user)
+	uc.ImageUseCase.Enqueue(ctx, model.ImageKindProfile, user.UserID, user.ProfileImgURL)
+	uc.SuggestUseCase.RefreshUser(user.UserID, user.Name)
+	return nil
 }
 
 // GetUpdatedUser 更新後のユーザー情報を取得する
-func (uc *UserUseCase) GetUpdatedUser(userID string) (*model.User, error) {
-	if userID == "" {
-		return nil, errors.New("[user_usecase.go] user_id が無効: 必須項目")
+func (uc *UserUseCase) GetUpdatedUser(ctx context.Context, userID string) (*model.User, error) {
+	if err := validate.Check(validate.Required("user_id", userID)); err != nil {
+		return nil, err
 	}
-	return uc.UserDAO.GetUser(userID)
+	return uc.UserDAO.GetUser(ctx, userID)
 }
 
 // GetTopUsersByTweetCount ツイート数の多い順にユーザ一覧を取得
-func (uc *UserUseCase) GetTopUsersByTweetCount(limit int) ([]model.User, error) {
-	return uc.UserDAO.GetTopUsersByTweetCount(limit)
+func (uc *UserUseCase) GetTopUsersByTweetCount(ctx context.Context, limit int) ([]model.User, error) {
+	return uc.UserDAO.GetTopUsersByTweetCount(ctx, limit)
 }
 
 // GetTopUsersByLikes いいね数の多い順にユーザ一覧を取得
-func (uc *UserUseCase) GetTopUsersByLikes(limit int) ([]model.User, error) {
-	return uc.UserDAO.GetTopUsersByLikes(limit)
+func (uc *UserUseCase) GetTopUsersByLikes(ctx context.Context, limit int) ([]model.User, error) {
+	return uc.UserDAO.GetTopUsersByLikes(ctx, limit)
 }
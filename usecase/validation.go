@@ -0,0 +1,106 @@
+package usecase
+
+import (
+	"time"
+	"twitter/model"
+	"twitter/validate"
+)
+
+// 入力の上限 (文字数はバイト数ではなく Unicode の文字数で数える)
+const (
+	maxUserIDLen   = 255
+	maxUserNameLen = 50
+	maxUserBioLen  = 160
+	maxLocationLen = 100
+	maxURLLen      = 2048
+	maxPostLen     = 280
+	maxReasonLen   = 500
+)
+
+// earliestBirthday 誕生日に指定できる最も古い日付
+var earliestBirthday = time.Date(1900, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// validateUser ユーザー登録・プロフィール更新の入力を検証
+func validateUser(user model.User) error {
+	return validate.Check(
+		validate.Length("user_id", user.UserID, 1, maxUserIDLen),
+		validate.Length("name", user.Name, 1, maxUserNameLen),
+		validate.MaxLength("bio", user.Bio, maxUserBioLen),
+		validate.URL("profile_img_url", user.ProfileImgURL, maxURLLen),
+		validate.URL("header_img_url", user.HeaderImgURL, maxURLLen),
+		validate.MaxLength("location", user.Location, maxLocationLen),
+		validate.DateBetween("birthday", user.Birthday, earliestBirthday, time.Now()),
+	)
+}
+
+// validateNewPost 投稿・リプライの作成の入力を検証 (リプライなら parent_post_id も必須)
+func validateNewPost(post model.Post, reply bool) error {
+	rules := []validate.Rule{
+		validate.Length("user_id", post.UserID, 1, maxUserIDLen),
+		validate.Length("content", post.Content, 1, maxPostLen),
+		validate.URL("img_url", post.ImgURL, maxURLLen),
+	}
+	if reply {
+		rules = append(rules, validate.Required("parent_post_id", stringValue(post.ParentPostID)))
+	}
+	return validate.Check(rules...)
+}
+
+// validatePostUpdate 投稿の更新の入力を検証
+func validatePostUpdate(post model.Post) error {
+	return validate.Check(
+		validate.Required("post_id", post.PostID),
+		validate.Length("content", post.Content, 1, maxPostLen),
+		validate.URL("img_url", post.ImgURL, maxURLLen),
+	)
+}
+
+// validateFollow フォロー・フォロー解除の入力を検証
+func validateFollow(userID, followingUserID string) error {
+	return validate.Check(
+		validate.Required("user_id", userID),
+		validate.Required("following_user_id", followingUserID),
+	)
+}
+
+// validateBlock ブロック・ブロック解除の入力を検証 (自分自身はブロックできない)
+func validateBlock(userID, blockedUserID string) error {
+	if err := validate.Check(
+		validate.Required("user_id", userID),
+		validate.Required("blocked_user_id", blockedUserID),
+	); err != nil {
+		return err
+	}
+	if userID == blockedUserID {
+		return validate.Fail("blocked_user_id", "自分自身はブロックできません")
+	}
+	return nil
+}
+
+// validateRestriction 制限の追加の入力を検証
+func validateRestriction(userID, kind, reason string, durationHours int) error {
+	return validate.Check(
+		validate.Required("user_id", userID),
+		validate.OneOf("kind", kind, model.RestrictionSuspend, model.RestrictionShadowBan),
+		validate.Length("reason", reason, 1, maxReasonLen),
+		validate.Min("duration_hours", durationHours, 0),
+	)
+}
+
+// validateQuotaOverride AI使用量の上限の上書きの入力を検証
+func validateQuotaOverride(userID string, requestsPerDay, tokensPerDay int, reason string) error {
+	return validate.Check(
+		validate.Required("user_id", userID),
+		validate.Min("requests_per_day", requestsPerDay, 0),
+		validate.Min("tokens_per_day", tokensPerDay, 0),
+		validate.Length("reason", reason, 1, maxReasonLen),
+	)
+}
+
+// stringValue ヘルパー関数: ポインタの文字列を値にする (nil なら空)
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,129 @@
+package validate
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+	"twitter/apperr"
+	"unicode/utf8"
+)
+
+// ErrInvalidInput 入力の項目が不正 (項目ごとの理由は Fields に入る)
+var ErrInvalidInput = apperr.Validation("invalid_input", "入力が不正です")
+
+// Rule 1つの項目の検証結果 (問題が無ければ nil)
+type Rule *apperr.FieldError
+
+// Check 全ての規則を検証し、不正な項目があれば全ての理由をまとめた ErrInvalidInput を返す
+// 同じ項目で複数の規則に違反した場合は、最初の理由だけを返す
+func Check(rules ...Rule) error {
+	return CheckAs(ErrInvalidInput, rules...)
+}
+
+// CheckAs Check と同じだが、不正な項目があれば base (入力の種類ごとのエラー) に理由をまとめて返す
+func CheckAs(base *apperr.Error, rules ...Rule) error {
+	var fields []apperr.FieldError
+	seen := map[string]bool{}
+	for _, rule := range rules {
+		if rule == nil || seen[rule.Field] {
+			continue
+		}
+		seen[rule.Field] = true
+		fields = append(fields, *rule)
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+
+	messages := make([]string, len(fields))
+	for i, field := range fields {
+		messages[i] = field.Message
+	}
+	return base.Detailf("%s", strings.Join(messages, "; ")).WithFields(fields...)
+}
+
+// Fail 規則で表せない検証で不正だった項目を、Check と同じ形式のエラーにする
+func Fail(field, format string, args ...interface{}) error {
+	return Check(fail(field, format, args...))
+}
+
+// Required 空でないこと
+func Required(field, value string) Rule {
+	if strings.TrimSpace(value) == "" {
+		return fail(field, "%s は必須項目です", field)
+	}
+	return nil
+}
+
+// Length 文字数 (バイト数ではなく Unicode の文字数) が min 以上 max 以下であること (min が 1 以上なら必須)
+func Length(field, value string, min, max int) Rule {
+	if min > 0 && strings.TrimSpace(value) == "" {
+		return fail(field, "%s は必須項目です", field)
+	}
+	if n := utf8.RuneCountInString(value); n < min || n > max {
+		if min > 1 {
+			return fail(field, "%s は%d文字以上%d文字以内で入力してください", field, min, max)
+		}
+		return fail(field, "%s は%d文字以内で入力してください", field, max)
+	}
+	return nil
+}
+
+// MaxLength 文字数 (バイト数ではなく Unicode の文字数) が max 以下であること (nil と空は許す)
+func MaxLength(field string, value *string, max int) Rule {
+	if value == nil {
+		return nil
+	}
+	return Length(field, *value, 0, max)
+}
+
+// URL http または https の絶対URLであること (nil と空は許す)
+func URL(field string, value *string, maxLen int) Rule {
+	if value == nil || *value == "" {
+		return nil
+	}
+	if utf8.RuneCountInString(*value) > maxLen {
+		return fail(field, "%s は%d文字以内で入力してください", field, maxLen)
+	}
+	parsed, err := url.Parse(*value)
+	if err != nil || parsed.Host == "" || parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return fail(field, "%s は http または https のURLで入力してください", field)
+	}
+	return nil
+}
+
+// DateBetween 日付が earliest 以降 latest 以前であること (nil は許す)
+func DateBetween(field string, value *time.Time, earliest, latest time.Time) Rule {
+	if value == nil {
+		return nil
+	}
+	if value.Before(earliest) || value.After(latest) {
+		return fail(field, "%s は%sから%sまでの日付で入力してください", field, earliest.Format(time.DateOnly), latest.Format(time.DateOnly))
+	}
+	return nil
+}
+
+// OneOf 値が allowed のいずれかであること
+func OneOf(field, value string, allowed ...string) Rule {
+	for _, a := range allowed {
+		if value == a {
+			return nil
+		}
+	}
+	return fail(field, "%s は %s のいずれかで入力してください", field, strings.Join(allowed, ", "))
+}
+
+// Min 値が min 以上であること
+func Min(field string, value, min int) Rule {
+	if value < min {
+		return fail(field, "%s は%d以上で入力してください", field, min)
+	}
+	return nil
+}
+
+// fail ヘルパー関数: 項目の不正な理由を作成
+func fail(field, format string, args ...interface{}) Rule {
+	fieldErr := apperr.Field(field, fmt.Sprintf(format, args...))
+	return &fieldErr
+}
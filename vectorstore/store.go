@@ -0,0 +1,152 @@
+package vectorstore
+
+import (
+	"container/heap"
+	"math"
+	"sync"
+)
+
+// Match 検索結果の1件 (Score はコサイン類似度)
+type Match struct {
+	ID    string  `json:"id"`
+	Score float64 `json:"score"`
+}
+
+// Store IDごとの埋め込みベクトルを保持し、コサイン類似度で近いものを探すベクトルストア
+type Store interface {
+	// Upsert ベクトルを追加する (同じIDがあれば置き換える)
+	Upsert(id string, vector []float32)
+	// Delete ベクトルを削除する
+	Delete(id string)
+	// Search query との類似度が高い順に最大 k 件返す
+	Search(query []float32, k int) []Match
+	// Similarity 指定したIDのベクトルと query の類似度を返す (無ければ false)
+	Similarity(id string, query []float32) (float64, bool)
+	// Len 保持しているベクトルの数
+	Len() int
+}
+
+// Local プロセス内のメモリに全てのベクトルを保持し、総当たりで検索するベクトルストア
+// 複数の goroutine から同時に使える
+type Local struct {
+	mu      sync.RWMutex
+	vectors map[string][]float32 // 長さ1に正規化したベクトル
+}
+
+// NewLocal 空のベクトルストアを作成
+func NewLocal() *Local {
+	return &Local{vectors: make(map[string][]float32)}
+}
+
+// Upsert ベクトルを長さ1に正規化して追加する (ゼロベクトルは追加しない)
+func (s *Local) Upsert(id string, vector []float32) {
+	normalized, ok := normalize(vector)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !ok {
+		delete(s.vectors, id)
+		return
+	}
+	s.vectors[id] = normalized
+}
+
+// Delete ベクトルを削除する
+func (s *Local) Delete(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.vectors, id)
+}
+
+// Search query との類似度が高い順に最大 k 件返す (次元の異なるベクトルは無視する)
+func (s *Local) Search(query []float32, k int) []Match {
+	normalized, ok := normalize(query)
+	if !ok || k <= 0 {
+		return nil
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	top := &minHeap{}
+	for id, vector := range s.vectors {
+		if len(vector) != len(normalized) {
+			continue
+		}
+		score := dot(vector, normalized)
+		if top.Len() < k {
+			heap.Push(top, Match{ID: id, Score: score})
+		} else if score > (*top)[0].Score {
+			(*top)[0] = Match{ID: id, Score: score}
+			heap.Fix(top, 0)
+		}
+	}
+
+	matches := make([]Match, top.Len())
+	for i := len(matches) - 1; i >= 0; i-- {
+		matches[i] = heap.Pop(top).(Match)
+	}
+	return matches
+}
+
+// Similarity 指定したIDのベクトルと query の類似度を返す
+func (s *Local) Similarity(id string, query []float32) (float64, bool) {
+	normalized, ok := normalize(query)
+	if !ok {
+		return 0, false
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	vector, ok := s.vectors[id]
+	if !ok || len(vector) != len(normalized) {
+		return 0, false
+	}
+	return dot(vector, normalized), true
+}
+
+// Len 保持しているベクトルの数
+func (s *Local) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.vectors)
+}
+
+// normalize ヘルパー関数: ベクトルを長さ1に正規化した複製を返す (ゼロベクトルなら false)
+func normalize(vector []float32) ([]float32, bool) {
+	var norm float64
+	for _, x := range vector {
+		norm += float64(x) * float64(x)
+	}
+	if norm == 0 {
+		return nil, false
+	}
+	norm = math.Sqrt(norm)
+
+	normalized := make([]float32, len(vector))
+	for i, x := range vector {
+		normalized[i] = float32(float64(x) / norm)
+	}
+	return normalized, true
+}
+
+// dot ヘルパー関数: 内積 (正規化済みのベクトルならコサイン類似度)
+func dot(a, b []float32) float64 {
+	var sum float64
+	for i := range a {
+		sum += float64(a[i]) * float64(b[i])
+	}
+	return sum
+}
+
+// minHeap 類似度の低いものが先頭に来るヒープ (上位 k 件の選択に使う)
+type minHeap []Match
+
+func (h minHeap) Len() int            { return len(h) }
+func (h minHeap) Less(i, j int) bool  { return h[i].Score < h[j].Score }
+func (h minHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *minHeap) Push(x interface{}) { *h = append(*h, x.(Match)) }
+func (h *minHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	*h = old[:n-1]
+	return item
+}